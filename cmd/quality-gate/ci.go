@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/service"
+)
+
+// runCI implements `quality-gate ci generate`: it writes the pipeline of a
+// CI system running the hook types of quality.yml. With --check, it only
+// reports whether the pipeline file is up to date.
+func runCI(args []string, configPath string, force bool) int {
+	usage := "Usage: quality-gate ci generate --provider " + strings.Join(service.CIProviders, "|") + " [--file PATH] [--check]"
+	if len(args) == 0 || args[0] != "generate" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("ci generate", flag.ContinueOnError)
+	providerFlag := flags.String("provider", "", "CI system: "+strings.Join(service.CIProviders, ", "))
+	configFlag := flags.String("config", configPath, "Path to the configuration file")
+	fileFlag := flags.String("file", "", "Path of the pipeline file, or - for standard output (default: the provider's)")
+	versionFlag := flags.String("install-version", installVersion(), "quality-gate release the pipeline installs, or latest")
+	checkFlag := flags.Bool("check", false, "Exit with 1 when the pipeline file is out of date, without changing it")
+	forceFlag := flags.Bool("force", force, "Overwrite a pipeline file that was not generated by quality-gate")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if service.CIPipelineFile(*providerFlag) == "" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+	file := *fileFlag
+	if file == "" || file == "-" {
+		file = service.CIPipelineFile(*providerFlag)
+	}
+	pipeline, err := service.GenerateCI(cfg, service.CIOptions{
+		Provider:    *providerFlag,
+		Version:     *versionFlag,
+		ConfigPath:  filepath.ToSlash(relativePath(cfg.Path)),
+		File:        filepath.ToSlash(file),
+		ProjectPath: ".",
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to generate the %s pipeline: %v\n", *providerFlag, err)
+		return 1
+	}
+
+	if *fileFlag == "-" {
+		fmt.Print(pipeline)
+		return 0
+	}
+	current, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if bytes.Equal(current, []byte(pipeline)) {
+		fmt.Printf("✅ %s is up to date\n", file)
+		return 0
+	}
+	if *checkFlag {
+		fmt.Printf("⚠️  %s does not match %s\n", file, relativePath(cfg.Path))
+		fmt.Printf("Run '%s' to update it\n", strings.Join(regenerateArgs(*providerFlag, *fileFlag, *configFlag), " "))
+		return 1
+	}
+	if current != nil && !*forceFlag && !bytes.Contains(current, []byte("quality-gate ci generate")) {
+		fmt.Fprintf(os.Stderr, "Error: %s already exists. Use --force to overwrite\n", file)
+		return 1
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write %s: %v\n", file, err)
+		return 1
+	}
+	if err := os.WriteFile(file, []byte(pipeline), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write %s: %v\n", file, err)
+		return 1
+	}
+	fmt.Printf("✅ Wrote %s\n", file)
+	return 0
+}
+
+// installVersion returns the release of the running binary, which pipelines
+// install by default, or latest for development builds.
+func installVersion() string {
+	if Version == "" || strings.Contains(Version, "-") {
+		return "latest"
+	}
+	return Version
+}
+
+// regenerateArgs returns the command regenerating a pipeline file.
+func regenerateArgs(provider, file, configPath string) []string {
+	args := []string{"quality-gate", "ci", "generate", "--provider", provider}
+	if configPath != "" {
+		args = append(args, "--config", configPath)
+	}
+	if file != "" {
+		args = append(args, "--file", file)
+	}
+	return args
+}
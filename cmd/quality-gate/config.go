@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig implements `quality-gate config show [--resolved]`: it prints
+// the configuration file, or the effective configuration once extends,
+// include, the local override and disable have been applied.
+func runConfig(args []string, configPath string) int {
+	if len(args) == 0 || args[0] != "show" {
+		fmt.Fprintln(os.Stderr, "Usage: quality-gate config show [--resolved] [--config PATH]")
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config show", flag.ContinueOnError)
+	resolvedFlag := flags.Bool("resolved", false, "Print the effective configuration after merging")
+	configFlag := flags.String("config", configPath, "Path to the configuration file")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	path, err := config.FindConfig(*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if !*resolvedFlag {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("# %s\n%s", path, data)
+		return 0
+	}
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	// The resolved configuration has the meaning of the current version
+	cfg.Version = config.CurrentVersion
+	fmt.Println("# Resolved configuration, merged from:")
+	for _, source := range cfg.Sources {
+		fmt.Printf("#   %s\n", source)
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"github.com/dmux/go-quality-gate/internal/infra/git"
+	"github.com/dmux/go-quality-gate/internal/infra/shell"
+	"github.com/dmux/go-quality-gate/internal/service"
+)
+
+// runDoctor implements `quality-gate doctor`: it prints a checklist of the
+// local setup and returns a non-zero exit code when a check fails.
+func runDoctor(args []string, output, configPath string) int {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	outputFlag := flags.String("output", output, "Output format (e.g., json)")
+	configFlag := flags.String("config", configPath, "Path to the configuration file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	path, err := config.FindConfig(*configFlag)
+	if err != nil {
+		path = config.FileNames[0]
+	}
+
+	doctor := service.NewDoctorService(&git.RealGitRepository{}, &shell.RealShellRunner{})
+	report := doctor.Diagnose(service.DoctorOptions{
+		ConfigPath: path,
+		Version:    Version,
+		Shell:      shell.PreferredShell(),
+	})
+
+	if *outputFlag == "json" {
+		jsonBytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(jsonBytes))
+	} else {
+		icons := map[service.DoctorStatus]string{
+			service.DoctorOK:      "✅",
+			service.DoctorWarning: "⚠️ ",
+			service.DoctorError:   "❌",
+		}
+		fmt.Println("quality-gate doctor")
+		fmt.Println("")
+		for _, check := range report.Checks {
+			fmt.Printf("%s %s: %s\n", icons[check.Status], check.Name, check.Detail)
+			if check.Fix != "" {
+				fmt.Printf("   → %s\n", check.Fix)
+			}
+		}
+	}
+
+	if report.HasErrors() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/service"
+)
+
+// runImport implements `quality-gate import`: it converts the configuration
+// of pre-commit, lefthook or husky into quality.yml and reports what could
+// not be translated.
+func runImport(args []string, configPath string, force bool) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	fromFlag := flags.String("from", "", "Hook manager to import: "+strings.Join(service.ImportSources, ", ")+" (default: detected)")
+	configFlag := flags.String("config", configPath, "Path of the configuration file to write")
+	forceFlag := flags.Bool("force", force, "Overwrite an existing configuration file")
+	dryRunFlag := flags.Bool("dry-run", false, "Print the configuration instead of writing it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	importer := service.NewImporter(".")
+	from := *fromFlag
+	if from == "" {
+		detected := importer.Detect()
+		switch len(detected) {
+		case 0:
+			fmt.Fprintf(os.Stderr, "Error: no pre-commit, lefthook or husky configuration found\n")
+			return 1
+		case 1:
+			from = detected[0]
+		default:
+			fmt.Fprintf(os.Stderr, "Error: found %s configurations; choose one with --from\n", strings.Join(detected, ", "))
+			return 2
+		}
+	}
+
+	result, err := importer.Import(from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to import %s: %v\n", from, err)
+		return 1
+	}
+	data, err := result.YAML()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to import %s: %v\n", from, err)
+		return 1
+	}
+
+	path := *configFlag
+	if path == "" {
+		path = "quality.yml"
+	}
+	if *dryRunFlag {
+		fmt.Print(string(data))
+	} else {
+		if _, err := os.Stat(path); err == nil && !*forceFlag {
+			fmt.Fprintf(os.Stderr, "Error: %s already exists. Use --force to overwrite\n", path)
+			return 1
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write %s: %v\n", path, err)
+			return 1
+		}
+		fmt.Printf("✅ Imported %s into %s\n", strings.Join(result.Files, ", "), path)
+	}
+
+	out := os.Stdout
+	if *dryRunFlag {
+		out = os.Stderr
+	}
+	if len(result.Notes) > 0 {
+		fmt.Fprintf(out, "\n⚠️  %d item(s) could not be translated as written:\n", len(result.Notes))
+		for _, note := range result.Notes {
+			fmt.Fprintf(out, "  - %s\n", note)
+		}
+	}
+	if !*dryRunFlag {
+		fmt.Fprintf(out, "\nReview %s, then run 'quality-gate validate' and 'quality-gate install'\n", path)
+	}
+	return 0
+}
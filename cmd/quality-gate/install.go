@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"github.com/dmux/go-quality-gate/internal/infra/git"
+	"github.com/dmux/go-quality-gate/internal/service"
+)
+
+// installFlags holds the options shared by --install/--uninstall and the
+// install/uninstall commands.
+type installFlags struct {
+	force     bool
+	chain     bool
+	global    bool
+	hooksPath bool
+	config    string
+}
+
+// parseInstallFlags parses the flags of the install and uninstall commands,
+// starting from the values of the global flags.
+func parseInstallFlags(name string, args []string, defaults installFlags) (installFlags, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := defaults
+	flags.BoolVar(&f.force, "force", f.force, "Replace existing hooks or git settings (a backup is kept)")
+	flags.BoolVar(&f.chain, "chain", f.chain, "Keep existing git hooks and run them before quality-gate")
+	flags.BoolVar(&f.global, "global", f.global, "Install for every repository of the current user")
+	flags.BoolVar(&f.hooksPath, "hooks-path", f.hooksPath, "With --global, set core.hooksPath instead of init.templateDir")
+	err := flags.Parse(args)
+	return f, err
+}
+
+// install installs the hooks in the current repository, or globally, and
+// returns a success message.
+func install(f installFlags) (string, error) {
+	if !f.global {
+		installationService := service.NewInstallationService(&git.RealGitRepository{})
+		if err := installationService.InstallHooksWithOptions(installOptions(f)); err != nil {
+			return "", err
+		}
+		return "Git hooks installed successfully.", nil
+	}
+
+	opts := service.GlobalInstallOptions{Mode: service.GlobalTemplateDir, Force: f.force, Version: Version}
+	if f.hooksPath {
+		opts.Mode = service.GlobalHooksPath
+	}
+	if path, err := os.Executable(); err == nil {
+		opts.BinaryPath = path
+	}
+
+	globalService := service.NewGlobalInstallationService(&git.RealGitConfig{})
+	dir, err := globalService.InstallGlobal(opts)
+	if err != nil {
+		return "", err
+	}
+	if opts.Mode == service.GlobalHooksPath {
+		return fmt.Sprintf("Git hooks installed globally in %s (core.hooksPath).", dir), nil
+	}
+	return fmt.Sprintf("Git hooks installed globally in %s (init.templateDir); new clones get them automatically, run 'git init' in existing repositories to add them.", dir), nil
+}
+
+// uninstall removes the hooks from the current repository, or the global
+// installation, and returns a success message.
+func uninstall(f installFlags) (string, error) {
+	if f.global {
+		if err := service.NewGlobalInstallationService(&git.RealGitConfig{}).UninstallGlobal(); err != nil {
+			return "", err
+		}
+		return "Global git hooks uninstalled.", nil
+	}
+
+	installationService := service.NewInstallationService(&git.RealGitRepository{})
+	if err := installationService.UninstallHooks(); err != nil {
+		return "", err
+	}
+	return "Git hooks uninstalled and original hooks restored.", nil
+}
+
+// installOptions describes the hook scripts to install: they pin the absolute
+// path of the running binary, its version and the checksum of the configuration.
+func installOptions(f installFlags) service.InstallOptions {
+	opts := service.InstallOptions{Force: f.force, Chain: f.chain, Version: Version}
+	if path, err := os.Executable(); err == nil {
+		opts.BinaryPath = path
+	}
+	if path, err := config.FindConfig(f.config); err == nil {
+		if checksum, err := config.Checksum(path); err == nil {
+			opts.ConfigChecksum = checksum
+		}
+	}
+	return opts
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/dmux/go-quality-gate/internal/config"
@@ -17,11 +19,20 @@ import (
 
 func main() {
 	installFlag := flag.Bool("install", false, "Install git hooks")
+	uninstallFlag := flag.Bool("uninstall", false, "Remove git hooks and restore the original ones")
+	forceFlag := flag.Bool("force", false, "Overwrite existing hooks or quality.yml (hooks are backed up)")
+	chainFlag := flag.Bool("chain", false, "Keep existing git hooks and run them before quality-gate")
+	globalFlag := flag.Bool("global", false, "With --install or --uninstall, apply to every repository of the current user")
+	hooksPathFlag := flag.Bool("hooks-path", false, "With --install --global, set core.hooksPath instead of init.templateDir")
 	initFlag := flag.Bool("init", false, "Initialize quality.yml")
 	fixFlag := flag.Bool("fix", false, "Fix fixable issues")
 	versionFlag := flag.Bool("version", false, "Show version information")
 	versionFlagShort := flag.Bool("v", false, "Show version information (shorthand)")
 	outputFlag := flag.String("output", "", "Output format (e.g., json)")
+	configFlag := flag.String("config", "", "Path to the configuration file (default: discovered up to the repository root)")
+	strictFlag := flag.Bool("strict", false, "Treat configuration warnings as errors")
+	policyFlag := flag.String("policy", "", "Path to the organization policy file (default: $QUALITY_GATE_POLICY)")
+	allFilesFlag := flag.Bool("all-files", false, "Check every tracked file instead of the staged or pushed ones (for CI)")
 
 	flag.Parse()
 
@@ -52,6 +63,34 @@ func main() {
 
 	args := flag.Args()
 
+	if len(args) > 0 && args[0] == "doctor" {
+		os.Exit(runDoctor(args[1:], *outputFlag, *configFlag))
+	}
+
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(runConfig(args[1:], *configFlag))
+	}
+
+	if len(args) > 0 && args[0] == "validate" {
+		os.Exit(runValidate(args[1:], *outputFlag, *configFlag, *policyFlag, *strictFlag))
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		os.Exit(runMigrate(args[1:], *configFlag))
+	}
+
+	if len(args) > 0 && args[0] == "import" {
+		os.Exit(runImport(args[1:], *configFlag, *forceFlag))
+	}
+
+	if len(args) > 0 && args[0] == "ci" {
+		os.Exit(runCI(args[1:], *configFlag, *forceFlag))
+	}
+
+	if len(args) > 0 && args[0] == "schema" {
+		os.Exit(runSchema())
+	}
+
 	// Helper function to print to the correct output stream
 	logPrint := func(format string, args ...interface{}) {
 		if *outputFlag == "json" {
@@ -69,64 +108,147 @@ func main() {
 		}
 	}
 
+	hookInstallFlags := installFlags{force: *forceFlag, chain: *chainFlag, global: *globalFlag, hooksPath: *hooksPathFlag, config: *configFlag}
+	if len(args) > 0 && (args[0] == "install" || args[0] == "uninstall") {
+		parsed, err := parseInstallFlags(args[0], args[1:], hookInstallFlags)
+		if err != nil {
+			os.Exit(2)
+		}
+		hookInstallFlags = parsed
+		*installFlag = args[0] == "install"
+		*uninstallFlag = args[0] == "uninstall"
+	}
+
 	if *installFlag {
 		logPrintln("Installing git hooks...")
-		gitRepo := &git.RealGitRepository{}
-		installationService := service.NewInstallationService(gitRepo)
-		if err := installationService.InstallHooks(); err != nil {
+		message, err := install(hookInstallFlags)
+		if err != nil {
 			logPrint("Error installing git hooks: %v\n", err)
 			os.Exit(1)
 		}
-		logPrintln("Git hooks installed successfully.")
+		logPrintln(message)
+		return
+	}
+
+	if *uninstallFlag {
+		logPrintln("Uninstalling git hooks...")
+		message, err := uninstall(hookInstallFlags)
+		if err != nil {
+			logPrint("Error uninstalling git hooks: %v\n", err)
+			os.Exit(1)
+		}
+		logPrintln(message)
 		return
 	}
 
 	if *initFlag {
-		logPrintln("Initializing quality.yml...")
+		initPath := "quality.yml"
+		if *configFlag != "" {
+			initPath = *configFlag
+		}
+		logPrint("Initializing %s...\n", initPath)
 		initService := service.NewInitService()
-		if err := initService.Init(); err != nil {
-			logPrint("Error initializing quality.yml: %v\n", err)
+		if err := initService.InitWithOptions(service.InitOptions{OutputPath: initPath, Force: *forceFlag}); err != nil {
+			logPrint("Error initializing %s: %v\n", initPath, err)
 			os.Exit(1)
 		}
-		logPrintln("quality.yml initialized successfully.")
+		logPrint("%s initialized successfully.\n", initPath)
 		return
 	}
 
 	if len(args) == 0 {
-		logPrintln("Usage: quality-gate [OPTIONS] [HOOK_TYPE]")
+		logPrintln("Usage: quality-gate [OPTIONS] [COMMAND | HOOK_TYPE] [ARGS...]")
+		logPrintln("")
+		logPrintln("Commands:")
+		logPrintln("  install       Install git hooks (same as --install)")
+		logPrintln("  uninstall     Remove git hooks (same as --uninstall)")
+		logPrintln("  doctor        Diagnose hooks, quality.yml, tools and shell")
+		logPrintln("  config show   Print the configuration (--resolved for the effective one)")
+		logPrintln("  validate      Check quality.yml for errors (--strict to fail on warnings)")
+		logPrintln("                --fix applies the automatic fixes after showing their diff")
+		logPrintln("  migrate       Upgrade quality.yml to the current configuration version")
+		logPrintln("  import        Convert pre-commit, lefthook or husky hooks (--from NAME)")
+		logPrintln("  ci generate   Write a CI pipeline running the hooks (--provider github|gitlab|azure|jenkins)")
+		logPrintln("  schema        Print the JSON Schema of quality.yml")
 		logPrintln("")
 		logPrintln("Hook Types:")
 		logPrintln("  pre-commit    Run pre-commit quality checks")
-		logPrintln("  pre-push      Run pre-push quality checks")
+		logPrintln("  pre-push      Run pre-push quality checks (reads git's ref updates from stdin)")
 		logPrintln("")
 		logPrintln("Options:")
 		logPrintln("  --install     Install git hooks in the current repository")
+		logPrintln("  --chain       With --install, keep existing hooks and run them first")
+		logPrintln("  --force       With --install, replace existing hooks (a backup is kept)")
+		logPrintln("  --uninstall   Remove git hooks and restore the original ones")
+		logPrintln("  --global      With --install/--uninstall, apply to every repository (init.templateDir)")
+		logPrintln("  --hooks-path  With --install --global, use core.hooksPath instead")
 		logPrintln("  --init        Initialize quality.yml with intelligent analysis")
 		logPrintln("  --fix         Automatically fix detected issues")
 		logPrintln("  --version, -v Show version information")
 		logPrintln("  --output json Output results in JSON format")
+		logPrintln("  --config PATH Use this configuration file (also $QUALITY_GATE_CONFIG)")
+		logPrintln("  --strict      Stop on any configuration issue, not only critical ones")
+		logPrintln("  --policy PATH Enforce an organization policy (also $QUALITY_GATE_POLICY)")
+		logPrintln("  --all-files   Check every tracked file, not only staged or pushed ones")
 		logPrintln("")
 		logPrintln("Examples:")
 		logPrintln("  quality-gate --init              # Create quality.yml for your project")
 		logPrintln("  quality-gate --install           # Install git hooks")
+		logPrintln("  quality-gate install --global    # Install hooks for every new clone")
 		logPrintln("  quality-gate pre-commit          # Run pre-commit checks")
 		logPrintln("  quality-gate --fix pre-commit    # Fix issues and run checks")
+		logPrintln("  quality-gate doctor              # Check the local setup")
+		logPrintln("  quality-gate validate            # Check quality.yml")
+		logPrintln("  quality-gate validate --fix      # Fix mechanical issues in quality.yml")
+		logPrintln("  quality-gate migrate             # Upgrade an older quality.yml")
+		logPrintln("  quality-gate import --from husky # Convert husky hooks into quality.yml")
+		logPrintln("  quality-gate ci generate --provider github  # Run the same checks in CI")
 		logPrintln("  quality-gate --version           # Show version")
 		os.Exit(1)
 	}
 
 	hookType := args[0]
 
-	cfg, err := config.LoadConfig("quality.yml")
+	cfg, configDisplay, err := loadConfig(*configFlag)
 	if err != nil {
-		logPrint("Error loading quality.yml: %v\n", err)
+		logPrint("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
+	logPrint("📄 Using %s\n", configDisplay)
+	if err := config.CheckMinVersion(cfg, Version); err != nil {
+		logPrint("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !checkConfig(cfg, *policyFlag, *strictFlag, logPrint) {
+		os.Exit(1)
+	}
+	if hookVersion := os.Getenv("QUALITY_GATE_HOOK_VERSION"); hookVersion != "" && hookVersion != Version {
+		logPrint("⚠️  Git hooks were installed by quality-gate %s but %s is running; run 'quality-gate --install --force' to update them.\n", hookVersion, Version)
+	}
 
 	shellRunner := &shell.RealShellRunner{}
 	consoleLogger := logger.NewConsoleLogger(*outputFlag == "json")
 	toolManager := service.NewToolManagerService(shellRunner, consoleLogger)
 	hookRunner := service.NewHookRunnerService(shellRunner, consoleLogger)
+	hookContext := domain.HookContext{HookType: hookType}
+	if hookType == "pre-push" {
+		push, err := readPushInfo(args[1:])
+		if err != nil {
+			logPrint("Error reading pre-push input: %v\n", err)
+			os.Exit(1)
+		}
+		hookContext.Push = push
+	}
+	hookContext.Files, err = hookFiles(hookContext, *allFilesFlag)
+	if err != nil {
+		logPrint("Error listing files for %s: %v\n", hookType, err)
+		os.Exit(1)
+	}
+	if !*allFilesFlag {
+		hookContext.ReadFile = hookFileReader(hookContext)
+		hookContext.Stat = hookFileStat(hookContext)
+	}
+	hookRunner.SetContext(hookContext)
 	qualityGate := service.NewQualityGateService(toolManager, hookRunner)
 
 	if *fixFlag {
@@ -159,6 +281,9 @@ func main() {
 			Output       string      `json:"output"`
 			DurationMs   int64       `json:"duration_ms"`
 			DurationText string      `json:"duration"`
+			Skipped      bool        `json:"skipped,omitempty"`
+			ExitCode     int         `json:"exit_code,omitempty"`
+			Message      string      `json:"message,omitempty"`
 		}
 
 		var jsonResults []JSONResult
@@ -169,14 +294,19 @@ func main() {
 				Output:       result.Output,
 				DurationMs:   result.Duration.Milliseconds(),
 				DurationText: result.Duration.Round(time.Millisecond).String(),
+				Skipped:      result.Skipped,
+				ExitCode:     result.ExitCode,
+				Message:      result.Message,
 			})
 		}
 
 		jsonOutput := struct {
 			Status  string       `json:"status"`
+			Config  string       `json:"config"`
 			Results []JSONResult `json:"results"`
 		}{
 			Status:  overallStatus,
+			Config:  cfg.Path,
 			Results: jsonResults,
 		}
 		jsonBytes, marshalErr := json.MarshalIndent(jsonOutput, "", "  ")
@@ -197,3 +327,126 @@ func main() {
 		}
 	}
 }
+
+// loadConfig finds and loads the configuration file, returning it with its
+// path relative to the working directory for display. Inside a git
+// repository, the working directory then changes to the root of the working
+// tree, which the file lists of git are relative to, so hook commands and
+// builtins see the same paths wherever quality-gate was started.
+
+func loadConfig(explicit string) (*config.Config, string, error) {
+	path, err := config.FindConfig(explicit)
+	if err != nil {
+		return nil, "", err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, "", err
+	}
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	display := relativePath(path)
+	if len(cfg.Sources) > 1 {
+		display = fmt.Sprintf("%s (merged from %d files, see 'quality-gate config show --resolved')", display, len(cfg.Sources))
+	}
+	if top, err := (&git.RealGitRepository{}).TopLevel(); err == nil {
+		if err := os.Chdir(top); err != nil {
+			return nil, "", err
+		}
+	}
+	return cfg, display, nil
+}
+
+// hookFiles lists the files builtin checks and file filters apply to: the
+// staged files for pre-commit and the pushed files for pre-push, or every
+// tracked file with --all-files.
+
+func hookFiles(ctx domain.HookContext, allFiles bool) ([]string, error) {
+	gitRepo := &git.RealGitRepository{}
+	if allFiles {
+		return gitRepo.TrackedFiles()
+	}
+	if ctx.Push != nil {
+		return gitRepo.PushedFiles(ctx.Push)
+	}
+	return gitRepo.StagedFiles()
+}
+
+// hookFileReader reads file content as it will be committed (the index) or
+// pushed (the local commit being pushed), falling back to the working tree
+// for files git cannot provide.
+
+func hookFileReader(ctx domain.HookContext) func(string) ([]byte, error) {
+	gitRepo := &git.RealGitRepository{}
+	rev := ""
+	if ctx.Push != nil {
+		update, ok := ctx.Push.Primary()
+		if !ok {
+			return nil
+		}
+		rev = update.LocalSHA
+	}
+	return func(path string) ([]byte, error) {
+		if data, err := gitRepo.ReadAt(rev, path); err == nil {
+			return data, nil
+		}
+		return os.ReadFile(path)
+	}
+}
+
+// hookFileStat returns the size and mode of a file as it will be committed or
+// pushed, like hookFileReader, falling back to the working tree.
+
+func hookFileStat(ctx domain.HookContext) func(string) (os.FileInfo, error) {
+	gitRepo := &git.RealGitRepository{}
+	rev := ""
+	if ctx.Push != nil {
+		update, ok := ctx.Push.Primary()
+		if !ok {
+			return nil
+		}
+		rev = update.LocalSHA
+	}
+	return func(path string) (os.FileInfo, error) {
+		if info, err := gitRepo.StatAt(rev, path); err == nil {
+			return info, nil
+		}
+		return os.Lstat(path)
+	}
+}
+
+// readPushInfo parses the remote name and URL git passes as arguments to the
+// pre-push hook, together with the ref updates written to standard input.
+
+func readPushInfo(args []string) (*domain.PushInfo, error) {
+	var remoteName, remoteURL string
+	if len(args) > 0 {
+		remoteName = args[0]
+	}
+	if len(args) > 1 {
+		remoteURL = args[1]
+	}
+
+	// Only consume stdin when git pipes ref updates into it; a manual run from
+	// a terminal must not block waiting for input.
+	var input io.Reader
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		input = os.Stdin
+	}
+	return service.ParsePushInfo(remoteName, remoteURL, input)
+}
+
+// relativePath returns an absolute path relative to the working directory
+// for display.
+
+func relativePath(path string) string {
+	if cwd, err := os.Getwd(); err == nil && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+)
+
+// runMigrate implements `quality-gate migrate`: it rewrites the configuration
+// files of an older version to the current one, showing their diff first.
+// With --check, it only reports whether files need migrating.
+func runMigrate(args []string, configPath string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	configFlag := flags.String("config", configPath, "Path to the configuration file")
+	yesFlag := flags.Bool("yes", false, "Write the migrated files without asking for confirmation")
+	checkFlag := flags.Bool("check", false, "Exit with 1 when files need migrating, without changing them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	path, err := config.FindConfig(*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+	fixes, err := config.Migrate(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to migrate %s: %v\n", relativePath(path), err)
+		return 1
+	}
+
+	if len(fixes) == 0 {
+		fmt.Printf("✅ %s is at the current version %d\n", relativePath(path), config.CurrentVersion)
+		return 0
+	}
+	if *checkFlag {
+		for _, fix := range fixes {
+			fmt.Printf("⚠️  %s needs migrating to version %d\n", relativePath(fix.Path), config.CurrentVersion)
+		}
+		fmt.Println("Run 'quality-gate migrate' to upgrade")
+		return 1
+	}
+
+	if _, err := writeFixes(fixes, *yesFlag, "Migrated", os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to migrate %s: %v\n", relativePath(path), err)
+		return 1
+	}
+	return 0
+}
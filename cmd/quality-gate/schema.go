@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+)
+
+// runSchema implements `quality-gate schema`: it prints the JSON Schema of
+// the configuration file for YAML editor plugins.
+func runSchema() int {
+	data, err := config.SchemaJSON()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating schema: %v\n", err)
+		return 1
+	}
+	fmt.Print(string(data))
+	return 0
+}
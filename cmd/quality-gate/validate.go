@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+)
+
+// runValidate implements `quality-gate validate`: it loads and validates the
+// configuration, printing every finding, and returns a non-zero exit code
+// when the configuration has errors, or warnings with --strict. With --fix,
+// it first applies the automatic fixes, showing their diff.
+func runValidate(args []string, output, configPath, policyPath string, strict bool) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	outputFlag := flags.String("output", output, "Output format (e.g., json)")
+	configFlag := flags.String("config", configPath, "Path to the configuration file")
+	strictFlag := flags.Bool("strict", strict, "Treat warnings as errors")
+	policyFlag := flags.String("policy", policyPath, "Path to the organization policy file")
+	fixFlag := flags.Bool("fix", false, "Apply the automatic fixes to the configuration")
+	yesFlag := flags.Bool("yes", false, "Apply the fixes without asking for confirmation")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	path, result := validateConfig(*configFlag, *policyFlag)
+
+	var fixed []string
+	if *fixFlag {
+		// Keep stdout for the JSON document
+		out := io.Writer(os.Stdout)
+		if *outputFlag == "json" {
+			out = os.Stderr
+		}
+		var err error
+		if fixed, err = fixConfig(path, result, *yesFlag, out); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to fix %s: %v\n", relativePath(path), err)
+			return 1
+		}
+		if len(fixed) > 0 {
+			path, result = validateConfig(*configFlag, *policyFlag)
+		}
+	}
+
+	if *outputFlag == "json" {
+		jsonOutput := struct {
+			Config string                   `json:"config"`
+			Valid  bool                     `json:"valid"`
+			Strict bool                     `json:"strict"`
+			Policy string                   `json:"policy,omitempty"`
+			Fixed  []string                 `json:"fixed,omitempty"`
+			Errors []config.ValidationError `json:"errors"`
+		}{
+			Config: path,
+			Valid:  !result.Failed(*strictFlag),
+			Strict: *strictFlag,
+			Policy: config.FindPolicy(*policyFlag),
+			Fixed:  fixed,
+			Errors: result.Errors,
+		}
+		jsonBytes, err := json.MarshalIndent(jsonOutput, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(jsonBytes))
+	} else {
+		display := relativePath(path)
+		fmt.Printf("🔍 Validating %s\n", display)
+		fmt.Println(result.GetFormattedErrors())
+		if fixable := countFixable(result); fixable > 0 && !*fixFlag {
+			fmt.Printf("🔧 %d issue(s) can be fixed automatically with 'quality-gate validate --fix'\n", fixable)
+		}
+		switch {
+		case result.Failed(*strictFlag):
+			fmt.Printf("❌ %s is invalid\n", display)
+		case len(result.Errors) > 0:
+			fmt.Printf("✅ %s is valid (use --strict to fail on warnings)\n", display)
+		default:
+			fmt.Printf("✅ %s is valid\n", display)
+		}
+	}
+
+	if result.Failed(*strictFlag) {
+		return 1
+	}
+	return 0
+}
+
+// fixConfig applies the automatic fixes of the findings, printing their diff
+// to out first. Unless yes is set, it asks for confirmation, and writes
+// nothing when it cannot ask. It returns the fixes written.
+func fixConfig(path string, result *config.ValidationResult, yes bool, out io.Writer) ([]string, error) {
+	fixes, err := config.ApplyFixes(path, result)
+	if err != nil {
+		return nil, err
+	}
+	if len(fixes) == 0 {
+		fmt.Fprintln(out, "🔧 No automatic fixes available")
+		return nil, nil
+	}
+
+	var applied []string
+	for _, fix := range fixes {
+		applied = append(applied, fix.Applied...)
+	}
+	if written, err := writeFixes(fixes, yes, "Fixed", out); !written || err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// writeFixes prints the diff and the description of rewritten configuration
+// files to out, then writes them with their original permissions. Unless yes
+// is set, it asks for confirmation, and writes nothing when it cannot ask.
+// It reports whether the files were written, each reported with verb.
+func writeFixes(fixes []config.FileFix, yes bool, verb string, out io.Writer) (bool, error) {
+	for _, fix := range fixes {
+		fmt.Fprint(out, config.UnifiedDiff(relativePath(fix.Path), fix.Original, fix.Fixed))
+	}
+	for _, fix := range fixes {
+		for _, description := range fix.Applied {
+			fmt.Fprintf(out, "🔧 %s\n", description)
+		}
+	}
+
+	if !yes {
+		if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			fmt.Fprintln(out, "Run with --yes to write these changes")
+			return false, nil
+		}
+		fmt.Fprint(out, "Apply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Fprintln(out, "No changes written")
+			return false, nil
+		}
+	}
+
+	for _, fix := range fixes {
+		info, err := os.Stat(fix.Path)
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(fix.Path, fix.Fixed, info.Mode().Perm()); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(out, "✅ %s %s\n", verb, relativePath(fix.Path))
+	}
+	return true, nil
+}
+
+// countFixable returns the number of findings with an automatic fix.
+func countFixable(result *config.ValidationResult) int {
+	count := 0
+	for _, finding := range result.Errors {
+		if finding.Fix != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// validateConfig finds, loads and validates the configuration, and checks
+// it against the organization policy if any. A file that cannot be found or
+// loaded is reported as critical findings.
+func validateConfig(explicit, policyPath string) (string, *config.ValidationResult) {
+	path, err := config.FindConfig(explicit)
+	if err != nil {
+		return config.FileNames[0], loadFailure(config.FileNames[0], err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return path, loadFailure(path, err)
+	}
+	result := config.NewConfigValidator(cfg).Validate()
+	if violations := policyFindings(cfg, policyPath); len(violations) > 0 {
+		result.Errors = append(result.Errors, violations...)
+		result.Valid = false
+	}
+	return path, result
+}
+
+// policyFindings checks the configuration against the organization policy
+// given by path or $QUALITY_GATE_POLICY, returning nothing when there is no
+// policy. A policy that cannot be loaded is a critical finding, so a broken
+// policy does not let every configuration through.
+func policyFindings(cfg *config.Config, path string) []config.ValidationError {
+	path = config.FindPolicy(path)
+	if path == "" {
+		return nil
+	}
+	policy, err := config.LoadPolicy(path)
+	if err != nil {
+		return []config.ValidationError{{
+			Rule:     config.RulePolicyLoad,
+			Field:    "policy",
+			Value:    path,
+			Issue:    fmt.Sprintf("Cannot load the policy: %v", err),
+			Severity: config.SeverityCritical,
+			Position: config.Position{File: path},
+		}}
+	}
+	return config.NewPolicyEngine(policy, cfg).Check().Errors
+}
+
+// loadFailure converts a loading error into a failed validation result, with
+// one finding per unknown key.
+func loadFailure(path string, err error) *config.ValidationResult {
+	result := &config.ValidationResult{Valid: false}
+
+	var decodeErrs config.DecodeErrors
+	if errors.As(err, &decodeErrs) {
+		for _, decodeErr := range decodeErrs {
+			result.Errors = append(result.Errors, config.ValidationError{
+				Rule:     config.RuleUnknownKey,
+				Field:    "file",
+				Issue:    decodeErr.Message,
+				Severity: config.SeverityCritical,
+				Position: decodeErr.Position,
+			})
+		}
+		return result
+	}
+
+	result.Errors = append(result.Errors, config.ValidationError{
+		Rule:     config.RuleConfigLoad,
+		Field:    "file",
+		Value:    path,
+		Issue:    err.Error(),
+		Severity: config.SeverityCritical,
+		Position: config.Position{File: path},
+	})
+	return result
+}
+
+// checkConfig validates the configuration, and checks it against the
+// organization policy, before hooks run. Critical findings, which include
+// every policy violation, and with strict any finding, stop the run; other
+// findings are summarized so they do not drown the hook output.
+func checkConfig(cfg *config.Config, policyPath string, strict bool, logPrint func(string, ...interface{})) bool {
+	result := config.NewConfigValidator(cfg).Validate()
+	result.Errors = append(result.Errors, policyFindings(cfg, policyPath)...)
+	blocking := result.GetErrorsBySeverity()[config.SeverityCritical]
+	if strict {
+		blocking = result.Errors
+	}
+
+	if len(blocking) > 0 {
+		logPrint("%s\n", (&config.ValidationResult{Errors: blocking}).GetFormattedErrors())
+		logPrint("Configuration is invalid; fix the issues above (see 'quality-gate validate').\n")
+		return false
+	}
+	if len(result.Errors) > 0 {
+		logPrint("⚠️  %d configuration issue(s) found; run 'quality-gate validate' for details.\n", len(result.Errors))
+	}
+	return true
+}
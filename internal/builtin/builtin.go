@@ -0,0 +1,176 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Finding is a single problem reported by a builtin check.
+type Finding struct {
+	File    string
+	Line    int
+	Message string
+}
+
+// String formats the finding as "file:line: message".
+func (f Finding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message)
+	}
+	return fmt.Sprintf("%s: %s", f.File, f.Message)
+}
+
+// Input is what a builtin check operates on.
+type Input struct {
+	// Files is the list of files to check, relative to the repository root.
+	Files []string
+	// Options holds the hook's options from quality.yml.
+	Options map[string]string
+	// ReadFile reads the content to check; it defaults to os.ReadFile.
+	ReadFile func(path string) ([]byte, error)
+	// Stat returns the size and mode of a file to check; it defaults to
+	// os.Lstat.
+	Stat func(path string) (os.FileInfo, error)
+}
+
+// read returns the content of a file using the configured reader.
+func (in Input) read(path string) ([]byte, error) {
+	if in.ReadFile != nil {
+		return in.ReadFile(path)
+	}
+	return os.ReadFile(path)
+}
+
+// stat returns the size and mode of a file using the configured function.
+func (in Input) stat(path string) (os.FileInfo, error) {
+	if in.Stat != nil {
+		return in.Stat(path)
+	}
+	return os.Lstat(path)
+}
+
+// intOption returns an integer option or the given default.
+func (in Input) intOption(name string, def int) (int, error) {
+	value, ok := in.Options[name]
+	if !ok || strings.TrimSpace(value) == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("option %s must be an integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+// Check is a native implementation of a hook.
+type Check struct {
+	Name        string
+	Description string
+	// Run reports the findings for the given input.
+	Run func(in Input) ([]Finding, error)
+	// Fix repairs the files in place and returns the ones it changed. It is
+	// nil for checks that cannot be fixed automatically.
+	Fix func(in Input) ([]string, error)
+	// Validate checks the hook options ahead of a run. It is nil for checks
+	// without options.
+	Validate func(options map[string]string) error
+}
+
+// registry holds all available builtin checks by name.
+var registry = map[string]Check{
+	"trailing-whitespace": {
+		Name:        "trailing-whitespace",
+		Description: "Lines must not end with spaces or tabs",
+		Run:         checkTrailingWhitespace,
+		Fix:         fixTrailingWhitespace,
+	},
+	"end-of-file": {
+		Name:        "end-of-file",
+		Description: "Files must end with a newline",
+		Run:         checkEndOfFile,
+		Fix:         fixEndOfFile,
+	},
+	"merge-conflict": {
+		Name:        "merge-conflict",
+		Description: "Files must not contain merge conflict markers",
+		Run:         checkMergeConflict,
+	},
+	"line-endings": {
+		Name:        "line-endings",
+		Description: "Files must use LF line endings",
+		Run:         checkLineEndings,
+		Fix:         fixLineEndings,
+	},
+	"large-files": {
+		Name:        "large-files",
+		Description: "Files must not exceed max_kb kilobytes (default 500)",
+		Run:         checkLargeFiles,
+		Validate:    validateLargeFilesOptions,
+	},
+	"case-conflict": {
+		Name:        "case-conflict",
+		Description: "File names must not differ only by case",
+		Run:         checkCaseConflict,
+	},
+	"broken-symlinks": {
+		Name:        "broken-symlinks",
+		Description: "Symbolic links must point to existing files",
+		Run:         checkBrokenSymlinks,
+	},
+	"shebang-executable": {
+		Name:        "shebang-executable",
+		Description: "Scripts with a shebang must be executable",
+		Run:         checkShebangExecutable,
+		Fix:         fixShebangExecutable,
+	},
+	"secrets": {
+		Name:        "secrets",
+		Description: "Files must not contain credentials, API keys or private keys",
+		Run:         checkSecrets,
+		Validate:    validateSecretsOptions,
+	},
+	"license-header": {
+		Name:        "license-header",
+		Description: "Source files must start with the configured license header",
+		Run:         checkLicenseHeader,
+		Fix:         fixLicenseHeader,
+		Validate:    validateLicenseOptions,
+	},
+}
+
+// Lookup returns the builtin check with the given name.
+func Lookup(name string) (Check, bool) {
+	check, ok := registry[name]
+	return check, ok
+}
+
+// Names returns the names of all builtin checks, sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatFindings renders findings one per line.
+func FormatFindings(findings []Finding) string {
+	lines := make([]string, 0, len(findings))
+	for _, f := range findings {
+		lines = append(lines, f.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isBinary uses the same heuristic as git: a NUL byte in the first 8000 bytes.
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
@@ -0,0 +1,304 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// textFiles calls fn for every regular, non-binary file of the input.
+func textFiles(in Input, fn func(file string, data []byte)) error {
+	for _, file := range in.Files {
+		if info, err := in.stat(file); err == nil && !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := in.read(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if isBinary(data) {
+			continue
+		}
+		fn(file, data)
+	}
+	return nil
+}
+
+// rewriteFiles applies transform to every regular, non-binary file in the
+// working tree and returns the files whose content changed. It stops at the
+// first file that cannot be written.
+func rewriteFiles(files []string, transform func(file string, data []byte) []byte) ([]string, error) {
+	var fixed []string
+	var writeErr error
+	err := textFiles(Input{Files: files}, func(file string, data []byte) {
+		if writeErr != nil {
+			return
+		}
+		if updated := transform(file, data); !bytes.Equal(updated, data) {
+			if err := os.WriteFile(file, updated, 0644); err != nil {
+				writeErr = fmt.Errorf("failed to write %s: %w", file, err)
+				return
+			}
+			fixed = append(fixed, file)
+		}
+	})
+	if err == nil {
+		err = writeErr
+	}
+	return fixed, err
+}
+
+// splitLines splits content into lines, keeping the line terminators.
+func splitLines(data []byte) [][]byte {
+	return bytes.SplitAfter(data, []byte("\n"))
+}
+
+func checkTrailingWhitespace(in Input) ([]Finding, error) {
+	var findings []Finding
+	err := textFiles(in, func(file string, data []byte) {
+		for i, line := range splitLines(data) {
+			content := bytes.TrimRight(line, "\r\n")
+			if len(content) > 0 && len(bytes.TrimRight(content, " \t")) != len(content) {
+				findings = append(findings, Finding{File: file, Line: i + 1, Message: "trailing whitespace"})
+			}
+		}
+	})
+	return findings, err
+}
+
+func fixTrailingWhitespace(in Input) ([]string, error) {
+	return rewriteFiles(in.Files, func(_ string, data []byte) []byte {
+		lines := splitLines(data)
+		for i, line := range lines {
+			content := bytes.TrimRight(line, "\r\n")
+			ending := line[len(content):]
+			lines[i] = append(bytes.TrimRight(content, " \t"), ending...)
+		}
+		return bytes.Join(lines, nil)
+	})
+}
+
+func checkEndOfFile(in Input) ([]Finding, error) {
+	var findings []Finding
+	err := textFiles(in, func(file string, data []byte) {
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			findings = append(findings, Finding{File: file, Message: "missing newline at end of file"})
+		}
+	})
+	return findings, err
+}
+
+func fixEndOfFile(in Input) ([]string, error) {
+	return rewriteFiles(in.Files, func(_ string, data []byte) []byte {
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			return append(data, '\n')
+		}
+		return data
+	})
+}
+
+func checkMergeConflict(in Input) ([]Finding, error) {
+	var findings []Finding
+	err := textFiles(in, func(file string, data []byte) {
+		inConflict := false
+		for i, line := range splitLines(data) {
+			content := string(bytes.TrimRight(line, "\r\n"))
+			switch {
+			case strings.HasPrefix(content, "<<<<<<< ") || content == "<<<<<<<":
+				inConflict = true
+				findings = append(findings, Finding{File: file, Line: i + 1, Message: "merge conflict marker '<<<<<<<'"})
+			case content == "=======" && inConflict:
+				findings = append(findings, Finding{File: file, Line: i + 1, Message: "merge conflict marker '======='"})
+			case strings.HasPrefix(content, ">>>>>>> ") || content == ">>>>>>>":
+				inConflict = false
+				findings = append(findings, Finding{File: file, Line: i + 1, Message: "merge conflict marker '>>>>>>>'"})
+			}
+		}
+	})
+	return findings, err
+}
+
+func checkLineEndings(in Input) ([]Finding, error) {
+	var findings []Finding
+	err := textFiles(in, func(file string, data []byte) {
+		for i, line := range splitLines(data) {
+			if bytes.HasSuffix(line, []byte("\r\n")) {
+				findings = append(findings, Finding{File: file, Line: i + 1, Message: "CRLF line ending"})
+				return
+			}
+		}
+	})
+	return findings, err
+}
+
+func fixLineEndings(in Input) ([]string, error) {
+	return rewriteFiles(in.Files, func(_ string, data []byte) []byte {
+		return bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	})
+}
+
+func checkLargeFiles(in Input) ([]Finding, error) {
+	maxKB, err := in.intOption("max_kb", 500)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, file := range in.Files {
+		info, err := in.stat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if sizeKB := (info.Size() + 1023) / 1024; sizeKB > int64(maxKB) {
+			findings = append(findings, Finding{
+				File:    file,
+				Message: fmt.Sprintf("file is %d KB, larger than the %d KB limit", sizeKB, maxKB),
+			})
+		}
+	}
+	return findings, nil
+}
+
+func checkCaseConflict(in Input) ([]Finding, error) {
+	var findings []Finding
+	reported := make(map[string]bool)
+	report := func(file, other string) {
+		if reported[file] {
+			return
+		}
+		reported[file] = true
+		findings = append(findings, Finding{
+			File:    file,
+			Message: fmt.Sprintf("name conflicts with %s on case-insensitive filesystems", other),
+		})
+	}
+
+	// Conflicts among the files being checked, including their directories.
+	seen := make(map[string]string)
+	for _, file := range in.Files {
+		parts := strings.Split(filepath.ToSlash(file), "/")
+		for i := range parts {
+			prefix := strings.Join(parts[:i+1], "/")
+			key := strings.ToLower(prefix)
+			if other, ok := seen[key]; ok && other != prefix {
+				report(file, other)
+				break
+			}
+			seen[key] = prefix
+		}
+	}
+
+	// Conflicts with entries already present next to each path component.
+	for _, file := range in.Files {
+		current := filepath.ToSlash(file)
+		for current != "." && current != "/" && current != "" {
+			dir, name := path.Split(current)
+			dir = strings.TrimSuffix(dir, "/")
+			entries, err := os.ReadDir(filepath.FromSlash(orDot(dir)))
+			if err == nil {
+				for _, entry := range entries {
+					if entry.Name() != name && strings.EqualFold(entry.Name(), name) {
+						report(file, path.Join(dir, entry.Name()))
+					}
+				}
+			}
+			current = dir
+		}
+	}
+	return findings, nil
+}
+
+func orDot(dir string) string {
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+func checkBrokenSymlinks(in Input) ([]Finding, error) {
+	var findings []Finding
+	for _, file := range in.Files {
+		info, err := os.Lstat(file)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			target, _ := os.Readlink(file)
+			findings = append(findings, Finding{File: file, Message: fmt.Sprintf("broken symlink to %s", target)})
+		}
+	}
+	return findings, nil
+}
+
+// nonExecutableScripts returns the regular files that start with a shebang
+// but have no executable bit set.
+func nonExecutableScripts(in Input) []string {
+	var scripts []string
+	for _, file := range in.Files {
+		info, err := in.stat(file)
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 != 0 {
+			continue
+		}
+		data, err := in.read(file)
+		if err == nil && bytes.HasPrefix(data, []byte("#!")) {
+			scripts = append(scripts, file)
+		}
+	}
+	return scripts
+}
+
+func checkShebangExecutable(in Input) ([]Finding, error) {
+	var findings []Finding
+	for _, file := range nonExecutableScripts(in) {
+		findings = append(findings, Finding{
+			File:    file,
+			Message: "has a shebang but is not executable (git update-index --chmod=+x)",
+		})
+	}
+	return findings, nil
+}
+
+func fixShebangExecutable(in Input) ([]string, error) {
+	var fixed []string
+	for _, file := range nonExecutableScripts(in) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fixed, err
+		}
+		// Grant execute permission wherever read permission is granted.
+		mode := info.Mode().Perm()
+		mode |= (mode & 0444) >> 2
+		if err := os.Chmod(file, mode); err != nil {
+			return fixed, fmt.Errorf("failed to chmod %s: %w", file, err)
+		}
+		if err := stageExecutable(file); err != nil {
+			return fixed, err
+		}
+		fixed = append(fixed, file)
+	}
+	return fixed, nil
+}
+
+// stageExecutable sets the executable bit of a tracked file in the index too,
+// so the commit ships the fix without another git add.
+func stageExecutable(file string) error {
+	if exec.Command("git", "ls-files", "--error-unmatch", "--", file).Run() != nil {
+		return nil
+	}
+	if output, err := exec.Command("git", "update-index", "--chmod=+x", "--", file).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set the executable bit of %s in the index: %s", file, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func validateLargeFilesOptions(options map[string]string) error {
+	_, err := Input{Options: options}.intOption("max_kb", 500)
+	return err
+}
@@ -0,0 +1,277 @@
+package builtin
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chdirTemp switches into a fresh temporary directory for the duration of the test.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func writeFile(t *testing.T, name, content string, mode os.FileMode) {
+	t.Helper()
+	if dir := filepath.Dir(name); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(name, []byte(content), mode); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+func runCheck(t *testing.T, name string, in Input) []Finding {
+	t.Helper()
+	check, ok := Lookup(name)
+	if !ok {
+		t.Fatalf("Builtin %q not registered", name)
+	}
+	findings, err := check.Run(in)
+	if err != nil {
+		t.Fatalf("%s failed: %v", name, err)
+	}
+	return findings
+}
+
+func fixCheck(t *testing.T, name string, in Input) []string {
+	t.Helper()
+	check, _ := Lookup(name)
+	if check.Fix == nil {
+		t.Fatalf("Builtin %q has no fixer", name)
+	}
+	fixed, err := check.Fix(in)
+	if err != nil {
+		t.Fatalf("%s fix failed: %v", name, err)
+	}
+	return fixed
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestTrailingWhitespace(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "a.txt", "clean\ndirty \nalso\t\r\n", 0644)
+	writeFile(t, "bin.dat", "x \x00 ", 0644)
+
+	findings := runCheck(t, "trailing-whitespace", Input{Files: []string{"a.txt", "bin.dat", "missing.txt"}})
+	if len(findings) != 2 || findings[0].Line != 2 || findings[1].Line != 3 {
+		t.Fatalf("Expected findings on lines 2 and 3, got %v", findings)
+	}
+
+	fixed := fixCheck(t, "trailing-whitespace", Input{Files: []string{"a.txt"}})
+	if len(fixed) != 1 {
+		t.Errorf("Expected 1 fixed file, got %v", fixed)
+	}
+	if got := readFile(t, "a.txt"); got != "clean\ndirty\nalso\r\n" {
+		t.Errorf("Unexpected fixed content %q", got)
+	}
+}
+
+func TestEndOfFile(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "ok.txt", "line\n", 0644)
+	writeFile(t, "bad.txt", "line", 0644)
+	writeFile(t, "empty.txt", "", 0644)
+
+	files := []string{"ok.txt", "bad.txt", "empty.txt"}
+	findings := runCheck(t, "end-of-file", Input{Files: files})
+	if len(findings) != 1 || findings[0].File != "bad.txt" {
+		t.Fatalf("Expected only bad.txt to be reported, got %v", findings)
+	}
+
+	fixCheck(t, "end-of-file", Input{Files: files})
+	if got := readFile(t, "bad.txt"); got != "line\n" {
+		t.Errorf("Unexpected fixed content %q", got)
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "conflict.go", "a\n<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> branch\n", 0644)
+	writeFile(t, "heading.md", "Title\n=======\n", 0644)
+
+	findings := runCheck(t, "merge-conflict", Input{Files: []string{"conflict.go", "heading.md"}})
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 markers, got %v", findings)
+	}
+	for _, f := range findings {
+		if f.File != "conflict.go" {
+			t.Errorf("Did not expect findings in %s", f.File)
+		}
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "crlf.txt", "a\r\nb\r\n", 0644)
+
+	findings := runCheck(t, "line-endings", Input{Files: []string{"crlf.txt"}})
+	if len(findings) != 1 {
+		t.Fatalf("Expected one finding per file, got %v", findings)
+	}
+
+	fixCheck(t, "line-endings", Input{Files: []string{"crlf.txt"}})
+	if got := readFile(t, "crlf.txt"); got != "a\nb\n" {
+		t.Errorf("Unexpected fixed content %q", got)
+	}
+}
+
+func TestLargeFiles(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "big.bin", strings.Repeat("x", 3*1024), 0644)
+	writeFile(t, "small.txt", "x", 0644)
+
+	findings := runCheck(t, "large-files", Input{
+		Files:   []string{"big.bin", "small.txt"},
+		Options: map[string]string{"max_kb": "2"},
+	})
+	if len(findings) != 1 || findings[0].File != "big.bin" {
+		t.Fatalf("Expected big.bin to be reported, got %v", findings)
+	}
+
+	check, _ := Lookup("large-files")
+	if _, err := check.Run(Input{Options: map[string]string{"max_kb": "lots"}}); err == nil {
+		t.Error("Expected an error for a non-numeric max_kb")
+	}
+}
+
+func TestCaseConflict(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "docs/README.md", "x", 0644)
+
+	findings := runCheck(t, "case-conflict", Input{Files: []string{"docs/README.md", "docs/readme.md", "src/a.go", "SRC/b.go"}})
+	reported := make(map[string]bool)
+	for _, f := range findings {
+		reported[f.File] = true
+	}
+	if !reported["docs/readme.md"] || !reported["SRC/b.go"] {
+		t.Errorf("Expected conflicting files to be reported, got %v", findings)
+	}
+}
+
+func TestBrokenSymlinks(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "target.txt", "x", 0644)
+	if err := os.Symlink("target.txt", "good"); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	os.Symlink("nowhere.txt", "bad")
+
+	findings := runCheck(t, "broken-symlinks", Input{Files: []string{"good", "bad", "target.txt"}})
+	if len(findings) != 1 || findings[0].File != "bad" {
+		t.Fatalf("Expected only the broken link to be reported, got %v", findings)
+	}
+}
+
+func TestShebangExecutable(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "script.sh", "#!/bin/sh\necho hi\n", 0644)
+	writeFile(t, "run.sh", "#!/bin/sh\n", 0755)
+	writeFile(t, "notes.txt", "plain\n", 0644)
+
+	files := []string{"script.sh", "run.sh", "notes.txt"}
+	findings := runCheck(t, "shebang-executable", Input{Files: files})
+	if len(findings) != 1 || findings[0].File != "script.sh" {
+		t.Fatalf("Expected script.sh to be reported, got %v", findings)
+	}
+
+	fixCheck(t, "shebang-executable", Input{Files: files})
+	info, err := os.Stat("script.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("Expected mode 0755, got %v", info.Mode().Perm())
+	}
+}
+
+// stagedInfo is an os.FileInfo standing in for an index entry.
+type stagedInfo struct {
+	os.FileInfo
+	size int64
+	mode os.FileMode
+}
+
+func (i stagedInfo) Size() int64       { return i.size }
+func (i stagedInfo) Mode() os.FileMode { return i.mode }
+
+func TestHygiene_UsesStagedSizeAndMode(t *testing.T) {
+	chdirTemp(t)
+	// The working copies differ from what is staged.
+	writeFile(t, "big.bin", "x", 0644)
+	writeFile(t, "script.sh", "#!/bin/sh\n", 0755)
+
+	staged := map[string]stagedInfo{
+		"big.bin":   {size: 5000, mode: 0644},
+		"script.sh": {size: 10, mode: 0644},
+	}
+	in := Input{
+		Files:   []string{"big.bin", "script.sh"},
+		Options: map[string]string{"max_kb": "1"},
+		Stat:    func(path string) (os.FileInfo, error) { return staged[path], nil },
+	}
+
+	if findings := runCheck(t, "large-files", in); len(findings) != 1 || findings[0].File != "big.bin" {
+		t.Errorf("Expected the staged size to be checked, got %v", findings)
+	}
+	if findings := runCheck(t, "shebang-executable", in); len(findings) != 1 || findings[0].File != "script.sh" {
+		t.Errorf("Expected the staged mode to be checked, got %v", findings)
+	}
+}
+
+func TestShebangExecutable_FixStagesMode(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	chdirTemp(t)
+	writeFile(t, "script.sh", "#!/bin/sh\necho hi\n", 0644)
+	for _, args := range [][]string{{"init", "-q"}, {"add", "script.sh"}} {
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	fixCheck(t, "shebang-executable", Input{Files: []string{"script.sh"}})
+
+	output, err := exec.Command("git", "ls-files", "-s", "script.sh").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(output), "100755 ") {
+		t.Errorf("Expected the index to record mode 100755, got %q", output)
+	}
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	if len(names) != len(registry) {
+		t.Fatalf("Expected %d names, got %d", len(registry), len(names))
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Errorf("Expected sorted names, got %v", names)
+		}
+	}
+}
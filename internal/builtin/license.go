@@ -0,0 +1,377 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commentStyle describes how a file type writes comments. Line styles only
+// set Prefix; block styles set Start and End.
+type commentStyle struct {
+	Prefix string
+	Start  string
+	End    string
+}
+
+var defaultCommentStyles = map[string]commentStyle{
+	".go": {Prefix: "//"}, ".js": {Prefix: "//"}, ".jsx": {Prefix: "//"}, ".ts": {Prefix: "//"},
+	".tsx": {Prefix: "//"}, ".mjs": {Prefix: "//"}, ".java": {Prefix: "//"}, ".kt": {Prefix: "//"},
+	".scala": {Prefix: "//"}, ".swift": {Prefix: "//"}, ".dart": {Prefix: "//"}, ".rs": {Prefix: "//"},
+	".c": {Prefix: "//"}, ".h": {Prefix: "//"}, ".cc": {Prefix: "//"}, ".cpp": {Prefix: "//"},
+	".hpp": {Prefix: "//"}, ".cs": {Prefix: "//"}, ".php": {Prefix: "//"}, ".proto": {Prefix: "//"},
+	".py": {Prefix: "#"}, ".rb": {Prefix: "#"}, ".sh": {Prefix: "#"}, ".bash": {Prefix: "#"},
+	".zsh": {Prefix: "#"}, ".pl": {Prefix: "#"}, ".r": {Prefix: "#"}, ".yml": {Prefix: "#"},
+	".yaml": {Prefix: "#"}, ".toml": {Prefix: "#"}, ".tf": {Prefix: "#"}, ".ex": {Prefix: "#"},
+	".exs": {Prefix: "#"},
+	".sql": {Prefix: "--"}, ".lua": {Prefix: "--"}, ".hs": {Prefix: "--"},
+	".css": {Start: "/*", End: "*/"}, ".scss": {Start: "/*", End: "*/"}, ".less": {Start: "/*", End: "*/"},
+	".html": {Start: "<!--", End: "-->"}, ".xml": {Start: "<!--", End: "-->"}, ".vue": {Start: "<!--", End: "-->"},
+	".svg": {Start: "<!--", End: "-->"}, ".md": {Start: "<!--", End: "-->"},
+}
+
+// yearPattern matches a single year or a year range such as 2019-2025.
+const yearPattern = `(\d{4})(?:\s*-\s*(\d{4}))?`
+
+var yearRegexp = regexp.MustCompile(yearPattern)
+
+// licenseSettings is the parsed form of the license-header options.
+type licenseSettings struct {
+	template           string
+	owner              string
+	year               string
+	requireCurrentYear bool
+	styles             map[string]commentStyle
+}
+
+// parseLicenseSettings reads the hook options. The header comes from "header"
+// or "header_file" and may use {year} and {owner}; "style.<ext>" options set
+// comment styles, either a line prefix ("--") or block delimiters ("/* */").
+func parseLicenseSettings(options map[string]string) (*licenseSettings, error) {
+	settings := &licenseSettings{
+		template: options["header"],
+		owner:    options["owner"],
+		year:     options["year"],
+		styles:   make(map[string]commentStyle),
+	}
+
+	if file := options["header_file"]; file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header_file: %w", err)
+		}
+		settings.template = string(data)
+	}
+	settings.template = strings.TrimRight(settings.template, "\n")
+	if strings.TrimSpace(settings.template) == "" {
+		return nil, fmt.Errorf("license-header requires the header or header_file option")
+	}
+	if strings.Contains(settings.template, "{owner}") && settings.owner == "" {
+		return nil, fmt.Errorf("license-header template uses {owner} but the owner option is not set")
+	}
+	if settings.year == "" {
+		settings.year = strconv.Itoa(time.Now().Year())
+	}
+	if value := options["require_current_year"]; value != "" {
+		require, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("option require_current_year must be true or false, got %q", value)
+		}
+		settings.requireCurrentYear = require
+	}
+
+	for ext, style := range defaultCommentStyles {
+		settings.styles[ext] = style
+	}
+	for key, value := range options {
+		ext, ok := strings.CutPrefix(key, "style.")
+		if !ok {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		parts := strings.Fields(value)
+		switch len(parts) {
+		case 1:
+			settings.styles[strings.ToLower(ext)] = commentStyle{Prefix: parts[0]}
+		case 2:
+			settings.styles[strings.ToLower(ext)] = commentStyle{Start: parts[0], End: parts[1]}
+		default:
+			return nil, fmt.Errorf("option %s must be a line prefix or block delimiters, got %q", key, value)
+		}
+	}
+
+	return settings, nil
+}
+
+// render formats the header for a file type with the given year text.
+func (s *licenseSettings) render(style commentStyle, year string) []string {
+	text := strings.NewReplacer("{year}", year, "{owner}", s.owner).Replace(s.template)
+	lines := strings.Split(text, "\n")
+
+	var out []string
+	if style.Prefix != "" {
+		for _, line := range lines {
+			out = append(out, strings.TrimRight(style.Prefix+" "+line, " "))
+		}
+		return out
+	}
+
+	linePrefix, end := "", style.End
+	if style.Start == "/*" {
+		linePrefix, end = " *", " */"
+	}
+	out = append(out, style.Start)
+	for _, line := range lines {
+		out = append(out, strings.TrimRight(linePrefix+" "+line, " "))
+	}
+	return append(out, end)
+}
+
+// pattern returns a regular expression matching the rendered header with any
+// year or year range in place of {year}.
+func (s *licenseSettings) pattern(style commentStyle) *regexp.Regexp {
+	const marker = "\x00YEAR\x00"
+	var quoted []string
+	for _, line := range s.render(style, marker) {
+		quoted = append(quoted, strings.ReplaceAll(regexp.QuoteMeta(line), marker, yearPattern))
+	}
+	return regexp.MustCompile(`^` + strings.Join(quoted, `\n`) + `(?:\n|$)`)
+}
+
+// headerLayout locates the preamble (shebang, XML or PHP opening lines) and
+// the existing top comment block of a file.
+type headerLayout struct {
+	preamble []string
+	comment  []string
+	rest     []string
+}
+
+func splitHeader(content string, style commentStyle) headerLayout {
+	lines := strings.Split(content, "\n")
+	var layout headerLayout
+
+	i := 0
+	for i < len(lines) && (strings.HasPrefix(lines[i], "#!") || strings.HasPrefix(lines[i], "<?xml") ||
+		strings.HasPrefix(lines[i], "<?php") || strings.HasPrefix(lines[i], "<!DOCTYPE")) {
+		i++
+	}
+	layout.preamble = lines[:i]
+	for i > 0 && i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+
+	start := i
+	if style.Prefix != "" {
+		for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), style.Prefix) {
+			i++
+		}
+	} else if i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), style.Start) {
+		for i < len(lines) {
+			closed := strings.Contains(lines[i], style.End) && (i > start || strings.Index(lines[i], style.End) > strings.Index(lines[i], style.Start))
+			i++
+			if closed {
+				break
+			}
+		}
+	}
+	layout.comment = lines[start:i]
+	layout.rest = lines[i:]
+	return layout
+}
+
+// isLicenseComment reports whether a comment block looks like a license header.
+func isLicenseComment(comment []string) bool {
+	text := strings.ToLower(strings.Join(comment, "\n"))
+	return strings.Contains(text, "copyright") || strings.Contains(text, "license") || strings.Contains(text, "spdx-license-identifier")
+}
+
+// isLicenseLine reports whether a comment line belongs to a license header:
+// a copyright or SPDX line, or a line of the configured header.
+func (s *licenseSettings) isLicenseLine(line string, style commentStyle) bool {
+	text := strings.ToLower(line)
+	if strings.Contains(text, "copyright") || strings.Contains(text, "spdx-license-identifier") {
+		return true
+	}
+	if commentText(line, style) == "" {
+		return false
+	}
+	for _, header := range s.headerLines(style) {
+		if header.MatchString(strings.TrimRight(line, " ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerLines returns a regular expression per line of the rendered header.
+func (s *licenseSettings) headerLines(style commentStyle) []*regexp.Regexp {
+	const marker = "\x00YEAR\x00"
+	var lines []*regexp.Regexp
+	for _, line := range s.render(style, marker) {
+		lines = append(lines, regexp.MustCompile(`^`+strings.ReplaceAll(regexp.QuoteMeta(line), marker, yearPattern)+`$`))
+	}
+	return lines
+}
+
+// commentText returns a comment line without its comment markers.
+func commentText(line string, style commentStyle) string {
+	text := strings.TrimSpace(line)
+	if style.Prefix != "" {
+		return strings.TrimSpace(strings.TrimPrefix(text, style.Prefix))
+	}
+	for _, marker := range []string{style.Start, style.End, "*"} {
+		text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSuffix(text, marker), marker))
+	}
+	return text
+}
+
+// splitLicenseComment separates the license lines at the top of a comment
+// block from the comment lines that follow them, such as a package doc
+// comment. The remainder is nil when the block holds nothing else.
+func (s *licenseSettings) splitLicenseComment(comment []string, style commentStyle) []string {
+	if style.Prefix != "" {
+		end := 0
+		for i, line := range comment {
+			if s.isLicenseLine(line, style) {
+				end = i + 1
+			} else if commentText(line, style) != "" {
+				break
+			}
+		}
+		rest := comment[end:]
+		for len(rest) > 0 && commentText(rest[0], style) == "" {
+			rest = rest[1:]
+		}
+		return rest
+	}
+
+	// Block comments keep their other lines in a block of their own.
+	if len(comment) < 3 || strings.TrimSpace(comment[0]) != style.Start || !strings.HasSuffix(strings.TrimSpace(comment[len(comment)-1]), style.End) ||
+		commentText(comment[len(comment)-1], style) != "" {
+		return nil
+	}
+	var kept []string
+	for _, line := range comment[1 : len(comment)-1] {
+		if !s.isLicenseLine(line, style) && (len(kept) > 0 || commentText(line, style) != "") {
+			kept = append(kept, line)
+		}
+	}
+	for len(kept) > 0 && commentText(kept[len(kept)-1], style) == "" {
+		kept = kept[:len(kept)-1]
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return append(append([]string{comment[0]}, kept...), comment[len(comment)-1])
+}
+
+// licenseStatus describes the header of a single file.
+type licenseStatus int
+
+const (
+	licenseOK licenseStatus = iota
+	licenseMissing
+	licenseOutdated
+	licenseUnsupported
+)
+
+// inspectLicense returns the status of a file's header and the year text to
+// keep when rewriting it.
+func (s *licenseSettings) inspectLicense(file string, content []byte) (licenseStatus, string) {
+	style, ok := s.styles[strings.ToLower(filepath.Ext(file))]
+	if !ok {
+		return licenseUnsupported, ""
+	}
+
+	layout := splitHeader(string(content), style)
+	block := strings.Join(append(append([]string{}, layout.comment...), layout.rest...), "\n")
+	if match := s.pattern(style).FindStringSubmatch(block); match != nil {
+		end := match[1]
+		if match[2] != "" {
+			end = match[2]
+		}
+		if s.requireCurrentYear && end != s.year {
+			return licenseOutdated, match[1] + "-" + s.year
+		}
+		return licenseOK, ""
+	}
+
+	// A header that does not match is rewritten for the configured owner
+	// and year; its old year belongs to the old text.
+	if len(layout.comment) > 0 && isLicenseComment(layout.comment) {
+		return licenseOutdated, s.year
+	}
+	return licenseMissing, s.year
+}
+
+func checkLicenseHeader(in Input) ([]Finding, error) {
+	settings, err := parseLicenseSettings(in.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	err = textFiles(in, func(file string, data []byte) {
+		switch status, _ := settings.inspectLicense(file, data); status {
+		case licenseMissing:
+			findings = append(findings, Finding{File: file, Line: 1, Message: "missing license header"})
+		case licenseOutdated:
+			findings = append(findings, Finding{File: file, Line: 1, Message: "outdated license header"})
+		}
+	})
+	return findings, err
+}
+
+func fixLicenseHeader(in Input) ([]string, error) {
+	settings, err := parseLicenseSettings(in.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return rewriteFiles(in.Files, func(file string, data []byte) []byte {
+		status, year := settings.inspectLicense(file, data)
+		if status != licenseMissing && status != licenseOutdated {
+			return data
+		}
+
+		style := settings.styles[strings.ToLower(filepath.Ext(file))]
+		layout := splitHeader(string(data), style)
+		header := settings.render(style, year)
+
+		var lines []string
+		lines = append(lines, layout.preamble...)
+		if len(layout.preamble) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, header...)
+		rest := layout.rest
+		switch {
+		case status == licenseMissing:
+			rest = append(append([]string{}, layout.comment...), layout.rest...)
+		case isLicenseComment(layout.comment):
+			if kept := settings.splitLicenseComment(layout.comment, style); len(kept) > 0 {
+				rest = append(append([]string{}, kept...), layout.rest...)
+			}
+		}
+		for len(rest) > 0 && rest[0] == "" && len(layout.preamble) > 0 {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 && rest[0] != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, rest...)
+		return bytes.TrimLeft([]byte(strings.Join(lines, "\n")), "\n")
+	})
+}
+
+func validateLicenseOptions(options map[string]string) error {
+	_, err := parseLicenseSettings(options)
+	return err
+}
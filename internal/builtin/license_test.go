@@ -0,0 +1,133 @@
+package builtin
+
+import (
+	"strings"
+	"testing"
+)
+
+func licenseOptions() map[string]string {
+	return map[string]string{
+		"header":    "Copyright {year} {owner}\nSPDX-License-Identifier: MIT",
+		"owner":     "Acme Inc.",
+		"year":      "2026",
+		"style.sql": "--",
+	}
+}
+
+func TestLicenseHeader_Check(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "ok.go", "// Copyright 2019 Acme Inc.\n// SPDX-License-Identifier: MIT\n\npackage main\n", 0644)
+	writeFile(t, "missing.go", "package main\n", 0644)
+	writeFile(t, "outdated.py", "# Copyright 2020 Old Corp\n# SPDX-License-Identifier: MIT\n\nprint(1)\n", 0644)
+	writeFile(t, "query.sql", "-- Copyright 2026 Acme Inc.\n-- SPDX-License-Identifier: MIT\nSELECT 1;\n", 0644)
+	writeFile(t, "data.bin.unknown", "anything\n", 0644)
+
+	findings := runCheck(t, "license-header", Input{
+		Files:   []string{"ok.go", "missing.go", "outdated.py", "query.sql", "data.bin.unknown"},
+		Options: licenseOptions(),
+	})
+
+	messages := make(map[string]string)
+	for _, f := range findings {
+		messages[f.File] = f.Message
+	}
+	if len(findings) != 2 {
+		t.Errorf("Expected 2 findings, got %v", findings)
+	}
+	if messages["missing.go"] != "missing license header" {
+		t.Errorf("Expected missing.go to be reported as missing, got %v", findings)
+	}
+	if messages["outdated.py"] != "outdated license header" {
+		t.Errorf("Expected outdated.py to be reported as outdated, got %v", findings)
+	}
+}
+
+func TestLicenseHeader_Fix(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "main.go", "// Package main does things.\npackage main\n", 0644)
+	writeFile(t, "run.sh", "#!/bin/sh\necho hi\n", 0755)
+	writeFile(t, "style.css", "/*\n * Copyright 2021 Old Corp\n */\nbody {}\n", 0644)
+
+	files := []string{"main.go", "run.sh", "style.css"}
+	fixed := fixCheck(t, "license-header", Input{Files: files, Options: licenseOptions()})
+	if len(fixed) != 3 {
+		t.Errorf("Expected 3 fixed files, got %v", fixed)
+	}
+
+	expected := map[string]string{
+		"main.go":   "// Copyright 2026 Acme Inc.\n// SPDX-License-Identifier: MIT\n\n// Package main does things.\npackage main\n",
+		"run.sh":    "#!/bin/sh\n\n# Copyright 2026 Acme Inc.\n# SPDX-License-Identifier: MIT\n\necho hi\n",
+		"style.css": "/*\n * Copyright 2026 Acme Inc.\n * SPDX-License-Identifier: MIT\n */\n\nbody {}\n",
+	}
+	for file, want := range expected {
+		if got := readFile(t, file); got != want {
+			t.Errorf("Unexpected content for %s:\n%s\nwant:\n%s", file, got, want)
+		}
+	}
+
+	if findings := runCheck(t, "license-header", Input{Files: files, Options: licenseOptions()}); len(findings) != 0 {
+		t.Errorf("Expected fixed files to pass, got %v", findings)
+	}
+}
+
+func TestLicenseHeader_FixKeepsFollowingComments(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "a.go", "// Copyright 2020 OldCo\n// Package a does things.\npackage a\n", 0644)
+	writeFile(t, "b.go", "// Copyright 2020 OldCo\n// SPDX-License-Identifier: MIT\n//\n// Package b does things.\npackage b\n", 0644)
+	writeFile(t, "c.css", "/*\n * Copyright 2020 OldCo\n *\n * Theme styles.\n */\nbody {}\n", 0644)
+
+	files := []string{"a.go", "b.go", "c.css"}
+	fixCheck(t, "license-header", Input{Files: files, Options: licenseOptions()})
+
+	expected := map[string]string{
+		"a.go":  "// Copyright 2026 Acme Inc.\n// SPDX-License-Identifier: MIT\n\n// Package a does things.\npackage a\n",
+		"b.go":  "// Copyright 2026 Acme Inc.\n// SPDX-License-Identifier: MIT\n\n// Package b does things.\npackage b\n",
+		"c.css": "/*\n * Copyright 2026 Acme Inc.\n * SPDX-License-Identifier: MIT\n */\n\n/*\n * Theme styles.\n */\nbody {}\n",
+	}
+	for file, want := range expected {
+		if got := readFile(t, file); got != want {
+			t.Errorf("Unexpected content for %s:\n%s\nwant:\n%s", file, got, want)
+		}
+	}
+
+	options := licenseOptions()
+	options["require_current_year"] = "true"
+	if findings := runCheck(t, "license-header", Input{Files: files, Options: options}); len(findings) != 0 {
+		t.Errorf("Expected fixed files to pass, got %v", findings)
+	}
+}
+
+func TestLicenseHeader_RequireCurrentYear(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "main.go", "// Copyright 2019 Acme Inc.\n// SPDX-License-Identifier: MIT\n\npackage main\n", 0644)
+
+	options := licenseOptions()
+	options["require_current_year"] = "true"
+
+	findings := runCheck(t, "license-header", Input{Files: []string{"main.go"}, Options: options})
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "outdated") {
+		t.Fatalf("Expected an outdated header, got %v", findings)
+	}
+
+	fixCheck(t, "license-header", Input{Files: []string{"main.go"}, Options: options})
+	if got := readFile(t, "main.go"); !strings.HasPrefix(got, "// Copyright 2019-2026 Acme Inc.\n") {
+		t.Errorf("Expected the year range to be updated, got %q", got)
+	}
+}
+
+func TestLicenseHeader_Options(t *testing.T) {
+	check, _ := Lookup("license-header")
+
+	if err := check.Validate(map[string]string{}); err == nil {
+		t.Error("Expected an error without a header")
+	}
+	if err := check.Validate(map[string]string{"header": "Copyright {owner}"}); err == nil {
+		t.Error("Expected an error when {owner} is used without an owner")
+	}
+	if err := check.Validate(map[string]string{"header": "x", "style.sql": "a b c"}); err == nil {
+		t.Error("Expected an error for an invalid comment style")
+	}
+	if err := check.Validate(licenseOptions()); err != nil {
+		t.Errorf("Expected valid options, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package builtin
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// allowMarker suppresses secret findings on the line it appears on.
+const allowMarker = "quality-gate:allow"
+
+// defaultAllowlistFile is read when the hook does not set the allowlist option.
+const defaultAllowlistFile = ".secrets-allowlist"
+
+// secretRule detects one kind of secret. When Group is set, only that
+// submatch is reported and checked against the allowlist.
+type secretRule struct {
+	ID          string
+	Description string
+	Pattern     *regexp.Regexp
+	Group       int
+}
+
+var secretRules = []secretRule{
+	{ID: "aws-access-key", Description: "AWS access key ID", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{ID: "aws-secret-key", Description: "AWS secret access key", Pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?['"=:\s]([A-Za-z0-9/+=]{40})\b`), Group: 1},
+	{ID: "github-token", Description: "GitHub token", Pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,255}\b`)},
+	{ID: "github-fine-grained-token", Description: "GitHub fine-grained token", Pattern: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{82}\b`)},
+	{ID: "gitlab-token", Description: "GitLab personal access token", Pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20}\b`)},
+	{ID: "slack-token", Description: "Slack token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{ID: "slack-webhook", Description: "Slack webhook URL", Pattern: regexp.MustCompile(`https://hooks\.slack\.com/services/T[A-Za-z0-9_]+/B[A-Za-z0-9_]+/[A-Za-z0-9_]+`)},
+	{ID: "google-api-key", Description: "Google API key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{ID: "stripe-key", Description: "Stripe secret key", Pattern: regexp.MustCompile(`\b(?:sk|rk)_live_[0-9A-Za-z]{24,}\b`)},
+	{ID: "npm-token", Description: "npm access token", Pattern: regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`)},
+	{ID: "private-key", Description: "private key", Pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{ID: "jwt", Description: "JSON Web Token", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}\b`)},
+}
+
+// secretAssignment matches values assigned to secret-looking names, which are
+// reported when their entropy suggests a real credential.
+var secretAssignment = regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|credential|auth)[A-Za-z0-9_\-]*["']?\s*[:=]\s*["']([^"'\s]{16,})["']`)
+
+var (
+	base64Charset = regexp.MustCompile(`^[A-Za-z0-9+/=_\-]+$`)
+	hexCharset    = regexp.MustCompile(`^[A-Fa-f0-9]+$`)
+)
+
+// secretAllowlist holds the suppressions read from the allowlist file.
+type secretAllowlist struct {
+	paths    []string
+	patterns []*regexp.Regexp
+}
+
+// loadSecretAllowlist reads an allowlist file. Each line is a regular
+// expression matched against detected secrets, or "path:<glob>" to skip files.
+func loadSecretAllowlist(file string, required bool) (*secretAllowlist, error) {
+	allowlist := &secretAllowlist{}
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return allowlist, nil
+		}
+		return nil, fmt.Errorf("failed to read secrets allowlist: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if glob, ok := strings.CutPrefix(line, "path:"); ok {
+			allowlist.paths = append(allowlist.paths, strings.TrimSpace(glob))
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern: %w", file, lineNumber, err)
+		}
+		allowlist.patterns = append(allowlist.patterns, re)
+	}
+	return allowlist, scanner.Err()
+}
+
+func (a *secretAllowlist) allowsFile(file string) bool {
+	slashed := filepath.ToSlash(file)
+	for _, glob := range a.paths {
+		if ok, _ := path.Match(glob, slashed); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, path.Base(slashed)); ok && !strings.Contains(glob, "/") {
+			return true
+		}
+		if strings.HasSuffix(glob, "/") && strings.HasPrefix(slashed, glob) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *secretAllowlist) allowsSecret(secret string) bool {
+	for _, re := range a.patterns {
+		if re.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// defaultEntropyThreshold is the entropy in bits per character above which
+// assigned values are reported, the level gitleaks uses. A string of n
+// distinct characters has log2(n) bits, so a higher default would miss
+// tokens shorter than 2^threshold characters.
+const defaultEntropyThreshold = 3.5
+
+// highEntropy reports whether value looks like a random credential.
+func highEntropy(value string, threshold float64) bool {
+	switch {
+	case hexCharset.MatchString(value):
+		// Hex strings carry at most 4 bits per character.
+		return shannonEntropy(value) > threshold*3/defaultEntropyThreshold
+	case base64Charset.MatchString(value):
+		return shannonEntropy(value) > threshold
+	default:
+		return false
+	}
+}
+
+// redact keeps only the edges of a secret so findings do not leak it again.
+func redact(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", 4) + secret[len(secret)-4:]
+}
+
+// scanSecrets scans content for secrets and returns findings for file.
+func scanSecrets(file string, content []byte, allowlist *secretAllowlist, entropyThreshold float64) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.Contains(line, allowMarker) {
+			continue
+		}
+
+		reported := make(map[string]bool)
+		add := func(secret, description string) {
+			if reported[secret] || (allowlist != nil && allowlist.allowsSecret(secret)) {
+				return
+			}
+			reported[secret] = true
+			findings = append(findings, Finding{
+				File:    file,
+				Line:    i + 1,
+				Message: fmt.Sprintf("possible %s (%s)", description, redact(secret)),
+			})
+		}
+
+		for _, rule := range secretRules {
+			for _, match := range rule.Pattern.FindAllStringSubmatch(line, -1) {
+				add(match[rule.Group], rule.Description)
+			}
+		}
+
+		if entropyThreshold > 0 {
+			for _, match := range secretAssignment.FindAllStringSubmatch(line, -1) {
+				if highEntropy(match[1], entropyThreshold) {
+					add(match[1], "high-entropy secret")
+				}
+			}
+		}
+	}
+	return findings
+}
+
+func checkSecrets(in Input) ([]Finding, error) {
+	allowlistFile, custom := in.Options["allowlist"]
+	if !custom {
+		allowlistFile = defaultAllowlistFile
+	}
+	allowlist, err := loadSecretAllowlist(allowlistFile, custom)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := defaultEntropyThreshold
+	if value, ok := in.Options["entropy"]; ok {
+		threshold, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("option entropy must be a number, got %q", value)
+		}
+	}
+
+	var findings []Finding
+	err = textFiles(in, func(file string, data []byte) {
+		if filepath.Clean(file) == filepath.Clean(allowlistFile) || allowlist.allowsFile(file) {
+			return
+		}
+		findings = append(findings, scanSecrets(file, data, allowlist, threshold)...)
+	})
+	return findings, err
+}
+
+func validateSecretsOptions(options map[string]string) error {
+	if value, ok := options["entropy"]; ok {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return fmt.Errorf("option entropy must be a number, got %q", value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package builtin
+
+import (
+	"strings"
+	"testing"
+)
+
+// Secrets are assembled at runtime so this file does not trip secret scanners.
+var (
+	fakeAWSKey      = "AKIA" + "IOSFODNN7EXAMPLE"
+	fakeGitHubToken = "ghp_" + strings.Repeat("aB3dE5fG7h", 4)
+	fakeRandomValue = "q8Zr" + "T2vXk9LmN4pW7sYb1CdF6gHj"
+)
+
+func TestScanSecrets(t *testing.T) {
+	content := strings.Join([]string{
+		"package config",
+		`const key = "` + fakeAWSKey + `"`,
+		`token := "` + fakeGitHubToken + `" // quality-gate:allow`,
+		`api_key = "` + fakeRandomValue + `"`,
+		`password = "aaaaaaaaaaaaaaaaaaaa"`,
+		"-----BEGIN RSA " + "PRIVATE KEY-----",
+	}, "\n")
+
+	findings := scanSecrets("config.go", []byte(content), &secretAllowlist{}, 4.0)
+
+	lines := make(map[int]string)
+	for _, f := range findings {
+		lines[f.Line] = f.Message
+	}
+
+	if !strings.Contains(lines[2], "AWS access key ID") {
+		t.Errorf("Expected AWS key on line 2, got %v", findings)
+	}
+	if strings.Contains(lines[2], fakeAWSKey) {
+		t.Errorf("Expected the secret to be redacted, got %q", lines[2])
+	}
+	if _, ok := lines[3]; ok {
+		t.Errorf("Expected inline allow marker to suppress line 3, got %v", findings)
+	}
+	if !strings.Contains(lines[4], "high-entropy") {
+		t.Errorf("Expected high-entropy finding on line 4, got %v", findings)
+	}
+	if _, ok := lines[5]; ok {
+		t.Errorf("Did not expect a low-entropy value to be reported, got %v", findings)
+	}
+	if !strings.Contains(lines[6], "private key") {
+		t.Errorf("Expected private key on line 6, got %v", findings)
+	}
+}
+
+func TestCheckSecrets_DefaultEntropyDetectsShortTokens(t *testing.T) {
+	chdirTemp(t)
+	short := "Xk7p" + "Q2mZ9rTb4LwN8vCj"
+	writeFile(t, "app.env", strings.Join([]string{
+		`API_TOKEN="` + short + `"`,
+		`SECRET="` + fakeRandomValue + `"`,
+		`PASSWORD="aaaaaaaaaaaaaaaaaaaa"`,
+	}, "\n")+"\n", 0644)
+
+	findings := runCheck(t, "secrets", Input{Files: []string{"app.env"}})
+	if len(findings) != 2 || findings[0].Line != 1 || findings[1].Line != 2 {
+		t.Fatalf("Expected the 20 and 28 character tokens to be reported, got %v", findings)
+	}
+}
+
+func TestCheckSecrets_Allowlist(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "app.env", "AWS_KEY="+fakeAWSKey+"\nGH="+fakeGitHubToken+"\n", 0644)
+	writeFile(t, "fixtures/keys.txt", fakeAWSKey+"\n", 0644)
+	writeFile(t, ".secrets-allowlist", "# test fixtures\npath:fixtures/*\n^ghp_\n", 0644)
+
+	findings := runCheck(t, "secrets", Input{Files: []string{"app.env", "fixtures/keys.txt", ".secrets-allowlist"}})
+	if len(findings) != 1 || findings[0].File != "app.env" || findings[0].Line != 1 {
+		t.Fatalf("Expected only the AWS key in app.env, got %v", findings)
+	}
+
+	check, _ := Lookup("secrets")
+	if _, err := check.Run(Input{Options: map[string]string{"allowlist": "missing.txt"}}); err == nil {
+		t.Error("Expected an error for a missing custom allowlist")
+	}
+}
+
+func TestCheckSecrets_ReadsProvidedContent(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, "main.go", "package main\n", 0644)
+
+	staged := func(path string) ([]byte, error) {
+		return []byte("const k = \"" + fakeAWSKey + "\"\n"), nil
+	}
+
+	findings := runCheck(t, "secrets", Input{Files: []string{"main.go"}, ReadFile: staged})
+	if len(findings) != 1 {
+		t.Fatalf("Expected the staged content to be scanned, got %v", findings)
+	}
+}
+
+func TestShannonEntropy(t *testing.T) {
+	if got := shannonEntropy("aaaa"); got != 0 {
+		t.Errorf("Expected zero entropy, got %f", got)
+	}
+	if got := shannonEntropy("abcd"); got != 2 {
+		t.Errorf("Expected entropy 2, got %f", got)
+	}
+}
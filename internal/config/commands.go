@@ -0,0 +1,421 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/shellparse"
+)
+
+// commandWrappers run the command given in their arguments.
+var commandWrappers = map[string]bool{
+	"sudo": true, "doas": true, "env": true, "nohup": true, "command": true, "exec": true,
+	"time": true, "nice": true, "xargs": true, "npx": true, "pnpx": true, "bunx": true,
+}
+
+// wrapperOptionValues lists the wrapper options followed by a separate value.
+var wrapperOptionValues = map[string][]string{
+	"sudo":  {"-u", "-g", "-C", "-D", "-h", "-p", "-r", "-t", "-U"},
+	"doas":  {"-u", "-C"},
+	"env":   {"-u", "-C", "-S"},
+	"nice":  {"-n"},
+	"xargs": {"-n", "-I", "-L", "-P", "-d", "-E", "-s", "-a"},
+	"npx":   {"-p", "--package"},
+}
+
+// packageRunners run a package binary with a subcommand, as in "pnpm exec eslint".
+var packageRunners = map[string][]string{
+	"npm":  {"exec", "x"},
+	"pnpm": {"exec", "dlx"},
+	"yarn": {"exec", "dlx"},
+	"bun":  {"x"},
+}
+
+var (
+	downloaders  = []string{"curl", "wget", "fetch"}
+	interpreters = []string{"sh", "bash", "zsh", "dash", "ksh", "fish", "python", "python3", "perl", "ruby", "node", "php", "eval", "source", "."}
+	diskDevice   = regexp.MustCompile(`^/dev/(sd|hd|vd|xvd|nvme|mmcblk|disk)`)
+	unguardedDir = regexp.MustCompile(`^\$[A-Za-z_][A-Za-z0-9_]*/\*?$`)
+)
+
+// commandCall is the command a simple command runs, looking through
+// wrappers such as sudo, env and npx.
+type commandCall struct {
+	Name string
+	// Word is the word naming the command.
+	Word *shellparse.Word
+	Args []*shellparse.Word
+	// Privileged is set when the command runs through sudo or doas.
+	Privileged bool
+}
+
+// resolveCall returns the command run by a simple command.
+func resolveCall(cmd *shellparse.SimpleCommand) commandCall {
+	var call commandCall
+	words := cmd.Args
+	for len(words) > 0 {
+		word := words[0]
+		name, _ := word.Literal()
+		if strings.Contains(name, "/") {
+			name = path.Base(name)
+		}
+		words = words[1:]
+
+		if subcommands, ok := packageRunners[name]; ok && len(words) > 0 {
+			if sub, _ := words[0].Literal(); contains(subcommands, sub) {
+				words = words[1:]
+				continue
+			}
+		}
+		if !commandWrappers[name] {
+			call.Name, call.Word, call.Args = name, word, words
+			return call
+		}
+		if name == "sudo" || name == "doas" {
+			call.Privileged = true
+		}
+		for len(words) > 0 {
+			option, _ := words[0].Literal()
+			if !strings.HasPrefix(option, "-") && (name != "env" || !strings.Contains(option, "=")) {
+				break
+			}
+			words = words[1:]
+			if contains(wrapperOptionValues[name], option) && len(words) > 0 {
+				words = words[1:]
+			}
+		}
+	}
+	return call
+}
+
+// commandCalls returns the commands run by every simple command of a script,
+// including those in pipelines, lists and substitutions.
+func commandCalls(node shellparse.Node) []commandCall {
+	var calls []commandCall
+	for _, cmd := range shellparse.SimpleCommands(node) {
+		if call := resolveCall(cmd); call.Name != "" {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// runsAny reports whether node runs one of the commands.
+func runsAny(node shellparse.Node, names []string) string {
+	for _, call := range commandCalls(node) {
+		if contains(names, call.Name) {
+			return call.Name
+		}
+	}
+	return ""
+}
+
+// dangerousOperation returns why a parsed command is dangerous, or "".
+func dangerousOperation(script *shellparse.Script) string {
+	var reason string
+	shellparse.Walk(script, func(node shellparse.Node) bool {
+		if reason != "" {
+			return false
+		}
+		switch n := node.(type) {
+		case *shellparse.SimpleCommand:
+			reason = dangerousCall(n)
+		case *shellparse.BinaryCmd:
+			if n.Op == "|" {
+				reason = pipedDownload(n)
+			}
+		case *shellparse.Redirect:
+			if target, _ := n.Target.Literal(); strings.HasPrefix(n.Op, ">") && diskDevice.MatchString(target) {
+				reason = fmt.Sprintf("writes to the disk device %s", target)
+			}
+		case *shellparse.FuncDecl:
+			if forkBomb(n) {
+				reason = fmt.Sprintf("function %s spawns copies of itself (fork bomb)", n.Name)
+			}
+		}
+		return true
+	})
+	return reason
+}
+
+// dangerousCall checks a simple command for destructive operations.
+func dangerousCall(cmd *shellparse.SimpleCommand) string {
+	call := resolveCall(cmd)
+	switch {
+	case call.Name == "rm":
+		if call.Privileged {
+			return "runs rm with sudo"
+		}
+		return sweepingRemoval(call.Args)
+	case call.Name == "dd":
+		for _, arg := range call.Args {
+			if value, _ := arg.Literal(); strings.HasPrefix(value, "of=") && diskDevice.MatchString(value[3:]) {
+				return fmt.Sprintf("dd writes to the disk device %s", value[3:])
+			}
+		}
+	case call.Name == "mkfs" || strings.HasPrefix(call.Name, "mkfs."):
+		return fmt.Sprintf("formats a filesystem with %s", call.Name)
+	case contains(interpreters, call.Name):
+		for _, arg := range call.Args {
+			if downloader := runsAny(arg, downloaders); downloader != "" {
+				return fmt.Sprintf("runs a script downloaded with %s", downloader)
+			}
+		}
+		if script := inlineScript(call); script != "" {
+			if parsed, err := shellparse.Parse(script); err == nil {
+				if reason := dangerousOperation(parsed); reason != "" {
+					return fmt.Sprintf("%s (in %s)", reason, call.Name)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// inlineShells take the script to run as the argument of -c.
+var inlineShells = []string{"sh", "bash", "zsh", "dash", "ksh"}
+
+// inlineScript returns the script a shell runs with -c, or the command eval
+// runs, with quotes removed.
+func inlineScript(call commandCall) string {
+	if call.Name == "eval" {
+		var words []string
+		for _, arg := range call.Args {
+			words = append(words, wordText(arg))
+		}
+		return strings.Join(words, " ")
+	}
+	if !contains(inlineShells, call.Name) {
+		return ""
+	}
+	inline, optionValue := false, false
+	for _, arg := range call.Args {
+		value, literal := arg.Literal()
+		switch {
+		case optionValue:
+			optionValue = false
+		case literal && (value == "-o" || value == "+o" || value == "-O" || value == "+O"):
+			optionValue = true
+		case literal && len(value) > 1 && strings.HasPrefix(value, "-") && !strings.HasPrefix(value, "--"):
+			inline = inline || strings.Contains(value, "c")
+		case literal && strings.HasPrefix(value, "--"):
+		case inline:
+			return wordText(arg)
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// sweepingRemoval reports a recursive rm of the root, home or working
+// directory, or of a directory variable that is / when unset.
+func sweepingRemoval(args []*shellparse.Word) string {
+	recursive := false
+	var targets []*shellparse.Word
+	options := true
+	for _, arg := range args {
+		value, literal := arg.Literal()
+		switch {
+		case options && literal && value == "--":
+			options = false
+		case options && literal && strings.HasPrefix(value, "--"):
+			recursive = recursive || value == "--recursive"
+		case options && literal && len(value) > 1 && strings.HasPrefix(value, "-"):
+			recursive = recursive || strings.ContainsAny(value, "rR")
+		default:
+			targets = append(targets, arg)
+		}
+	}
+	if !recursive {
+		return ""
+	}
+
+	for _, target := range targets {
+		text := wordText(target)
+		switch {
+		case path.Clean(text) == "/":
+			return fmt.Sprintf("recursively removes %s", target.Raw)
+		case target.HasUnquotedGlob() && (path.Base(text) == "*" || path.Base(text) == ".*") && contains([]string{".", "/", "~", "$HOME"}, path.Dir(text)):
+			return fmt.Sprintf("recursively removes %s", target.Raw)
+		case text == "~" || text == "~/" || text == "$HOME" || text == "$HOME/":
+			return fmt.Sprintf("recursively removes the home directory (%s)", target.Raw)
+		case unguardedDir.MatchString(text):
+			return fmt.Sprintf("recursively removes %s, which is / when the variable is empty (use ${VAR:?})", target.Raw)
+		}
+	}
+	return ""
+}
+
+// wordText renders a word with its quotes removed and simple parameter
+// expansions written as $NAME. Other expansions keep their ${...} form.
+func wordText(word *shellparse.Word) string {
+	var b strings.Builder
+	var render func(parts []shellparse.WordPart)
+	render = func(parts []shellparse.WordPart) {
+		for _, part := range parts {
+			switch part := part.(type) {
+			case *shellparse.Lit:
+				b.WriteString(part.Value)
+			case *shellparse.SingleQuoted:
+				b.WriteString(part.Value)
+			case *shellparse.DoubleQuoted:
+				render(part.Parts)
+			case *shellparse.ParamExp:
+				if part.Expr == part.Name {
+					b.WriteString("$" + part.Name)
+				} else {
+					b.WriteString("${" + part.Expr + "}")
+				}
+			default:
+				b.WriteString("$(...)")
+			}
+		}
+	}
+	render(word.Parts)
+	return b.String()
+}
+
+// pipedDownload reports a download piped into an interpreter.
+func pipedDownload(pipe *shellparse.BinaryCmd) string {
+	var stages []*shellparse.Stmt
+	var flatten func(stmt *shellparse.Stmt)
+	flatten = func(stmt *shellparse.Stmt) {
+		if inner, ok := stmt.Cmd.(*shellparse.BinaryCmd); ok && inner.Op == "|" {
+			flatten(inner.X)
+			flatten(inner.Y)
+			return
+		}
+		stages = append(stages, stmt)
+	}
+	flatten(pipe.X)
+	flatten(pipe.Y)
+
+	for i, stage := range stages[:len(stages)-1] {
+		downloader := runsAny(stage, downloaders)
+		if downloader == "" {
+			continue
+		}
+		for _, later := range stages[i+1:] {
+			if cmd, ok := later.Cmd.(*shellparse.SimpleCommand); ok {
+				if call := resolveCall(cmd); contains(interpreters, call.Name) && readsProgramFromStdin(call) {
+					return fmt.Sprintf("pipes a download from %s into %s", downloader, call.Name)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// inlineProgramOptions are the options giving an interpreter its program
+// (or module) on the command line instead of standard input.
+var inlineProgramOptions = map[string][]string{
+	"python": {"-c", "-m"}, "python3": {"-c", "-m"}, "perl": {"-e", "-E"}, "ruby": {"-e"},
+	"node": {"-e", "-p", "--eval", "--print"}, "php": {"-r", "-f"},
+	"sh": {"-c"}, "bash": {"-c"}, "zsh": {"-c"}, "dash": {"-c"}, "ksh": {"-c"}, "fish": {"-c", "--command"},
+}
+
+// readsProgramFromStdin reports whether an interpreter runs the program it
+// reads from standard input: it has no inline program and no script
+// argument, or reads the script from - or /dev/stdin.
+func readsProgramFromStdin(call commandCall) bool {
+	if call.Name == "eval" {
+		return false
+	}
+	if call.Name == "source" || call.Name == "." {
+		if len(call.Args) == 0 {
+			return false
+		}
+		script, _ := call.Args[0].Literal()
+		return script == "-" || script == "/dev/stdin"
+	}
+
+	inline := inlineProgramOptions[call.Name]
+	for _, arg := range call.Args {
+		value, literal := arg.Literal()
+		switch {
+		case !literal:
+			return false
+		case value == "-" || value == "/dev/stdin":
+			return true
+		case value == "--":
+			// The next argument is a script
+			continue
+		case strings.HasPrefix(value, "--"):
+			if contains(inline, value) || contains(inline, strings.SplitN(value, "=", 2)[0]) {
+				return false
+			}
+		case strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+"):
+			if value == "-s" && contains(inlineShells, call.Name) {
+				// sh -s reads the script from stdin whatever follows
+				return true
+			}
+			for _, option := range inline {
+				if !strings.HasPrefix(option, "--") && strings.HasPrefix(value, "-") && strings.Contains(value[1:], option[1:]) {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// forkBomb reports a function that runs itself in a pipeline or in the
+// background.
+func forkBomb(decl *shellparse.FuncDecl) bool {
+	spawns := false
+	shellparse.Walk(decl.Body, func(node shellparse.Node) bool {
+		switch n := node.(type) {
+		case *shellparse.BinaryCmd:
+			spawns = spawns || (n.Op == "|" && callsFunction(n, decl.Name))
+		case *shellparse.Stmt:
+			spawns = spawns || (n.Background && callsFunction(n, decl.Name))
+		}
+		return !spawns
+	})
+	return spawns
+}
+
+func callsFunction(node shellparse.Node, name string) bool {
+	for _, cmd := range shellparse.SimpleCommands(node) {
+		if cmd.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// syntaxErrorIssue describes a shell syntax error, locating it in the command.
+func syntaxErrorIssue(command string, err error) string {
+	syntaxErr, ok := err.(*shellparse.SyntaxError)
+	if !ok {
+		return fmt.Sprintf("Shell syntax error: %v", err)
+	}
+	if strings.Contains(command, "\n") {
+		return fmt.Sprintf("Shell syntax error at line %d, column %d: %s", syntaxErr.Pos.Line, syntaxErr.Pos.Column, syntaxErr.Message)
+	}
+	return fmt.Sprintf("Shell syntax error at column %d: %s", syntaxErr.Pos.Column, syntaxErr.Message)
+}
+
+// commandLines renders every command a shell command runs as its name
+// followed by its arguments, unquoted, looking through wrappers. A command
+// that does not parse is returned as is.
+func commandLines(command string) []string {
+	script, err := shellparse.Parse(command)
+	if err != nil {
+		return []string{command}
+	}
+	var lines []string
+	for _, call := range commandCalls(script) {
+		words := []string{call.Name}
+		for _, arg := range call.Args {
+			words = append(words, wordText(arg))
+		}
+		lines = append(lines, strings.Join(words, " "))
+	}
+	return lines
+}
@@ -1,8 +1,66 @@
 package config
 
+import "gopkg.in/yaml.v3"
+
 type Config struct {
-	Tools Tools `yaml:"tools"`
-	Hooks Hooks `yaml:"hooks"`
+	// Version is the configuration version of the file, 1 when it declares none.
+	Version    int      `yaml:"version,omitempty"`
+	MinVersion string   `yaml:"min_version,omitempty"`
+	Extends    []Extend `yaml:"extends,omitempty"`
+	Include    []string `yaml:"include,omitempty"`
+	Tools      Tools    `yaml:"tools"`
+	Hooks      Hooks    `yaml:"hooks"`
+	// Disable lists hooks, by name, that are not run whatever their group.
+	Disable []string `yaml:"disable,omitempty"`
+	// Env holds environment variables added to hook and fix commands.
+	Env map[string]string `yaml:"env,omitempty"`
+	// Validation configures the checks of the ConfigValidator.
+	Validation Validation `yaml:"validation,omitempty"`
+
+	// Path is the file the configuration was loaded from.
+	Path string `yaml:"-"`
+	// Sources lists every file merged into the configuration, bases first.
+	Sources []string `yaml:"-"`
+	// Undefined lists the ${VAR} references to unset variables without default.
+	Undefined []UndefinedVariable `yaml:"-"`
+	// Positions locates the top-level keys, hook groups ("hooks.<group>") and
+	// hook types ("hooks.<group>.<type>").
+	Positions Positions `yaml:"-"`
+}
+
+// Extend references a base configuration: a local path, or a path inside a
+// git repository fetched at a pinned ref.
+type Extend struct {
+	Path string `yaml:"path"`
+	Git  string `yaml:"git,omitempty"`
+	Ref  string `yaml:"ref,omitempty"`
+}
+
+// UnmarshalYAML accepts a plain string as a local path.
+func (e *Extend) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Path = node.Value
+		return nil
+	}
+	type plain Extend
+	return node.Decode((*plain)(e))
+}
+
+// Validation disables validator rules by ID, overrides their severity and
+// adds custom rules.
+type Validation struct {
+	Disable  []string          `yaml:"disable,omitempty"`
+	Severity map[string]string `yaml:"severity,omitempty"`
+	Rules    []ValidationRule  `yaml:"rules,omitempty"`
+}
+
+// ValidationRule reports every command matching a regular expression.
+type ValidationRule struct {
+	ID         string `yaml:"id"`
+	Pattern    string `yaml:"pattern"`
+	Message    string `yaml:"message,omitempty"`
+	Suggestion string `yaml:"suggestion,omitempty"`
+	Severity   string `yaml:"severity,omitempty"`
 }
 
 type Tools []Tool
@@ -11,18 +69,35 @@ type Tool struct {
 	Name           string `yaml:"name"`
 	CheckCommand   string `yaml:"check_command"`
 	InstallCommand string `yaml:"install_command"`
+	// Remove deletes a tool of the same name inherited from a base configuration.
+	Remove bool `yaml:"remove,omitempty"`
+
+	Positions Positions `yaml:"-"`
 }
 
 type Hooks map[string]map[string][]Hook
 
 type Hook struct {
-	Name        string      `yaml:"name"`
-	Command     string      `yaml:"command"`
-	FixCommand  string      `yaml:"fix_command,omitempty"`
-	OutputRules OutputRules `yaml:"output_rules,omitempty"`
+	Name        string            `yaml:"name"`
+	Command     string            `yaml:"command,omitempty"`
+	Builtin     string            `yaml:"builtin,omitempty"`
+	Options     map[string]string `yaml:"options,omitempty"`
+	Files       []string          `yaml:"files,omitempty"`
+	Exclude     []string          `yaml:"exclude,omitempty"`
+	FixCommand  string            `yaml:"fix_command,omitempty"`
+	OutputRules OutputRules       `yaml:"output_rules,omitempty"`
+	// Remove deletes a hook of the same name inherited from a base configuration.
+	Remove bool `yaml:"remove,omitempty"`
+
+	Positions Positions `yaml:"-"`
 }
 
 type OutputRules struct {
 	ShowOn           string `yaml:"show_on,omitempty"`
 	OnFailureMessage string `yaml:"on_failure_message,omitempty"`
+	OnSuccessMessage string `yaml:"on_success_message,omitempty"`
+	MaxLines         int    `yaml:"max_lines,omitempty"`
+	Truncate         string `yaml:"truncate,omitempty"`
+	Filter           string `yaml:"filter,omitempty"`
+	Strip            string `yaml:"strip,omitempty"`
 }
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp is one line of a line diff: kept ('='), removed ('-') or added
+// ('+'). A and B are the indexes of the line in the old and new text, or -1.
+type diffOp struct {
+	Kind byte
+	A, B int
+}
+
+// diffLines computes a minimal line diff of a and b, listing removals before
+// additions. Configuration files are small, so a quadratic longest common
+// subsequence is fast enough.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{Kind: '=', A: i, B: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{Kind: '-', A: i, B: -1})
+			i++
+		default:
+			ops = append(ops, diffOp{Kind: '+', A: -1, B: j})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines splits text into lines without their line breaks.
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// UnifiedDiff returns the changes from before to after in the unified diff
+// format, with three lines of context, or "" when they are equal.
+func UnifiedDiff(name string, before, after []byte) string {
+	const context = 3
+	a, b := splitLines(string(before)), splitLines(string(after))
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].Kind == '=' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk over changes separated by little context
+		end := start
+		for end < len(ops) {
+			if ops[end].Kind != '=' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == '=' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+		from := max(start-context, 0)
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+		aStart, bStart := 0, 0
+		for _, op := range ops[:from] {
+			if op.A >= 0 {
+				aStart++
+			}
+			if op.B >= 0 {
+				bStart++
+			}
+		}
+		var lines strings.Builder
+		aLen, bLen := 0, 0
+		for _, op := range ops[from:end] {
+			switch op.Kind {
+			case '=':
+				lines.WriteString(" " + a[op.A] + "\n")
+				aLen++
+				bLen++
+			case '-':
+				lines.WriteString("-" + a[op.A] + "\n")
+				aLen++
+			case '+':
+				lines.WriteString("+" + b[op.B] + "\n")
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n%s", hunkRange(aStart, aLen), hunkRange(bStart, bLen), lines.String())
+		start = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the line range of a hunk. An empty range is given by
+// the line before it.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
@@ -0,0 +1,429 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dmux/go-quality-gate/internal/shellparse"
+	"gopkg.in/yaml.v3"
+)
+
+// Fix is a mechanical change of the configuration file that resolves a
+// finding, applied by `quality-gate validate --fix`.
+type Fix struct {
+	Description string `json:"description"`
+	// apply edits the root mapping of the file and reports whether it
+	// changed it. pos locates the finding in that file.
+	apply func(root *yaml.Node, pos Position) bool
+	// toConfig applies the fix to the configuration file itself rather than
+	// to the file the finding is located in.
+	toConfig bool
+}
+
+// FileFix is a configuration file with the fixes applied to it.
+type FileFix struct {
+	Path     string
+	Original []byte
+	Fixed    []byte
+	// Applied describes the fixes that changed the file.
+	Applied []string
+}
+
+// ApplyFixes applies the fixes of the findings to the configuration at path
+// and the files it includes, without writing them. Files outside the
+// directory of the configuration, such as cached remote bases, are left
+// alone. Comments are kept, and lines the fixes do not touch are kept as
+// written.
+func ApplyFixes(path string, result *ValidationResult) ([]FileFix, error) {
+	type target struct {
+		fix    *FileFix
+		doc    *yaml.Node
+		before []byte
+		indent int
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*target
+	byFile := make(map[string]*target)
+	for _, finding := range result.Errors {
+		if finding.Fix == nil {
+			continue
+		}
+		file := finding.Position.File
+		if finding.Fix.toConfig || file == "" {
+			file = path
+		}
+		file, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(dir, file); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		t, ok := byFile[file]
+		if !ok {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			doc, err := parseDocument(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			t = &target{fix: &FileFix{Path: file, Original: data}, doc: doc, indent: detectIndent(data)}
+			if t.before, err = encodeDocument(doc, t.indent); err != nil {
+				return nil, err
+			}
+			byFile[file] = t
+			targets = append(targets, t)
+		}
+		if finding.Fix.apply(t.doc.Content[0], finding.Position) {
+			t.fix.Applied = append(t.fix.Applied, finding.Fix.Description)
+		}
+	}
+
+	var fixes []FileFix
+	for _, t := range targets {
+		if len(t.fix.Applied) == 0 {
+			continue
+		}
+		after, err := encodeDocument(t.doc, t.indent)
+		if err != nil {
+			return nil, err
+		}
+		t.fix.Fixed = preserveFormatting(t.fix.Original, t.before, after)
+		fixes = append(fixes, *t.fix)
+	}
+	return fixes, nil
+}
+
+// parseDocument parses a configuration file into a document whose content
+// is a mapping; an empty file gives an empty mapping.
+func parseDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the configuration is not a mapping")
+	}
+	return &doc, nil
+}
+
+func encodeDocument(doc *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// detectIndent returns the indentation of the first indented line, or 2.
+func detectIndent(data []byte) int {
+	for _, line := range splitLines(string(data)) {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return indent
+		}
+	}
+	return 2
+}
+
+// preserveFormatting returns the edited document as changes of the original
+// file. yaml.v3 drops blank lines and may reformat lines when it encodes a
+// document, so the lines of the original file are kept wherever the
+// encodings before and after the fixes agree.
+func preserveFormatting(original, before, after []byte) []byte {
+	o := splitLines(string(original))
+	b := unescapeLines(splitLines(string(before)))
+	e := unescapeLines(splitLines(string(after)))
+
+	// origin[i] is the original line encoded as line i of before, or -1.
+	// Lines of before without an original line belong to the gap numbered
+	// by the aligned lines above them.
+	origin := make([]int, len(b))
+	for i := range origin {
+		origin[i] = -1
+	}
+	for _, op := range diffLines(o, b) {
+		if op.Kind == '=' {
+			origin[op.B] = op.A
+		}
+	}
+	gap := make([]int, len(b)+1)
+	for i := range b {
+		gap[i+1] = gap[i]
+		if origin[i] >= 0 {
+			gap[i+1]++
+		}
+	}
+
+	// A fix that changes a reformatted line replaces the original lines of
+	// its gap with their encoding, keeping the blank lines
+	ops := diffLines(b, e)
+	dirty := make(map[int]bool)
+	for _, op := range ops {
+		if op.Kind == '-' && origin[op.A] < 0 {
+			dirty[gap[op.A]] = true
+		}
+	}
+
+	var out []string
+	next := 0
+	copyOriginal := func(end, g int) {
+		for ; next < end; next++ {
+			if !dirty[g] || strings.TrimSpace(o[next]) == "" {
+				out = append(out, o[next])
+			}
+		}
+	}
+	for _, op := range ops {
+		switch {
+		case op.Kind == '+':
+			out = append(out, e[op.B])
+		case origin[op.A] >= 0:
+			copyOriginal(origin[op.A], gap[op.A])
+			if op.Kind == '=' {
+				out = append(out, o[origin[op.A]])
+			}
+			next = origin[op.A] + 1
+		case op.Kind == '=' && dirty[gap[op.A]]:
+			out = append(out, b[op.A])
+		}
+	}
+	copyOriginal(len(o), gap[len(b)])
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+var escapedRune = regexp.MustCompile(`(\\+)U([0-9A-Fa-f]{8})`)
+
+// unescapeLines undoes the \UXXXXXXXX escapes yaml.v3 writes in double
+// quoted strings for characters such as emoji.
+func unescapeLines(lines []string) []string {
+	for i, line := range lines {
+		lines[i] = escapedRune.ReplaceAllStringFunc(line, func(match string) string {
+			m := escapedRune.FindStringSubmatch(match)
+			code, _ := strconv.ParseUint(m[2], 16, 32)
+			if len(m[1])%2 == 0 || !unicode.IsPrint(rune(code)) {
+				return match
+			}
+			return m[1][1:] + string(rune(code))
+		})
+	}
+	return lines
+}
+
+// scalarAt returns the scalar node at a position, or nil.
+func scalarAt(node *yaml.Node, pos Position) *yaml.Node {
+	if node.Kind == yaml.ScalarNode && node.Line == pos.Line && node.Column == pos.Column {
+		return node
+	}
+	for _, child := range node.Content {
+		if found := scalarAt(child, pos); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value of a key of a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// ensureValue returns the value of a key of a mapping node, adding the key
+// before the key named before (or last) when it is missing. A null value
+// becomes an empty node of the kind.
+func ensureValue(node *yaml.Node, key, before string, kind yaml.Kind) *yaml.Node {
+	value := mappingValue(node, key)
+	if value == nil {
+		value = &yaml.Node{}
+		index := len(node.Content)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == before {
+				index = i
+			}
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		if index == 0 && len(node.Content) > 0 {
+			// Keep the comment at the top of the file above the new key
+			keyNode.HeadComment, node.Content[0].HeadComment = node.Content[0].HeadComment, ""
+		}
+		node.Content = append(node.Content[:index], append([]*yaml.Node{keyNode, value}, node.Content[index:]...)...)
+	}
+	if value.Kind == 0 || (value.Kind == yaml.ScalarNode && value.Tag == "!!null") {
+		tag := "!!map"
+		if kind == yaml.SequenceNode {
+			tag = "!!seq"
+		}
+		*value = yaml.Node{Kind: kind, Tag: tag, HeadComment: value.HeadComment, LineComment: value.LineComment}
+	}
+	return value
+}
+
+// newMapping builds a mapping node from keys and values. Strings are double
+// quoted, like the configuration generated by --init.
+func newMapping(pairs ...interface{}) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: pairs[i].(string)}
+		var value *yaml.Node
+		switch v := pairs[i+1].(type) {
+		case string:
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v, Style: yaml.DoubleQuotedStyle}
+		case *yaml.Node:
+			value = v
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node
+}
+
+// renameCommandFix replaces the name of a misspelled command in the
+// command of the finding.
+func renameCommandFix(from, to string) *Fix {
+	return &Fix{
+		Description: fmt.Sprintf("Replace '%s' with '%s'", from, to),
+		apply: func(root *yaml.Node, pos Position) bool {
+			node := scalarAt(root, pos)
+			if node == nil {
+				return false
+			}
+			script, err := shellparse.Parse(node.Value)
+			if err != nil {
+				return false
+			}
+			var offsets []int
+			for _, call := range commandCalls(script) {
+				if i := strings.LastIndex(call.Word.Raw, from); call.Name == from && i >= 0 {
+					offsets = append(offsets, call.Word.Pos.Offset+i)
+				}
+			}
+			// Replace from the end so earlier offsets stay valid
+			sort.Sort(sort.Reverse(sort.IntSlice(offsets)))
+			for _, offset := range offsets {
+				node.Value = node.Value[:offset] + to + node.Value[offset+len(from):]
+			}
+			return len(offsets) > 0
+		},
+	}
+}
+
+// addToolFix adds the tool configuration of a known command, or returns nil
+// for other commands.
+func addToolFix(command string) *Fix {
+	tool, ok := knownTools[command]
+	if !ok {
+		return nil
+	}
+	return &Fix{
+		Description: fmt.Sprintf("Add a tool configuration for '%s'", command),
+		toConfig:    true,
+		apply: func(root *yaml.Node, _ Position) bool {
+			tools := ensureValue(root, "tools", "hooks", yaml.SequenceNode)
+			if tools.Kind != yaml.SequenceNode {
+				return false
+			}
+			for _, item := range tools.Content {
+				if check := mappingValue(item, "check_command"); check != nil {
+					if script, err := shellparse.Parse(check.Value); err == nil && runsAny(script, []string{command}) != "" {
+						return false
+					}
+				}
+			}
+			tools.Content = append(tools.Content, newMapping(
+				"name", tool.Name,
+				"check_command", tool.CheckCommand,
+				"install_command", tool.InstallCommand,
+			))
+			return true
+		},
+	}
+}
+
+// securityHooksFix adds a security hook group running the builtin secret
+// scanner, which needs no tool.
+var securityHooksFix = &Fix{
+	Description: "Add a security hook group scanning for secrets",
+	toConfig:    true,
+	apply: func(root *yaml.Node, _ Position) bool {
+		hooks := ensureValue(root, "hooks", "", yaml.MappingNode)
+		if hooks.Kind != yaml.MappingNode {
+			return false
+		}
+		for i := 0; i < len(hooks.Content); i += 2 {
+			if strings.Contains(strings.ToLower(hooks.Content[i].Value), "security") {
+				return false
+			}
+		}
+		group := ensureValue(hooks, "security", "", yaml.MappingNode)
+		preCommit := ensureValue(group, "pre-commit", "", yaml.SequenceNode)
+		preCommit.Content = append(preCommit.Content, newMapping(
+			"name", "🔒 Secret Detection",
+			"builtin", "secrets",
+		))
+		return true
+	},
+}
+
+// showOnAliases maps common spellings of show_on values to the value.
+var showOnAliases = map[string]string{
+	"fail": "failure", "failed": "failure", "failures": "failure", "error": "failure", "errors": "failure",
+	"pass": "success", "passed": "success", "succeeded": "success", "ok": "success",
+	"all": "always",
+}
+
+// showOnFix replaces an invalid show_on value by the value it stands for,
+// or returns nil when there is no such value.
+func showOnFix(value string) *Fix {
+	allowed := allowedValues("OutputRules.ShowOn")
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	normalized = strings.TrimPrefix(strings.TrimPrefix(normalized, "on_"), "on-")
+	if alias, ok := showOnAliases[normalized]; ok {
+		normalized = alias
+	}
+	if !contains(allowed, normalized) {
+		normalized = suggestKey(normalized, allowed)
+	}
+	if normalized == "" {
+		return nil
+	}
+	return &Fix{
+		Description: fmt.Sprintf("Replace show_on '%s' with '%s'", value, normalized),
+		apply: func(root *yaml.Node, pos Position) bool {
+			node := scalarAt(root, pos)
+			if node == nil || node.Value != value {
+				return false
+			}
+			node.Value = normalized
+			return true
+		},
+	}
+}
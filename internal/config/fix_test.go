@@ -0,0 +1,163 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyFixes(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"quality.yml": `# yaml-language-server: $schema=https://example.com/schema.json
+tools:
+  - name: "Go"   # the toolchain
+    check_command: "go version"
+    install_command: "true"
+
+hooks:
+  # Frontend checks
+  frontend:
+    pre-commit:
+      - name: "🎨 Format"
+        command: "npx pretier --check . && npx eslint ."
+        output_rules:
+          show_on: Failed
+
+      - name: "🧪 Tests"
+        command: go test ./...
+`,
+	})
+	path := filepath.Join(dir, "quality.yml")
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := NewConfigValidator(config).Validate()
+
+	fixes, err := ApplyFixes(path, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixes) != 1 {
+		t.Fatalf("Expected fixes to one file, got %d", len(fixes))
+	}
+
+	expectedApplied := []string{
+		"Replace 'pretier' with 'prettier'",
+		"Replace show_on 'Failed' with 'failure'",
+		"Add a tool configuration for 'eslint'",
+		"Add a security hook group scanning for secrets",
+		"Migrate the configuration from version 1 to 2",
+	}
+	if !reflect.DeepEqual(fixes[0].Applied, expectedApplied) {
+		t.Errorf("Expected fixes %q, got %q", expectedApplied, fixes[0].Applied)
+	}
+
+	// Comments, blank lines, quoting and emoji are kept
+	expected := `# yaml-language-server: $schema=https://example.com/schema.json
+version: 2
+tools:
+  - name: "Go"   # the toolchain
+    check_command: "go version"
+    install_command: "true"
+  - name: "ESLint (Linter)"
+    check_command: "npx eslint --version"
+    install_command: "npm install --save-dev eslint"
+
+hooks:
+  # Frontend checks
+  frontend:
+    pre-commit:
+      - name: "🎨 Format"
+        command: "npx prettier --check . && npx eslint ."
+        output_rules:
+          show_on: failure
+
+      - name: "🧪 Tests"
+        command: go test ./...
+  security:
+    pre-commit:
+      - name: "🔒 Secret Detection"
+        builtin: "secrets"
+`
+	if string(fixes[0].Fixed) != expected {
+		t.Errorf("Unexpected fixed configuration:\n%s", fixes[0].Fixed)
+	}
+
+	// Only the tool of the fixed typo is left to fix
+	if err := os.WriteFile(path, fixes[0].Fixed, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if config, err = LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	var fixable []string
+	for _, finding := range NewConfigValidator(config).Validate().Errors {
+		if finding.Fix != nil {
+			fixable = append(fixable, finding.Fix.Description)
+		}
+	}
+	if !reflect.DeepEqual(fixable, []string{"Add a tool configuration for 'prettier'"}) {
+		t.Errorf("Unexpected fixes left: %q", fixable)
+	}
+}
+
+func TestApplyFixes_OtherFiles(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"quality.yml": "version: 2\ninclude: [hooks/go.yml]\n",
+		"hooks/go.yml": `hooks:
+  security:
+    pre-commit:
+      - name: "Vet"
+        command: go vet ./... && ruf check && gofmt -l .
+`,
+	})
+	path := filepath.Join(dir, "quality.yml")
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixes, err := ApplyFixes(path, NewConfigValidator(config).Validate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixes) != 2 {
+		t.Fatalf("Expected fixes to two files, got %d", len(fixes))
+	}
+	if fixes[0].Path != filepath.Join(dir, "hooks", "go.yml") || !strings.Contains(string(fixes[0].Fixed), "go vet ./... && ruff check && gofmt -l .") {
+		t.Errorf("Expected the typo to be fixed in the included file, got %s:\n%s", fixes[0].Path, fixes[0].Fixed)
+	}
+	if fixes[1].Path != path || !strings.HasPrefix(string(fixes[1].Fixed), "version: 2\ninclude: [hooks/go.yml]\ntools:\n  - name: \"Gofmt") {
+		t.Errorf("Expected the tool to be added to the configuration, got %s:\n%s", fixes[1].Path, fixes[1].Fixed)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+
+	expected := `--- a/quality.yml
++++ b/quality.yml
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,3 +9,4 @@
+ i
+ j
+ k
++l
+`
+	if diff := UnifiedDiff("quality.yml", []byte(before), []byte(after)); diff != expected {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+	if diff := UnifiedDiff("quality.yml", []byte(before), []byte(before)); diff != "" {
+		t.Errorf("Expected no diff for equal files, got:\n%s", diff)
+	}
+}
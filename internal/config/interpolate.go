@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UndefinedVariable records a ${VAR} reference without a default to a
+// variable that is not set when the configuration is loaded. It is left as
+// written for the shell.
+type UndefinedVariable struct {
+	Name     string
+	Field    string
+	Position Position
+}
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// runtimeVariablePrefix starts the variables quality-gate sets for each hook
+// run, such as QUALITY_GATE_PUSH_RANGE; only the shell can expand them.
+const runtimeVariablePrefix = "QUALITY_GATE_"
+
+// Interpolate expands ${VAR} and ${VAR:-default} references in s. The default
+// is used when VAR is unset or empty. $${ escapes a literal ${, and other
+// forms such as $VAR or ${VAR%suffix} are left for the shell, as are
+// references to unset variables without default. It returns the names of
+// those unset variables.
+func Interpolate(s string, lookup func(string) (string, bool)) (string, []string) {
+	return interpolate(s, lookup, nil, false)
+}
+
+// shellQuoting is the quoting in effect at a position of a shell command.
+type shellQuoting int
+
+const (
+	unquoted shellQuoting = iota
+	singleQuoted
+	doubleQuoted
+)
+
+// interpolate is Interpolate leaving every reference to the variables
+// matched by deferred as written, defaults included, for the shell. In a
+// command, values are quoted for the quoting in effect where they appear
+// (see quoteValue); defaults are written as is, like the rest of the command.
+func interpolate(s string, lookup func(string) (string, bool), deferred func(string) bool, command bool) (string, []string) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	var undefined []string
+	quoting := unquoted
+	for i := 0; i < len(s); i++ {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 2
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			if command {
+				switch {
+				case s[i] == '\\' && quoting != singleQuoted && i+1 < len(s):
+					b.WriteString(s[i : i+2])
+					i++
+					continue
+				case s[i] == '\'' && quoting == unquoted:
+					quoting = singleQuoted
+				case s[i] == '"' && quoting == unquoted:
+					quoting = doubleQuoted
+				case (s[i] == '\'' && quoting == singleQuoted) || (s[i] == '"' && quoting == doubleQuoted):
+					quoting = unquoted
+				}
+			}
+			b.WriteByte(s[i])
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		expr := s[i+2 : i+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if !variableNamePattern.MatchString(name) || (deferred != nil && deferred(name)) {
+			b.WriteString(s[i : i+end+1])
+			i += end
+			continue
+		}
+
+		value, ok := lookup(name)
+		switch {
+		case hasDefault && value == "":
+			value = def
+		case !ok:
+			undefined = append(undefined, name)
+			value = s[i : i+end+1]
+		case command:
+			value = quoteValue(value, quoting)
+		}
+		b.WriteString(value)
+		i += end
+	}
+	return b.String(), undefined
+}
+
+// quoteValue quotes a variable value substituted into a shell command so
+// that it cannot change the command. Unquoted values are split into words
+// like the shell splits an unquoted expansion, each word single-quoted.
+func quoteValue(value string, quoting shellQuoting) string {
+	switch quoting {
+	case singleQuoted:
+		return strings.ReplaceAll(value, "'", `'\''`)
+	case doubleQuoted:
+		return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+	}
+	words := strings.Fields(value)
+	for i, word := range words {
+		words[i] = "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+	}
+	return strings.Join(words, " ")
+}
+
+// commandFields are the keys whose values are shell commands, where
+// substituted values are quoted.
+var commandFields = map[string]bool{
+	"command":         true,
+	"fix_command":     true,
+	"check_command":   true,
+	"install_command": true,
+}
+
+// interpolateNode expands variables in every scalar value below node. Keys
+// are left untouched. field tracks the path used in validation messages.
+// Variables set by env entries (see resolveEnv) and by quality-gate at run time
+// are left for the shell, which has them when the hook runs.
+func interpolateNode(node *yaml.Node, field, file string, lookup func(string) (string, bool), env map[string]string, undefined *[]UndefinedVariable) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			interpolateNode(child, field, file, lookup, env, undefined)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if field == "" && key == "env" {
+				// Resolved by resolveEnv
+				continue
+			}
+			if field != "" {
+				key = field + "." + key
+			}
+			interpolateNode(node.Content[i+1], key, file, lookup, env, undefined)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			interpolateNode(child, fmt.Sprintf("%s[%d]", field, i), file, lookup, env, undefined)
+		}
+	case yaml.ScalarNode:
+		command := commandFields[field[strings.LastIndexByte(field, '.')+1:]]
+		value, names := interpolate(node.Value, lookup, func(name string) bool {
+			_, isEnv := env[name]
+			return isEnv || strings.HasPrefix(name, runtimeVariablePrefix)
+		}, command)
+		node.Value = value
+		for _, name := range names {
+			*undefined = append(*undefined, UndefinedVariable{Name: name, Field: field, Position: nodePosition(node, file)})
+		}
+	}
+}
+
+// resolveEnv expands the env entries of a configuration node in place, in
+// order: each value sees the entries of the files referencing it, the
+// entries before it and the environment, so "${GOFLAGS} -race" extends the
+// environment's GOFLAGS. It returns the inherited entries with those added.
+func resolveEnv(node *yaml.Node, file string, lookup func(string) (string, bool), inherited map[string]string, undefined *[]UndefinedVariable) map[string]string {
+	env := make(map[string]string, len(inherited))
+	for name, value := range inherited {
+		env[name] = value
+	}
+	if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return env
+	}
+	resolved := func(name string) (string, bool) {
+		if value, ok := env[name]; ok {
+			return value, true
+		}
+		return lookup(name)
+	}
+
+	root := node.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "env" || root.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		entries := root.Content[i+1].Content
+		for j := 0; j+1 < len(entries); j += 2 {
+			name, value := entries[j].Value, entries[j+1]
+			if value.Kind == yaml.ScalarNode {
+				expanded, names := interpolate(value.Value, resolved, nil, false)
+				value.Value = expanded
+				for _, missing := range names {
+					*undefined = append(*undefined, UndefinedVariable{Name: missing, Field: "env." + name, Position: nodePosition(value, file)})
+				}
+			}
+			env[name] = value.Value
+		}
+	}
+	return env
+}
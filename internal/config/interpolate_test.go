@@ -0,0 +1,210 @@
+package config
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"GOFLAGS": "-mod=mod", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		input     string
+		expected  string
+		undefined []string
+	}{
+		{"go build ${GOFLAGS} ./...", "go build -mod=mod ./...", nil},
+		{"cd ${CI_PROJECT_DIR:-.}", "cd .", nil},
+		{"echo ${EMPTY:-fallback}", "echo fallback", nil},
+		{"echo ${EMPTY}", "echo ", nil},
+		{"echo [${MISSING}]", "echo [${MISSING}]", []string{"MISSING"}},
+		{"echo $${GOFLAGS}", "echo ${GOFLAGS}", nil},
+		{"echo $HOME ${#list} ${name%.go}", "echo $HOME ${#list} ${name%.go}", nil},
+		{"awk '{print $1}'", "awk '{print $1}'", nil},
+		{"broken ${GOFLAGS", "broken ${GOFLAGS", nil},
+	}
+
+	for _, tt := range tests {
+		got, undefined := Interpolate(tt.input, lookup)
+		if got != tt.expected {
+			t.Errorf("Interpolate(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+		if !reflect.DeepEqual(undefined, tt.undefined) {
+			t.Errorf("Interpolate(%q) undefined = %v, want %v", tt.input, undefined, tt.undefined)
+		}
+	}
+}
+
+func TestLoader_Interpolation(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"quality.yml": `tools:
+  - name: "Go"
+    check_command: "go version"
+    install_command: "${GO_INSTALLER:-brew install go}"
+hooks:
+  go:
+    pre-commit:
+      - name: "Build ${GOOS}"
+        command: "go build ${GOFLAGS} ./..."
+        files: ["${SRC_DIR:-src}/**/*.go"]
+        output_rules:
+          on_failure_message: "Build failed in ${UNSET_DIR}"
+`})
+
+	loader := &Loader{LookupEnv: func(name string) (string, bool) {
+		value, ok := map[string]string{"GOFLAGS": "-trimpath", "GOOS": "linux"}[name]
+		return value, ok
+	}}
+	path := dir + "/quality.yml"
+	cfg, err := loader.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Tools[0].InstallCommand != "brew install go" {
+		t.Errorf("Expected default to be used, got %q", cfg.Tools[0].InstallCommand)
+	}
+	hook := cfg.Hooks["go"]["pre-commit"][0]
+	if hook.Name != "Build linux" || hook.Command != "go build '-trimpath' ./..." || hook.Files[0] != "src/**/*.go" {
+		t.Errorf("Expected every string field to be interpolated, got %+v", hook)
+	}
+
+	expected := []UndefinedVariable{{
+		Name:     "UNSET_DIR",
+		Field:    "hooks.go.pre-commit[0].output_rules.on_failure_message",
+		Position: Position{File: path, Line: 12, Column: 31},
+	}}
+	if !reflect.DeepEqual(cfg.Undefined, expected) {
+		t.Errorf("Expected undefined %+v, got %+v", expected, cfg.Undefined)
+	}
+}
+
+func TestLoader_InterpolationLeavesShellVariables(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yml": `hooks:
+  go:
+    pre-push:
+      - name: "Lint"
+        command: "golangci-lint run ${LINT_FLAGS}"
+`,
+		"quality.yml": `extends: [base.yml]
+env:
+  LINT_FLAGS: "--fast"
+hooks:
+  go:
+    pre-push:
+      - name: "Changed"
+        command: "git diff --name-only ${QUALITY_GATE_PUSH_RANGE:-HEAD}"
+      - name: "Report"
+        command: "report ${REPORT_DIR}"
+`,
+	})
+
+	// A variable set both in the environment and by an env entry takes the
+	// env entry's value when the hook runs.
+	loader := &Loader{LookupEnv: func(name string) (string, bool) {
+		value, ok := map[string]string{"LINT_FLAGS": "--slow", "QUALITY_GATE_PUSH_RANGE": "stale"}[name]
+		return value, ok
+	}}
+	cfg, err := loader.Load(dir + "/quality.yml")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	var commands []string
+	for _, hook := range cfg.Hooks["go"]["pre-push"] {
+		commands = append(commands, hook.Command)
+	}
+	expected := []string{
+		"golangci-lint run ${LINT_FLAGS}",
+		"git diff --name-only ${QUALITY_GATE_PUSH_RANGE:-HEAD}",
+		"report ${REPORT_DIR}",
+	}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Expected variables to be left for the shell\n%q\ngot\n%q", expected, commands)
+	}
+	if len(cfg.Undefined) != 1 || cfg.Undefined[0].Name != "REPORT_DIR" {
+		t.Errorf("Expected only REPORT_DIR to be undefined, got %+v", cfg.Undefined)
+	}
+}
+
+func TestLoader_InterpolationQuotesCommandValues(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"quality.yml": `hooks:
+  go:
+    pre-commit:
+      - name: "Message"
+        command: "echo \"msg=${MSG}\""
+        fix_command: "echo ${MSG} '${MSG}'"
+`})
+
+	msg := `x"; echo INJECTED; echo '$(id)'`
+	loader := &Loader{LookupEnv: func(name string) (string, bool) {
+		if name == "MSG" {
+			return msg, true
+		}
+		return "", false
+	}}
+	cfg, err := loader.Load(dir + "/quality.yml")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	hook := cfg.Hooks["go"]["pre-commit"][0]
+	expected := map[string]string{
+		hook.Command:    "msg=" + msg,
+		hook.FixCommand: `x"; echo INJECTED; echo '$(id)' ` + msg,
+	}
+	for command, want := range expected {
+		output, err := exec.Command("sh", "-c", command).Output()
+		if err != nil {
+			t.Fatalf("Command %q failed: %v", command, err)
+		}
+		if got := strings.TrimSpace(string(output)); got != want {
+			t.Errorf("Expected %q to print the value literally, got %q", command, got)
+		}
+	}
+}
+
+func TestLoader_InterpolationResolvesEnvEntries(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"quality.yml": `env:
+  GOFLAGS: "${GOFLAGS} -race"
+  OUT: "${HOME}/out"
+  B: "${OUT}/b"
+hooks:
+  go:
+    pre-commit:
+      - name: "Build"
+        command: "go build -o ${B} ./..."
+`,
+		"quality.local.yml": `env:
+  C: "${B}/c"
+`,
+	})
+
+	loader := &Loader{LookupEnv: func(name string) (string, bool) {
+		value, ok := map[string]string{"GOFLAGS": "-mod=mod", "HOME": "/h"}[name]
+		return value, ok
+	}}
+	cfg, err := loader.Load(dir + "/quality.yml")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	expected := map[string]string{"GOFLAGS": "-mod=mod -race", "OUT": "/h/out", "B": "/h/out/b", "C": "/h/out/b/c"}
+	if !reflect.DeepEqual(cfg.Env, expected) {
+		t.Errorf("Expected env %v, got %v", expected, cfg.Env)
+	}
+	// Commands still leave env entries for the shell
+	if command := cfg.Hooks["go"]["pre-commit"][0].Command; command != "go build -o ${B} ./..." {
+		t.Errorf("Expected the env entry to be left for the shell, got %q", command)
+	}
+	if len(cfg.Undefined) != 0 {
+		t.Errorf("Expected no undefined variables, got %+v", cfg.Undefined)
+	}
+}
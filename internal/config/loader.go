@@ -1,22 +1,281 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// FileNames lists the configuration file names searched for, in order of preference.
+var FileNames = []string{"quality.yml", ".quality.yml", ".quality-gate.yml"}
+
+// EnvVar names the environment variable overriding configuration discovery.
+const EnvVar = "QUALITY_GATE_CONFIG"
+
+// Loader reads configuration files and resolves their extends and include
+// references.
+type Loader struct {
+	// FetchRepository returns a local checkout of a git repository at a ref.
+	FetchRepository func(repo, ref string) (string, error)
+	// LookupEnv resolves ${VAR} references; it defaults to os.LookupEnv.
+	LookupEnv func(name string) (string, bool)
+}
+
+// NewLoader creates a Loader fetching git repositories into the user cache
+// and interpolating the process environment.
+func NewLoader() *Loader {
+	return &Loader{FetchRepository: fetchRepository, LookupEnv: os.LookupEnv}
+}
+
 func LoadConfig(path string) (*Config, error) {
+	return NewLoader().Load(path)
+}
+
+// Load reads a configuration file, rejecting unknown keys with their
+// position and a suggestion. ${VAR} and ${VAR:-default} references in
+// string values are expanded from the environment (see Interpolate); those
+// to env entries and to the QUALITY_GATE_ variables of a hook run are left
+// for the shell. Bases listed in extends are merged first,
+// in order, then the fragments listed in include, then the file itself, so
+// the file always has the last word. Relative paths are resolved from the
+// directory of the file referencing them. A personal override file next to
+// it (see LocalPath) is merged last and disabled hooks are dropped. Every
+// file is upgraded from its version (see CurrentVersion) before merging.
+func (l *Loader) Load(path string) (*Config, error) {
+	config, err := l.load(path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	localPath := LocalPath(path)
+	if _, err := os.Stat(localPath); err == nil {
+		local, err := l.load(localPath, nil, config.Env)
+		if err != nil {
+			return nil, err
+		}
+		version := config.Version
+		config = Merge(config, local)
+		config.Version = version
+	}
+
+	stripRemoved(config)
+	applyDisable(config)
+	config.Path = path
+	return config, nil
+}
+
+// LocalPath returns the personal override file of a configuration file:
+// quality.local.yml for quality.yml. It is meant to be ignored by git.
+func LocalPath(path string) string {
+	ext := filepath.Ext(path)
+	if ext != ".yml" && ext != ".yaml" {
+		return path + ".local"
+	}
+	return strings.TrimSuffix(path, ext) + ".local" + ext
+}
+
+// load reads a configuration file and the files it references. env holds
+// the resolved env entries of the files referencing it.
+func (l *Loader) load(path string, chain []string, env map[string]string) (*Config, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, seen := range chain {
+		if seen == abs {
+			return nil, fmt.Errorf("configuration cycle: %s", strings.Join(append(chain, abs), " -> "))
+		}
+	}
+	chain = append(chain, abs)
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	version := 1
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+		if version, err = fileVersion(node.Content[0], path); err != nil {
+			return nil, err
+		}
+	}
+
 	var config Config
-	err = yaml.Unmarshal(data, &config)
+	lookup := l.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	env = resolveEnv(&node, path, lookup, env, &config.Undefined)
+	interpolateNode(&node, "", path, lookup, env, &config.Undefined)
+	if node.Kind != 0 {
+		var unknown DecodeErrors
+		checkKnownKeys(&node, Schema(), "", path, &unknown)
+		if len(unknown) > 0 {
+			return nil, unknown
+		}
+		// Older files are decoded with the meaning they were written for
+		upgrade(node.Content[0], version)
+		if err := node.Decode(&config); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		recordPositions(&config, &node, path)
+	}
+	config.Version = version
+	config.Sources = []string{path}
+
+	// Remove entries are kept until the final merge: a fragment removing
+	// its only hook of a hook type must not read as clearing the type.
+	if len(config.Extends) == 0 && len(config.Include) == 0 {
+		return &config, nil
+	}
+
+	dir := filepath.Dir(path)
+	merged := &Config{}
+	for _, extend := range config.Extends {
+		basePath, err := l.resolveExtend(extend, dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		base, err := l.load(basePath, chain, env)
+		if err != nil {
+			return nil, err
+		}
+		merged = Merge(merged, base)
+	}
+	for _, include := range config.Include {
+		fragment, err := l.load(resolvePath(include, dir), chain, env)
+		if err != nil {
+			return nil, err
+		}
+		merged = Merge(merged, fragment)
+	}
+
+	config.Extends = nil
+	config.Include = nil
+	return Merge(merged, &config), nil
+}
+
+// resolveExtend returns the local path of a base configuration.
+func (l *Loader) resolveExtend(extend Extend, dir string) (string, error) {
+	if extend.Git == "" {
+		if extend.Path == "" {
+			return "", fmt.Errorf("extends entry needs a path or a git repository")
+		}
+		return resolvePath(extend.Path, dir), nil
+	}
+
+	if extend.Ref == "" {
+		return "", fmt.Errorf("extends entry for %s must pin a ref (tag, branch or commit)", extend.Git)
+	}
+	checkout, err := l.FetchRepository(extend.Git, extend.Ref)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to fetch %s at %s: %w", extend.Git, extend.Ref, err)
+	}
+	path := extend.Path
+	if path == "" {
+		path = FileNames[0]
+	}
+	return filepath.Join(checkout, filepath.FromSlash(path)), nil
+}
+
+func resolvePath(path, dir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, filepath.FromSlash(path))
+}
+
+// fetchRepository checks a git repository out at a ref into the user cache.
+// A ref is fetched once; pin tags or commits so the cache stays correct.
+func fetchRepository(repo, ref string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(repo + "@" + ref))
+	dir := filepath.Join(cacheDir, "quality-gate", "extends", hex.EncodeToString(sum[:8]))
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "fetch-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", repo, ref},
+		{"checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmp
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git %s: %w\n%s", args[0], err, output)
+		}
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// FindConfig returns the configuration file to load: the explicit path when
+// given, else $QUALITY_GATE_CONFIG, else the first of FileNames found in the
+// current directory or its parents up to the repository root.
+func FindConfig(explicit string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	if path := os.Getenv(EnvVar); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return discoverConfig(dir)
+}
+
+// discoverConfig searches dir and its parents for a configuration file,
+// stopping at the first directory containing .git.
+func discoverConfig(dir string) (string, error) {
+	start := dir
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
 	}
 
-	return &config, nil
+	return "", fmt.Errorf("no configuration file found (looked for %s from %s up to %s); run 'quality-gate --init' to create one, or use --config or $%s",
+		strings.Join(FileNames, ", "), start, dir, EnvVar)
 }
@@ -1,6 +1,12 @@
 package config
 
 import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -40,3 +46,567 @@ func TestLoadConfig(t *testing.T) {
 		t.Errorf("Expected hook name '🔒 Verificação de Segredos (Gitleaks)', got '%s'", preCommitHooks[0].Name)
 	}
 }
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "pkg", "api")
+	for _, dir := range []string{filepath.Join(root, ".git"), sub} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(EnvVar, "")
+
+	if _, err := discoverConfig(sub); err == nil || !strings.Contains(err.Error(), "no configuration file found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	hidden := filepath.Join(root, ".quality-gate.yml")
+	if err := os.WriteFile(hidden, []byte("tools: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := discoverConfig(sub); err != nil || path != hidden {
+		t.Errorf("Expected %s from subdirectory, got %s (%v)", hidden, path, err)
+	}
+
+	preferred := filepath.Join(root, "quality.yml")
+	if err := os.WriteFile(preferred, []byte("tools: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := discoverConfig(sub); path != preferred {
+		t.Errorf("Expected quality.yml to be preferred, got %s", path)
+	}
+
+	// The search stops at the repository root.
+	if err := os.MkdirAll(filepath.Join(sub, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := discoverConfig(sub); err == nil {
+		t.Error("Expected the search to stop at the nested repository root")
+	}
+
+	t.Setenv(EnvVar, "/etc/quality-gate/team.yml")
+	if path, _ := FindConfig(""); path != "/etc/quality-gate/team.yml" {
+		t.Errorf("Expected %s to override discovery, got %s", EnvVar, path)
+	}
+	if path, _ := FindConfig("custom.yml"); path != "custom.yml" {
+		t.Errorf("Expected --config to take precedence, got %s", path)
+	}
+}
+
+func TestLoadConfig_RecordsPath(t *testing.T) {
+	config, err := LoadConfig("testdata/quality.yml")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.Path != "testdata/quality.yml" {
+		t.Errorf("Expected Path to be recorded, got %q", config.Path)
+	}
+}
+
+// writeConfigFiles writes files relative to a temporary directory and returns it.
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func hookNames(hooks []Hook) []string {
+	var names []string
+	for _, hook := range hooks {
+		names = append(names, hook.Name)
+	}
+	return names
+}
+
+func TestLoader_ExtendsAndInclude(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"shared/base.yml": `min_version: "1.1.0"
+tools:
+  - name: "Gitleaks"
+    check_command: "gitleaks version"
+    install_command: "go install gitleaks"
+  - name: "Legacy"
+    check_command: "legacy --version"
+hooks:
+  security:
+    pre-commit:
+      - name: "Secrets"
+        builtin: secrets
+      - name: "Audit"
+        command: "audit"
+  legacy:
+    pre-commit:
+      - name: "Old lint"
+        command: "old-lint"
+  go:
+    pre-commit:
+      - name: "Vet"
+        command: "go vet ./..."
+        output_rules:
+          show_on: failure
+          on_failure_message: "vet failed"
+    pre-push:
+      - name: "Race tests"
+        command: "go test -race ./..."
+`,
+		"fragments/tests.yml": `hooks:
+  go:
+    pre-push:
+      - name: "Tests"
+        command: "go test ./..."
+`,
+		"quality.yml": `min_version: "1.0.0"
+extends:
+  - shared/base.yml
+include:
+  - fragments/tests.yml
+tools:
+  - name: "Gitleaks"
+    check_command: "gitleaks --version"
+  - name: "Legacy"
+    remove: true
+  - name: "golangci-lint"
+    check_command: "golangci-lint --version"
+hooks:
+  security:
+    pre-commit:
+      - name: "Audit"
+        remove: true
+  legacy: null
+  go:
+    pre-commit:
+      - name: "Vet"
+        command: "go vet -tags integration ./..."
+      - name: "Lint"
+        command: "golangci-lint run"
+    pre-push: []
+`,
+	})
+
+	path := filepath.Join(dir, "quality.yml")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.MinVersion != "1.1.0" {
+		t.Errorf("Expected the strictest min_version 1.1.0, got %q", cfg.MinVersion)
+	}
+	if cfg.Path != path {
+		t.Errorf("Expected Path %s, got %s", path, cfg.Path)
+	}
+	expectedSources := []string{filepath.Join(dir, "shared/base.yml"), filepath.Join(dir, "fragments/tests.yml"), path}
+	if !reflect.DeepEqual(cfg.Sources, expectedSources) {
+		t.Errorf("Expected sources %v, got %v", expectedSources, cfg.Sources)
+	}
+	if cfg.Extends != nil || cfg.Include != nil {
+		t.Errorf("Expected extends and include to be resolved")
+	}
+
+	// Each field keeps the position of the file that set it.
+	if pos := cfg.Tools[0].Positions["install_command"]; pos.File != filepath.Join(dir, "shared/base.yml") || pos.Line == 0 {
+		t.Errorf("Expected install_command to be located in the base, got %v", pos)
+	}
+	if pos := cfg.Tools[0].Positions["check_command"]; pos.File != path {
+		t.Errorf("Expected check_command to be located in quality.yml, got %v", pos)
+	}
+	for i := range cfg.Tools {
+		cfg.Tools[i].Positions = nil
+	}
+
+	// Tools: override keeps unset fields, remove deletes, new names append.
+	expectedTools := Tools{
+		{Name: "Gitleaks", CheckCommand: "gitleaks --version", InstallCommand: "go install gitleaks"},
+		{Name: "golangci-lint", CheckCommand: "golangci-lint --version"},
+	}
+	if !reflect.DeepEqual(cfg.Tools, expectedTools) {
+		t.Errorf("Expected tools %+v, got %+v", expectedTools, cfg.Tools)
+	}
+
+	if names := hookNames(cfg.Hooks["security"]["pre-commit"]); !reflect.DeepEqual(names, []string{"Secrets"}) {
+		t.Errorf("Expected Audit to be removed, got %v", names)
+	}
+	if _, ok := cfg.Hooks["legacy"]; ok {
+		t.Errorf("Expected the legacy group to be removed")
+	}
+	if _, ok := cfg.Hooks["go"]["pre-push"]; ok {
+		t.Errorf("Expected go pre-push hooks to be cleared, got %v", hookNames(cfg.Hooks["go"]["pre-push"]))
+	}
+
+	goHooks := cfg.Hooks["go"]["pre-commit"]
+	if names := hookNames(goHooks); !reflect.DeepEqual(names, []string{"Vet", "Lint"}) {
+		t.Fatalf("Expected Vet overridden and Lint appended, got %v", names)
+	}
+	if goHooks[0].Command != "go vet -tags integration ./..." || goHooks[0].OutputRules.OnFailureMessage != "vet failed" {
+		t.Errorf("Expected Vet command overridden and output rules inherited, got %+v", goHooks[0])
+	}
+}
+
+func TestLoader_RemoveFromFragment(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yml": `hooks:
+  g:
+    pre-commit:
+      - name: "one"
+        command: "one"
+      - name: "two"
+        command: "two"
+`,
+		"fragment.yml": `hooks:
+  g:
+    pre-commit:
+      - name: "one"
+        remove: true
+`,
+		"quality.yml": `extends: [base.yml]
+include: [fragment.yml]
+`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "quality.yml"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if names := hookNames(cfg.Hooks["g"]["pre-commit"]); !reflect.DeepEqual(names, []string{"two"}) {
+		t.Errorf("Expected only one to be removed, got %v", names)
+	}
+}
+
+func TestLoader_ExtendsErrors(t *testing.T) {
+	t.Run("Cycle", func(t *testing.T) {
+		dir := writeConfigFiles(t, map[string]string{
+			"a.yml": "extends: [b.yml]\n",
+			"b.yml": "extends: [a.yml]\n",
+		})
+		_, err := LoadConfig(filepath.Join(dir, "a.yml"))
+		if err == nil || !strings.Contains(err.Error(), "configuration cycle") {
+			t.Errorf("Expected cycle error, got %v", err)
+		}
+	})
+
+	t.Run("MissingBase", func(t *testing.T) {
+		dir := writeConfigFiles(t, map[string]string{"quality.yml": "extends: [missing.yml]\n"})
+		if _, err := LoadConfig(filepath.Join(dir, "quality.yml")); err == nil {
+			t.Error("Expected error for missing base")
+		}
+	})
+
+	t.Run("UnpinnedGit", func(t *testing.T) {
+		dir := writeConfigFiles(t, map[string]string{"quality.yml": "extends:\n  - git: https://example.com/org/configs.git\n"})
+		_, err := LoadConfig(filepath.Join(dir, "quality.yml"))
+		if err == nil || !strings.Contains(err.Error(), "must pin a ref") {
+			t.Errorf("Expected unpinned ref error, got %v", err)
+		}
+	})
+}
+
+func TestLoader_UnknownKeys(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"quality.yml": `tools:
+  - name: "Lint"
+    check_comand: "lint --version"
+hooks:
+  go:
+    pre-commit:
+      - name: "Vet"
+        command: "go vet ./..."
+        outputrules:
+          show_on: failure
+      - name: "Test"
+        command: "go test ./..."
+        output_rules:
+          shown_on: always
+verbose: true
+`})
+	path := filepath.Join(dir, "quality.yml")
+
+	_, err := LoadConfig(path)
+	var errs DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected DecodeErrors, got %v", err)
+	}
+
+	expected := []string{
+		path + `:3:5: unknown key "check_comand" in tools[0] (did you mean "check_command"?)`,
+		path + `:9:9: unknown key "outputrules" in hooks.go.pre-commit[0] (did you mean "output_rules"?)`,
+		path + `:14:11: unknown key "shown_on" in hooks.go.pre-commit[1].output_rules (did you mean "show_on"?)`,
+		path + `:15:1: unknown key "verbose" (known keys: disable, env, extends, hooks, include, min_version, tools, validation, version)`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], err.Error())
+		}
+	}
+}
+
+func TestLoader_MergeKeys(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"quality.yml": `x-lint: &lint
+  files: ["*.go"]
+  output_rules: &rules
+    show_on: failure
+hooks:
+  go:
+    pre-commit:
+      - <<: *lint
+        name: "Vet"
+        command: "go vet ./..."
+      - name: "Lint"
+        command: "golangci-lint run"
+        output_rules:
+          <<: *rules
+          on_failure_message: "lint failed"
+`})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "quality.yml"))
+	if err != nil {
+		t.Fatalf("Expected merge keys and x- keys to load, got %v", err)
+	}
+	hooks := cfg.Hooks["go"]["pre-commit"]
+	if !reflect.DeepEqual(hooks[0].Files, []string{"*.go"}) || hooks[0].OutputRules.ShowOn != "failure" {
+		t.Errorf("Expected the anchor to be merged into Vet, got %+v", hooks[0])
+	}
+	if rules := hooks[1].OutputRules; rules.ShowOn != "failure" || rules.OnFailureMessage != "lint failed" {
+		t.Errorf("Expected the anchor to be merged into output_rules, got %+v", rules)
+	}
+}
+
+func TestLoader_Positions(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"quality.yml": `tools:
+  - name: "Lint"
+    check_command: "lint --version"
+hooks:
+  go:
+    pre-commit:
+      - name: "Vet"
+        command: "go vet ./..."
+        output_rules:
+          show_on: failure
+`})
+	path := filepath.Join(dir, "quality.yml")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		actual   Position
+		expected Position
+	}{
+		{"hooks", cfg.Positions["hooks"], Position{File: path, Line: 4, Column: 1}},
+		{"hook type", cfg.Positions["hooks.go.pre-commit"], Position{File: path, Line: 6, Column: 5}},
+		{"tool", cfg.Tools[0].Positions[""], Position{File: path, Line: 2, Column: 5}},
+		{"tool field", cfg.Tools[0].Positions["check_command"], Position{File: path, Line: 3, Column: 20}},
+		{"nested hook field", cfg.Hooks["go"]["pre-commit"][0].Positions["output_rules.show_on"], Position{File: path, Line: 10, Column: 20}},
+	}
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, tt.actual)
+		}
+	}
+}
+
+func TestLoader_ExtendsGit(t *testing.T) {
+	remote := writeConfigFiles(t, map[string]string{
+		"go/quality.yml": "tools:\n  - name: \"Go\"\n    check_command: \"go version\"\n",
+	})
+	dir := writeConfigFiles(t, map[string]string{"quality.yml": `extends:
+  - git: https://example.com/org/configs.git
+    ref: v1.0.0
+    path: go/quality.yml
+`})
+
+	var fetched []string
+	loader := &Loader{FetchRepository: func(repo, ref string) (string, error) {
+		fetched = append(fetched, repo+"@"+ref)
+		return remote, nil
+	}}
+	cfg, err := loader.Load(filepath.Join(dir, "quality.yml"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !reflect.DeepEqual(fetched, []string{"https://example.com/org/configs.git@v1.0.0"}) {
+		t.Errorf("Unexpected fetches: %v", fetched)
+	}
+	if len(cfg.Tools) != 1 || cfg.Tools[0].Name != "Go" {
+		t.Errorf("Expected tool from the git base, got %+v", cfg.Tools)
+	}
+}
+
+func TestFetchRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+
+	repo := writeConfigFiles(t, map[string]string{"quality.yml": "tools: []\n"})
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "quality.yml"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "base"},
+		{"tag", "v1"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	checkout, err := fetchRepository(repo, "v1")
+	if err != nil {
+		t.Fatalf("fetchRepository failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(checkout, "quality.yml")); err != nil {
+		t.Errorf("Expected quality.yml in the checkout: %v", err)
+	}
+
+	again, err := fetchRepository(repo, "v1")
+	if err != nil || again != checkout {
+		t.Errorf("Expected the cached checkout to be reused, got %s (%v)", again, err)
+	}
+}
+
+func TestLoader_LocalOverride(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"quality.yml": `env:
+  GOFLAGS: "-mod=readonly"
+hooks:
+  go:
+    pre-commit:
+      - name: "Vet"
+        command: "go vet ./..."
+        output_rules:
+          show_on: failure
+      - name: "Slow tests"
+        command: "go test ./..."
+  security:
+    pre-commit:
+      - name: "Secrets"
+        builtin: secrets
+validation:
+  disable: [tool-not-found]
+  severity:
+    tool-typo: error
+  rules:
+    - id: no-verify
+      pattern: "--no-verify"
+`,
+		"quality.local.yml": `disable:
+  - "Slow tests"
+validation:
+  disable: [security-hooks-missing]
+  severity:
+    tool-typo: warning
+  rules:
+    - id: no-verify
+      pattern: "--no-verify"
+      severity: warning
+env:
+  GOFLAGS: "-mod=mod"
+  CGO_ENABLED: "0"
+hooks:
+  go:
+    pre-commit:
+      - name: "Vet"
+        output_rules:
+          show_on: always
+  personal:
+    pre-commit:
+      - name: "Spellcheck"
+        command: "codespell"
+`,
+	})
+
+	path := filepath.Join(dir, "quality.yml")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Path != path {
+		t.Errorf("Expected Path to stay %s, got %s", path, cfg.Path)
+	}
+	if len(cfg.Sources) != 2 || cfg.Sources[1] != filepath.Join(dir, "quality.local.yml") {
+		t.Errorf("Expected the local file in sources, got %v", cfg.Sources)
+	}
+	if names := hookNames(cfg.Hooks["go"]["pre-commit"]); !reflect.DeepEqual(names, []string{"Vet"}) {
+		t.Errorf("Expected Slow tests to be disabled, got %v", names)
+	}
+	if vet := cfg.Hooks["go"]["pre-commit"][0]; vet.Command != "go vet ./..." || vet.OutputRules.ShowOn != "always" {
+		t.Errorf("Expected output rules to be overridden, got %+v", vet)
+	}
+	if names := hookNames(cfg.Hooks["personal"]["pre-commit"]); !reflect.DeepEqual(names, []string{"Spellcheck"}) {
+		t.Errorf("Expected personal hook to be added, got %v", names)
+	}
+	if expected := map[string]string{"GOFLAGS": "-mod=mod", "CGO_ENABLED": "0"}; !reflect.DeepEqual(cfg.Env, expected) {
+		t.Errorf("Expected env %v, got %v", expected, cfg.Env)
+	}
+	if cfg.Disable != nil {
+		t.Errorf("Expected disable to be applied, got %v", cfg.Disable)
+	}
+
+	expectedValidation := Validation{
+		Disable:  []string{"tool-not-found", "security-hooks-missing"},
+		Severity: map[string]string{"tool-typo": "warning"},
+		Rules:    []ValidationRule{{ID: "no-verify", Pattern: "--no-verify", Severity: "warning"}},
+	}
+	if !reflect.DeepEqual(cfg.Validation, expectedValidation) {
+		t.Errorf("Expected validation %+v, got %+v", expectedValidation, cfg.Validation)
+	}
+}
+
+func TestLoader_LocalOverrideRemove(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"quality.yml": `hooks:
+  g:
+    pre-commit:
+      - name: "one"
+        command: "one"
+      - name: "two"
+        command: "two"
+`,
+		"quality.local.yml": `hooks:
+  g:
+    pre-commit:
+      - name: "one"
+        remove: true
+`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "quality.yml"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if names := hookNames(cfg.Hooks["g"]["pre-commit"]); !reflect.DeepEqual(names, []string{"two"}) {
+		t.Errorf("Expected only one to be removed, got %v", names)
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	for path, expected := range map[string]string{
+		"quality.yml":              "quality.local.yml",
+		"/repo/.quality-gate.yml":  "/repo/.quality-gate.local.yml",
+		"configs/team.yaml":        "configs/team.local.yaml",
+		"configs/quality-settings": "configs/quality-settings.local",
+	} {
+		if got := LocalPath(path); got != expected {
+			t.Errorf("LocalPath(%q) = %q, want %q", path, got, expected)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package config
+
+// Merge returns base overlaid with overlay. Tools and hooks are matched by
+// name: an entry with the name of an inherited one overrides the fields it
+// sets, a new name is appended and an entry with remove: true deletes the
+// inherited one. A hook group or hook type set to null or [] clears it.
+// Disabled hooks and rules accumulate, env variables and rule severities are
+// overridden by key and custom rules by id.
+// Neither argument is modified.
+func Merge(base, overlay *Config) *Config {
+	merged := &Config{
+		Version:    overlay.Version,
+		MinVersion: base.MinVersion,
+		Tools:      mergeTools(base.Tools, overlay.Tools),
+		Hooks:      mergeHooks(base.Hooks, overlay.Hooks),
+		Path:       overlay.Path,
+		Positions:  mergePositions(base.Positions, overlay.Positions),
+	}
+
+	// The strictest version requirement wins.
+	if overlay.MinVersion != "" {
+		if merged.MinVersion == "" {
+			merged.MinVersion = overlay.MinVersion
+		} else if cmp, err := CompareVersions(overlay.MinVersion, merged.MinVersion); err != nil || cmp > 0 {
+			merged.MinVersion = overlay.MinVersion
+		}
+	}
+
+	merged.Disable = append(append([]string{}, base.Disable...), overlay.Disable...)
+	if len(merged.Disable) == 0 {
+		merged.Disable = nil
+	}
+	if base.Env != nil || overlay.Env != nil {
+		merged.Env = make(map[string]string)
+		for key, value := range base.Env {
+			merged.Env[key] = value
+		}
+		for key, value := range overlay.Env {
+			merged.Env[key] = value
+		}
+	}
+
+	merged.Validation = mergeValidation(base.Validation, overlay.Validation)
+
+	merged.Sources = append(append([]string{}, base.Sources...), overlay.Sources...)
+	merged.Undefined = append(append([]UndefinedVariable{}, base.Undefined...), overlay.Undefined...)
+	if len(merged.Undefined) == 0 {
+		merged.Undefined = nil
+	}
+	return merged
+}
+
+// mergeValidation overlays the validation sections.
+func mergeValidation(base, overlay Validation) Validation {
+	merged := Validation{Disable: append(append([]string{}, base.Disable...), overlay.Disable...)}
+	if len(merged.Disable) == 0 {
+		merged.Disable = nil
+	}
+	if base.Severity != nil || overlay.Severity != nil {
+		merged.Severity = make(map[string]string)
+		for id, severity := range base.Severity {
+			merged.Severity[id] = severity
+		}
+		for id, severity := range overlay.Severity {
+			merged.Severity[id] = severity
+		}
+	}
+	merged.Rules = append([]ValidationRule{}, base.Rules...)
+	for _, rule := range overlay.Rules {
+		replaced := false
+		for i := range merged.Rules {
+			if merged.Rules[i].ID == rule.ID {
+				merged.Rules[i], replaced = rule, true
+			}
+		}
+		if !replaced {
+			merged.Rules = append(merged.Rules, rule)
+		}
+	}
+	if len(merged.Rules) == 0 {
+		merged.Rules = nil
+	}
+	return merged
+}
+
+// mergeTools overlays tools by name.
+func mergeTools(base, overlay Tools) Tools {
+	merged := append(Tools{}, base...)
+	for _, tool := range overlay {
+		i := indexOfTool(merged, tool.Name)
+		switch {
+		case tool.Remove:
+			if i >= 0 {
+				merged = append(merged[:i], merged[i+1:]...)
+			}
+		case i >= 0:
+			merged[i] = overrideTool(merged[i], tool)
+		default:
+			merged = append(merged, tool)
+		}
+	}
+	return merged
+}
+
+func overrideTool(base, overlay Tool) Tool {
+	if overlay.CheckCommand != "" {
+		base.CheckCommand = overlay.CheckCommand
+	}
+	if overlay.InstallCommand != "" {
+		base.InstallCommand = overlay.InstallCommand
+	}
+	base.Positions = mergePositions(base.Positions, overlay.Positions)
+	return base
+}
+
+func indexOfTool(tools Tools, name string) int {
+	for i, tool := range tools {
+		if tool.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeHooks overlays hook groups, then hook types, then hooks by name.
+func mergeHooks(base, overlay Hooks) Hooks {
+	if base == nil && overlay == nil {
+		return nil
+	}
+
+	merged := make(Hooks)
+	for group, hookTypes := range base {
+		merged[group] = make(map[string][]Hook)
+		for hookType, hooks := range hookTypes {
+			merged[group][hookType] = append([]Hook{}, hooks...)
+		}
+	}
+
+	for group, hookTypes := range overlay {
+		if len(hookTypes) == 0 {
+			delete(merged, group)
+			continue
+		}
+		if merged[group] == nil {
+			merged[group] = make(map[string][]Hook)
+		}
+		for hookType, hooks := range hookTypes {
+			if len(hooks) == 0 {
+				delete(merged[group], hookType)
+				continue
+			}
+			merged[group][hookType] = mergeHookList(merged[group][hookType], hooks)
+			if len(merged[group][hookType]) == 0 {
+				delete(merged[group], hookType)
+			}
+		}
+		if len(merged[group]) == 0 {
+			delete(merged, group)
+		}
+	}
+	return merged
+}
+
+// mergeHookList overlays a list of hooks by name.
+func mergeHookList(base, overlay []Hook) []Hook {
+	merged := append([]Hook{}, base...)
+	for _, hook := range overlay {
+		i := indexOfHook(merged, hook.Name)
+		switch {
+		case hook.Remove:
+			if i >= 0 {
+				merged = append(merged[:i], merged[i+1:]...)
+			}
+		case i >= 0:
+			merged[i] = overrideHook(merged[i], hook)
+		default:
+			merged = append(merged, hook)
+		}
+	}
+	return merged
+}
+
+func overrideHook(base, overlay Hook) Hook {
+	// A hook is either a command or a builtin; setting one replaces the other.
+	if overlay.Command != "" || overlay.Builtin != "" {
+		base.Command = overlay.Command
+		base.Builtin = overlay.Builtin
+	}
+	if overlay.Options != nil {
+		base.Options = overlay.Options
+	}
+	if overlay.Files != nil {
+		base.Files = overlay.Files
+	}
+	if overlay.Exclude != nil {
+		base.Exclude = overlay.Exclude
+	}
+	if overlay.FixCommand != "" {
+		base.FixCommand = overlay.FixCommand
+	}
+	if overlay.OutputRules.ShowOn != "" {
+		base.OutputRules.ShowOn = overlay.OutputRules.ShowOn
+	}
+	if overlay.OutputRules.OnFailureMessage != "" {
+		base.OutputRules.OnFailureMessage = overlay.OutputRules.OnFailureMessage
+	}
+	if overlay.OutputRules.OnSuccessMessage != "" {
+		base.OutputRules.OnSuccessMessage = overlay.OutputRules.OnSuccessMessage
+	}
+	if overlay.OutputRules.MaxLines != 0 {
+		base.OutputRules.MaxLines = overlay.OutputRules.MaxLines
+	}
+	if overlay.OutputRules.Truncate != "" {
+		base.OutputRules.Truncate = overlay.OutputRules.Truncate
+	}
+	if overlay.OutputRules.Filter != "" {
+		base.OutputRules.Filter = overlay.OutputRules.Filter
+	}
+	if overlay.OutputRules.Strip != "" {
+		base.OutputRules.Strip = overlay.OutputRules.Strip
+	}
+	base.Positions = mergePositions(base.Positions, overlay.Positions)
+	return base
+}
+
+func indexOfHook(hooks []Hook, name string) int {
+	for i, hook := range hooks {
+		if hook.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// stripRemoved drops the remove entries left once every file is merged:
+// those of the root file, which had nothing to remove from. Hook types that
+// only held remove entries are dropped with them.
+func stripRemoved(config *Config) {
+	tools := config.Tools[:0]
+	for _, tool := range config.Tools {
+		if !tool.Remove {
+			tools = append(tools, tool)
+		}
+	}
+	config.Tools = tools
+
+	for group, hookTypes := range config.Hooks {
+		for hookType, hooks := range hookTypes {
+			var kept []Hook
+			for _, hook := range hooks {
+				if !hook.Remove {
+					kept = append(kept, hook)
+				}
+			}
+			if len(kept) == len(hooks) {
+				continue
+			}
+			if len(kept) == 0 {
+				delete(hookTypes, hookType)
+				if len(hookTypes) == 0 {
+					delete(config.Hooks, group)
+				}
+			} else {
+				hookTypes[hookType] = kept
+			}
+		}
+	}
+}
+
+// applyDisable removes the disabled hooks from every group and hook type.
+func applyDisable(config *Config) {
+	if len(config.Disable) == 0 {
+		return
+	}
+	for group, hookTypes := range config.Hooks {
+		for hookType, hooks := range hookTypes {
+			var kept []Hook
+			for _, hook := range hooks {
+				if !contains(config.Disable, hook.Name) {
+					kept = append(kept, hook)
+				}
+			}
+			if len(kept) == 0 {
+				delete(hookTypes, hookType)
+			} else {
+				hookTypes[hookType] = kept
+			}
+		}
+		if len(hookTypes) == 0 {
+			delete(config.Hooks, group)
+		}
+	}
+	config.Disable = nil
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the configuration version of this quality-gate. Files
+// without a version key are version 1; newer files are refused.
+const CurrentVersion = 2
+
+// migration upgrades the root mapping of a configuration file to version to
+// from the version before.
+type migration struct {
+	to          int
+	description string
+	// apply edits the root mapping and reports whether it changed it.
+	apply func(root *yaml.Node) bool
+}
+
+// migrations upgrade configuration files one version at a time. Files are
+// upgraded when loaded, so every version keeps working, and rewritten by
+// `quality-gate migrate`.
+var migrations = []migration{
+	{
+		to:          2,
+		description: "Escape {{ in messages, which version 2 renders as templates",
+		apply:       escapeMessages,
+	},
+}
+
+// fileVersion returns the version declared by the root mapping of a
+// configuration file, 1 when it declares none, and an error for versions
+// this quality-gate cannot read.
+func fileVersion(root *yaml.Node, path string) (int, error) {
+	value := mappingValue(root, "version")
+	if value == nil {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(value.Value)
+	if value.Kind != yaml.ScalarNode || err != nil || version < 1 {
+		return 0, fmt.Errorf("%s:%d:%d: version must be a positive integer, got %q", path, value.Line, value.Column, value.Value)
+	}
+	if version > CurrentVersion {
+		return 0, fmt.Errorf("%s uses configuration version %d, but this quality-gate supports versions up to %d; please upgrade", path, version, CurrentVersion)
+	}
+	return version, nil
+}
+
+// upgrade applies the migrations after version to the root mapping of a
+// configuration file, returning the descriptions of those that changed it.
+// It does not set the version key.
+func upgrade(root *yaml.Node, version int) []string {
+	var applied []string
+	for _, m := range migrations {
+		if m.to > version && m.apply(root) {
+			applied = append(applied, m.description)
+		}
+	}
+	return applied
+}
+
+// Migrate rewrites the files of a loaded configuration to CurrentVersion
+// without writing them: the configuration file, its local override and the
+// files it extends or includes. Like ApplyFixes, it leaves alone files
+// outside the directory of the configuration, and keeps comments and the
+// lines the migrations do not touch. Current files are not returned.
+func Migrate(cfg *Config) ([]FileFix, error) {
+	dir, err := filepath.Abs(filepath.Dir(cfg.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	var fixes []FileFix
+	for _, source := range cfg.Sources {
+		file, err := filepath.Abs(source)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(dir, file); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		fix, err := migrateFile(source)
+		if err != nil {
+			return nil, err
+		}
+		if fix != nil {
+			fixes = append(fixes, *fix)
+		}
+	}
+	return fixes, nil
+}
+
+// migrateFile rewrites a configuration file to CurrentVersion, or returns
+// nil when it is current.
+func migrateFile(path string) (*FileFix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	root := doc.Content[0]
+	version, err := fileVersion(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if version == CurrentVersion {
+		return nil, nil
+	}
+
+	indent := detectIndent(data)
+	before, err := encodeDocument(doc, indent)
+	if err != nil {
+		return nil, err
+	}
+	applied := upgrade(root, version)
+	setVersion(root)
+	applied = append(applied, fmt.Sprintf("Set version %d (was %d)", CurrentVersion, version))
+	after, err := encodeDocument(doc, indent)
+	if err != nil {
+		return nil, err
+	}
+	return &FileFix{Path: path, Original: data, Fixed: preserveFormatting(data, before, after), Applied: applied}, nil
+}
+
+// migrateFix migrates the configuration file to CurrentVersion.
+func migrateFix(version int) *Fix {
+	return &Fix{
+		Description: fmt.Sprintf("Migrate the configuration from version %d to %d", version, CurrentVersion),
+		apply: func(root *yaml.Node, _ Position) bool {
+			if current, err := fileVersion(root, ""); err != nil || current == CurrentVersion {
+				return false
+			}
+			upgrade(root, version)
+			setVersion(root)
+			return true
+		},
+		toConfig: true,
+	}
+}
+
+// setVersion sets the version key of the root mapping to CurrentVersion,
+// adding it first when missing.
+func setVersion(root *yaml.Node) {
+	first := ""
+	if len(root.Content) > 0 {
+		first = root.Content[0].Value
+	}
+	value := ensureValue(root, "version", first, yaml.ScalarNode)
+	value.Tag = "!!int"
+	value.Style = 0
+	value.Value = strconv.Itoa(CurrentVersion)
+}
+
+// escapeMessages escapes template actions in the messages of every hook.
+// Version 1 printed messages as written.
+func escapeMessages(root *yaml.Node) bool {
+	changed := false
+	eachHookNode(root, func(hook *yaml.Node) {
+		rules := mappingValue(hook, "output_rules")
+		if rules == nil || rules.Kind != yaml.MappingNode {
+			return
+		}
+		for _, key := range []string{"on_failure_message", "on_success_message"} {
+			message := mappingValue(rules, key)
+			if message != nil && message.Kind == yaml.ScalarNode && strings.Contains(message.Value, "{{") {
+				message.Value = domain.EscapeMessage(message.Value)
+				changed = true
+			}
+		}
+	})
+	return changed
+}
+
+// eachHookNode calls fn with the mapping of every hook of the root mapping
+// of a configuration file.
+func eachHookNode(root *yaml.Node, fn func(hook *yaml.Node)) {
+	hooks := mappingValue(root, "hooks")
+	if hooks == nil || hooks.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 1; i < len(hooks.Content); i += 2 {
+		group := hooks.Content[i]
+		if group.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 1; j < len(group.Content); j += 2 {
+			for _, hook := range group.Content[j].Content {
+				if hook.Kind == yaml.MappingNode {
+					fn(hook)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
+)
+
+func TestLoader_Versions(t *testing.T) {
+	hooks := `
+hooks:
+  go:
+    pre-commit:
+      - name: "Lint"
+        command: "golangci-lint run"
+        output_rules:
+          on_failure_message: "Run {{.FixCommand}} to fix"
+`
+	tests := []struct {
+		name    string
+		version string
+		message string
+		err     string
+	}{
+		{"Unversioned", "", "Run {{.FixCommand}} to fix", ""},
+		{"Version1", "version: 1\n", "Run {{.FixCommand}} to fix", ""},
+		{"Current", "version: 2\n", "Run  to fix", ""},
+		{"Newer", "version: 3\n", "", "uses configuration version 3, but this quality-gate supports versions up to 2; please upgrade"},
+		{"Invalid", "version: latest\n", "", `quality.yml:1:10: version must be a positive integer, got "latest"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, map[string]string{"quality.yml": tt.version + hooks})
+			cfg, err := LoadConfig(filepath.Join(dir, "quality.yml"))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Messages of version 1 are printed as written
+			message := cfg.Hooks["go"]["pre-commit"][0].OutputRules.OnFailureMessage
+			rendered, err := domain.RenderMessage(message, domain.MessageData{})
+			if err != nil || rendered != tt.message {
+				t.Errorf("Expected message %q to render as %q, got %q (%v)", message, tt.message, rendered, err)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"quality.yml": `# yaml-language-server: $schema=https://example.com/schema.json
+include: [hooks/go.yml]
+
+hooks:
+  security:
+    pre-commit:
+      - name: "🔒 Secrets"   # scans staged files
+        builtin: secrets
+        output_rules:
+          on_failure_message: "Never commit {{ secrets }}!"
+`,
+		"hooks/go.yml": `version: 2
+hooks:
+  go:
+    pre-commit:
+      - name: "Vet"
+        command: "go vet ./..."
+`,
+		"quality.local.yml": "disable: [Vet]\n",
+	})
+	path := filepath.Join(dir, "quality.yml")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message := cfg.Hooks["security"]["pre-commit"][0].OutputRules.OnFailureMessage; message != "Never commit {{`{{`}} secrets }}!" {
+		t.Errorf("Expected the version 1 message to be escaped when loaded, got %q", message)
+	}
+
+	fixes, err := Migrate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, fix := range fixes {
+		paths = append(paths, fix.Path)
+	}
+	if expected := []string{path, filepath.Join(dir, "quality.local.yml")}; !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected the version 1 files %v to be migrated, got %v", expected, paths)
+	}
+
+	expected := `# yaml-language-server: $schema=https://example.com/schema.json
+version: 2
+include: [hooks/go.yml]
+
+hooks:
+  security:
+    pre-commit:
+      - name: "🔒 Secrets"   # scans staged files
+        builtin: secrets
+        output_rules:
+          on_failure_message: "Never commit {{` + "`{{`" + `}} secrets }}!"
+`
+	if string(fixes[0].Fixed) != expected {
+		t.Errorf("Unexpected migrated configuration:\n%s", fixes[0].Fixed)
+	}
+	expectedApplied := []string{"Escape {{ in messages, which version 2 renders as templates", "Set version 2 (was 1)"}
+	if !reflect.DeepEqual(fixes[0].Applied, expectedApplied) {
+		t.Errorf("Expected %q, got %q", expectedApplied, fixes[0].Applied)
+	}
+	if string(fixes[1].Fixed) != "version: 2\ndisable: [Vet]\n" {
+		t.Errorf("Unexpected migrated local override:\n%s", fixes[1].Fixed)
+	}
+
+	// Migrated files load to the same configuration
+	for _, fix := range fixes {
+		if err := os.WriteFile(fix.Path, fix.Fixed, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	migrated, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message := migrated.Hooks["security"]["pre-commit"][0].OutputRules.OnFailureMessage; message != "Never commit {{`{{`}} secrets }}!" || migrated.Version != CurrentVersion {
+		t.Errorf("Expected the migrated configuration to keep its meaning, got version %d and message %q", migrated.Version, message)
+	}
+	if fixes, err := Migrate(migrated); err != nil || len(fixes) != 0 {
+		t.Errorf("Expected nothing left to migrate, got %d file(s) (%v)", len(fixes), err)
+	}
+}
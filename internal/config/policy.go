@@ -0,0 +1,313 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyEnvVar names the environment variable giving the policy file.
+const PolicyEnvVar = "QUALITY_GATE_POLICY"
+
+// Rule IDs of the policy findings. Unlike validator rules, the validation
+// section of quality.yml cannot disable them.
+const (
+	RulePolicyRequired  = "policy-required"
+	RulePolicyForbidden = "policy-forbidden"
+	RulePolicySettings  = "policy-settings"
+	RulePolicyLoad      = "policy-load"
+)
+
+// Policy is an organization policy that the configuration of every
+// repository must satisfy. It is kept outside the repositories, so a
+// repository cannot weaken it.
+type Policy struct {
+	Name      string              `yaml:"name,omitempty"`
+	Required  []PolicyRequirement `yaml:"required,omitempty"`
+	Forbidden []PolicyForbidden   `yaml:"forbidden,omitempty"`
+	Settings  PolicySettings      `yaml:"settings,omitempty"`
+
+	// Path is the file the policy was loaded from.
+	Path string `yaml:"-"`
+}
+
+// PolicyRequirement is a hook the configuration must have: a hook of
+// HookType, in the hook group Group, that runs a command matching Command or
+// the builtin Builtin. Empty fields match anything.
+type PolicyRequirement struct {
+	// Name describes the requirement in messages, such as "Secret scanning".
+	Name     string `yaml:"name"`
+	Group    string `yaml:"group,omitempty"`
+	HookType string `yaml:"hook_type,omitempty"`
+	// Command is a regular expression matched against every command run by
+	// the hook, written as its name followed by its arguments.
+	Command string `yaml:"command,omitempty"`
+	Builtin string `yaml:"builtin,omitempty"`
+	Message string `yaml:"message,omitempty"`
+
+	command *regexp.Regexp
+}
+
+// PolicyForbidden is a command no tool, hook or fix command may run.
+type PolicyForbidden struct {
+	// Command is a regular expression matched like PolicyRequirement.Command.
+	Command string `yaml:"command"`
+	Message string `yaml:"message,omitempty"`
+
+	command *regexp.Regexp
+}
+
+// PolicySettings are the minimum settings of the configuration.
+type PolicySettings struct {
+	// MinVersion is the lowest min_version the configuration may declare.
+	MinVersion string `yaml:"min_version,omitempty"`
+	// ProtectedRules are validator rules the configuration may not disable
+	// or lower the severity of.
+	ProtectedRules []string `yaml:"protected_rules,omitempty"`
+}
+
+// FindPolicy returns the policy file to load: the explicit path when given,
+// else $QUALITY_GATE_POLICY, else "" when no policy applies.
+func FindPolicy(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	return os.Getenv(PolicyEnvVar)
+}
+
+// LoadPolicy reads a policy file, rejecting unknown keys, invalid regular
+// expressions and requirements without any criteria.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var policy Policy
+	if node.Kind != 0 {
+		var unknown DecodeErrors
+		checkKnownKeys(&node, schemaFor(reflect.TypeOf(Policy{})), "", path, &unknown)
+		if len(unknown) > 0 {
+			return nil, unknown
+		}
+		if err := node.Decode(&policy); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	policy.Path = path
+
+	for i := range policy.Required {
+		requirement := &policy.Required[i]
+		field := fmt.Sprintf("required[%d]", i)
+		if requirement.Name == "" {
+			return nil, fmt.Errorf("%s: %s needs a name", path, field)
+		}
+		if requirement.Group == "" && requirement.HookType == "" && requirement.Command == "" && requirement.Builtin == "" {
+			return nil, fmt.Errorf("%s: %s needs a group, hook_type, command or builtin", path, field)
+		}
+		if requirement.command, err = compilePolicyPattern(requirement.Command); err != nil {
+			return nil, fmt.Errorf("%s: %s.command: %w", path, field, err)
+		}
+	}
+	for i := range policy.Forbidden {
+		forbidden := &policy.Forbidden[i]
+		if forbidden.Command == "" {
+			return nil, fmt.Errorf("%s: forbidden[%d] needs a command", path, i)
+		}
+		if forbidden.command, err = compilePolicyPattern(forbidden.Command); err != nil {
+			return nil, fmt.Errorf("%s: forbidden[%d].command: %w", path, i, err)
+		}
+	}
+	if policy.Settings.MinVersion != "" {
+		if _, ok := parseVersion(policy.Settings.MinVersion); !ok {
+			return nil, fmt.Errorf("%s: settings.min_version: %q is not a valid version", path, policy.Settings.MinVersion)
+		}
+	}
+	return &policy, nil
+}
+
+func compilePolicyPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// PolicyEngine checks a configuration against an organization policy.
+type PolicyEngine struct {
+	policy *Policy
+	config *Config
+}
+
+// NewPolicyEngine creates a policy engine for a configuration.
+func NewPolicyEngine(policy *Policy, config *Config) *PolicyEngine {
+	return &PolicyEngine{policy: policy, config: config}
+}
+
+// Check reports every way the configuration weakens the policy. Every
+// finding is critical, so it fails the gate.
+func (e *PolicyEngine) Check() *ValidationResult {
+	result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
+
+	e.checkRequired(result)
+	e.checkForbidden(result)
+	e.checkSettings(result)
+
+	locator := &ConfigValidator{config: e.config}
+	for i := range result.Errors {
+		result.Errors[i].Position = locator.locate(result.Errors[i].Field)
+	}
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// checkRequired reports the required hooks the configuration lacks.
+func (e *PolicyEngine) checkRequired(result *ValidationResult) {
+	for _, requirement := range e.policy.Required {
+		if e.satisfies(requirement) {
+			continue
+		}
+
+		issue := fmt.Sprintf("Policy requires %s: no %s", requirement.Name, describeRequirement(requirement))
+		if requirement.Message != "" {
+			issue += " (" + requirement.Message + ")"
+		}
+		suggestion := "Add such a hook to the configuration"
+		if len(e.config.Disable) > 0 {
+			suggestion += ", or stop disabling it"
+		}
+		field := "hooks"
+		if requirement.Group != "" {
+			if _, ok := e.config.Hooks[requirement.Group]; ok {
+				field = "hooks." + requirement.Group
+			}
+		}
+		result.Errors = append(result.Errors, e.finding(RulePolicyRequired, field, requirement.Name, issue, suggestion))
+	}
+}
+
+// satisfies reports whether a hook of the configuration meets a requirement.
+func (e *PolicyEngine) satisfies(requirement PolicyRequirement) bool {
+	for group, hookTypes := range e.config.Hooks {
+		if requirement.Group != "" && group != requirement.Group {
+			continue
+		}
+		for hookType, hooks := range hookTypes {
+			if requirement.HookType != "" && hookType != requirement.HookType {
+				continue
+			}
+			for _, hook := range hooks {
+				if requirement.command == nil && requirement.Builtin == "" {
+					return true
+				}
+				if requirement.Builtin != "" && hook.Builtin == requirement.Builtin {
+					return true
+				}
+				if requirement.command != nil && hook.Command != "" && matchesAny(requirement.command, commandLines(hook.Command)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// describeRequirement describes the hook a requirement asks for, as in
+// "pre-push hook in group 'tests' running a command matching '^go test'".
+func describeRequirement(requirement PolicyRequirement) string {
+	description := "hook"
+	if requirement.HookType != "" {
+		description = requirement.HookType + " hook"
+	}
+	if requirement.Group != "" {
+		description += fmt.Sprintf(" in group '%s'", requirement.Group)
+	}
+	var runs []string
+	if requirement.Builtin != "" {
+		runs = append(runs, fmt.Sprintf("the builtin '%s'", requirement.Builtin))
+	}
+	if requirement.Command != "" {
+		runs = append(runs, fmt.Sprintf("a command matching '%s'", requirement.Command))
+	}
+	if len(runs) > 0 {
+		description += " running " + strings.Join(runs, " or ")
+	}
+	return description
+}
+
+// checkForbidden reports the commands the policy forbids.
+func (e *PolicyEngine) checkForbidden(result *ValidationResult) {
+	locator := &ConfigValidator{config: e.config}
+	locator.eachCommand(func(field, command string) {
+		lines := commandLines(command)
+		for _, forbidden := range e.policy.Forbidden {
+			if !matchesAny(forbidden.command, lines) {
+				continue
+			}
+			issue := fmt.Sprintf("Policy forbids commands matching '%s'", forbidden.Command)
+			if forbidden.Message != "" {
+				issue = "Policy forbids this command: " + forbidden.Message
+			}
+			result.Errors = append(result.Errors, e.finding(RulePolicyForbidden, field, command, issue, "Remove the forbidden command"))
+		}
+	})
+}
+
+// checkSettings reports the settings below the minimum of the policy.
+func (e *PolicyEngine) checkSettings(result *ValidationResult) {
+	settings := e.policy.Settings
+	if settings.MinVersion != "" {
+		if cmp, err := CompareVersions(e.config.MinVersion, settings.MinVersion); err != nil || cmp < 0 {
+			result.Errors = append(result.Errors, e.finding(RulePolicySettings, "min_version", e.config.MinVersion,
+				fmt.Sprintf("Policy requires min_version %s or newer", settings.MinVersion),
+				fmt.Sprintf("Set min_version: \"%s\"", settings.MinVersion)))
+		}
+	}
+
+	validation := e.config.Validation
+	for i, rule := range validation.Disable {
+		if contains(settings.ProtectedRules, rule) {
+			result.Errors = append(result.Errors, e.finding(RulePolicySettings, fmt.Sprintf("validation.disable[%d]", i), rule,
+				fmt.Sprintf("Policy protects the rule '%s', which cannot be disabled", rule),
+				"Remove it from validation.disable"))
+		}
+	}
+	for _, rule := range sortedKeys(validation.Severity) {
+		if severity, _ := ParseSeverity(validation.Severity[rule]); contains(settings.ProtectedRules, rule) && severity != SeverityCritical {
+			result.Errors = append(result.Errors, e.finding(RulePolicySettings, "validation.severity."+rule, validation.Severity[rule],
+				fmt.Sprintf("Policy protects the rule '%s', whose severity can only be raised to critical", rule),
+				"Remove the severity override"))
+		}
+	}
+}
+
+func (e *PolicyEngine) finding(rule, field, value, issue, suggestion string) ValidationError {
+	if e.policy.Name != "" {
+		issue = fmt.Sprintf("[%s] %s", e.policy.Name, issue)
+	}
+	return ValidationError{
+		Rule:       rule,
+		Field:      field,
+		Value:      value,
+		Issue:      issue,
+		Suggestion: suggestion,
+		Severity:   SeverityCritical,
+	}
+}
+
+func matchesAny(pattern *regexp.Regexp, lines []string) bool {
+	for _, line := range lines {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPolicy = `name: Acme
+required:
+  - name: secret scanning
+    hook_type: pre-commit
+    builtin: secrets
+    command: '^gitleaks (detect|protect)'
+  - name: tests
+    hook_type: pre-push
+    command: '^(go|npm) test'
+forbidden:
+  - command: '--no-verify'
+    message: hooks must not be skipped
+settings:
+  min_version: "1.2.0"
+  protected_rules: [dangerous-command]
+`
+
+func TestLoadPolicy(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"policy.yml":  testPolicy,
+		"unknown.yml": "required:\n  - name: tests\n    hooktype: pre-push\n",
+		"empty.yml":   "required:\n  - name: anything\n",
+		"pattern.yml": "forbidden:\n  - command: '(unclosed'\n",
+		"version.yml": "settings:\n  min_version: latest\n",
+	})
+
+	policy, err := LoadPolicy(filepath.Join(dir, "policy.yml"))
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	if policy.Name != "Acme" || len(policy.Required) != 2 || len(policy.Forbidden) != 1 || policy.Settings.MinVersion != "1.2.0" {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+
+	for file, expected := range map[string]string{
+		"unknown.yml": `unknown key "hooktype" in required[0] (did you mean "hook_type"?)`,
+		"empty.yml":   "required[0] needs a group, hook_type, command or builtin",
+		"pattern.yml": "forbidden[0].command: error parsing regexp",
+		"version.yml": `settings.min_version: "latest" is not a valid version`,
+	} {
+		_, err := LoadPolicy(filepath.Join(dir, file))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected error containing %q, got %v", file, expected, err)
+		}
+	}
+}
+
+func TestFindPolicy(t *testing.T) {
+	t.Setenv(PolicyEnvVar, "/etc/quality-gate/policy.yml")
+	if path := FindPolicy("policy.yml"); path != "policy.yml" {
+		t.Errorf("Expected the explicit path, got %q", path)
+	}
+	if path := FindPolicy(""); path != "/etc/quality-gate/policy.yml" {
+		t.Errorf("Expected the path from %s, got %q", PolicyEnvVar, path)
+	}
+}
+
+func TestPolicyEngine_Check(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"policy.yml": testPolicy})
+	policy, err := LoadPolicy(filepath.Join(dir, "policy.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compliant := `min_version: "1.3.0"
+hooks:
+  security:
+    pre-commit:
+      - name: "Secrets"
+        command: "gitleaks protect --staged"
+  go:
+    pre-push:
+      - name: "Tests"
+        command: "cd backend && go test ./..."
+`
+	testCases := []struct {
+		name     string
+		config   string
+		local    string
+		expected []string
+	}{
+		{
+			name:   "Compliant",
+			config: compliant,
+		},
+		{
+			name:   "BuiltinSatisfiesRequirement",
+			config: strings.Replace(compliant, `command: "gitleaks protect --staged"`, `builtin: secrets`, 1),
+		},
+		{
+			name: "MissingHooks",
+			config: `min_version: "1.2.0"
+hooks:
+  go:
+    pre-push:
+      - name: "Lint"
+        command: "echo go test"
+`,
+			expected: []string{
+				"policy-required: [Acme] Policy requires secret scanning: no pre-commit hook running the builtin 'secrets' or a command matching '^gitleaks (detect|protect)'",
+				"policy-required: [Acme] Policy requires tests: no pre-push hook running a command matching '^(go|npm) test'",
+			},
+		},
+		{
+			name:   "WeakenedByLocalOverride",
+			config: compliant,
+			local: `disable: ["Tests"]
+validation:
+  disable: [dangerous-command]
+  severity:
+    dangerous-command: warning
+hooks:
+  go:
+    pre-commit:
+      - name: "Push"
+        command: "git push --no-verify"
+`,
+			expected: []string{
+				"policy-required: [Acme] Policy requires tests: no pre-push hook running a command matching '^(go|npm) test'",
+				"policy-forbidden: [Acme] Policy forbids this command: hooks must not be skipped",
+				"policy-settings: [Acme] Policy protects the rule 'dangerous-command', which cannot be disabled",
+				"policy-settings: [Acme] Policy protects the rule 'dangerous-command', whose severity can only be raised to critical",
+			},
+		},
+		{
+			name:     "OldMinVersion",
+			config:   strings.Replace(compliant, `"1.3.0"`, `"1.1.0"`, 1),
+			expected: []string{"policy-settings: [Acme] Policy requires min_version 1.2.0 or newer"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string]string{"quality.yml": tc.config}
+			if tc.local != "" {
+				files["quality.local.yml"] = tc.local
+			}
+			path := filepath.Join(writeConfigFiles(t, files), "quality.yml")
+			config, err := LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := NewPolicyEngine(policy, config).Check()
+			var findings []string
+			for _, finding := range result.Errors {
+				if finding.Severity != SeverityCritical {
+					t.Errorf("Expected policy findings to be critical, got %v", finding)
+				}
+				findings = append(findings, finding.Rule+": "+finding.Issue)
+			}
+			if strings.Join(findings, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(tc.expected, "\n"), strings.Join(findings, "\n"))
+			}
+			if result.Valid != (len(tc.expected) == 0) {
+				t.Errorf("Expected Valid to be %v", len(tc.expected) == 0)
+			}
+		})
+	}
+}
+
+func TestPolicyEngine_Positions(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"policy.yml":  testPolicy,
+		"quality.yml": "hooks:\n  go:\n    pre-push:\n      - name: \"Push\"\n        command: \"git push --no-verify\"\n",
+	})
+	policy, err := LoadPolicy(filepath.Join(dir, "policy.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "quality.yml")
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, finding := range NewPolicyEngine(policy, config).Check().Errors {
+		if finding.Rule == RulePolicyForbidden {
+			if expected := (Position{File: path, Line: 5, Column: 18}); finding.Position != expected {
+				t.Errorf("Expected the forbidden command at %v, got %v", expected, finding.Position)
+			}
+			return
+		}
+	}
+	t.Error("Expected a policy-forbidden finding")
+}
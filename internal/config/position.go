@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position locates a value in a configuration file.
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// IsZero reports whether the position is unknown.
+func (p Position) IsZero() bool {
+	return p.File == "" && p.Line == 0
+}
+
+// String formats the position as file:line:column.
+func (p Position) String() string {
+	switch {
+	case p.Line == 0:
+		return p.File
+	case p.File == "":
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	default:
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+}
+
+// Positions maps the keys of a configuration entry, such as "command" or
+// "output_rules.show_on", to where their values were set. The empty key
+// locates the entry itself.
+type Positions map[string]Position
+
+// mergePositions returns base with the positions set in overlay taking precedence.
+func mergePositions(base, overlay Positions) Positions {
+	if base == nil && overlay == nil {
+		return nil
+	}
+	merged := make(Positions, len(base)+len(overlay))
+	for key, pos := range base {
+		merged[key] = pos
+	}
+	for key, pos := range overlay {
+		merged[key] = pos
+	}
+	return merged
+}
+
+// DecodeError is a configuration error located in a file.
+type DecodeError struct {
+	Position Position
+	Message  string
+}
+
+func (e *DecodeError) Error() string {
+	return e.Position.String() + ": " + e.Message
+}
+
+// DecodeErrors collects every error found while decoding a file.
+type DecodeErrors []*DecodeError
+
+func (e DecodeErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func nodePosition(node *yaml.Node, file string) Position {
+	return Position{File: file, Line: node.Line, Column: node.Column}
+}
+
+// checkKnownKeys reports every mapping key below node that the schema does
+// not allow, suggesting the closest known key. YAML merge keys (<<) are
+// skipped: the mapping they merge is checked where its anchor is defined.
+func checkKnownKeys(node *yaml.Node, schema *SchemaNode, field, file string, errs *DecodeErrors) {
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			checkKnownKeys(child, schema, field, file, errs)
+		}
+		return
+	}
+	for _, variant := range schema.OneOf {
+		if variant.Type == "object" && node.Kind == yaml.MappingNode {
+			schema = variant
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			path := key.Value
+			if field != "" {
+				path = field + "." + key.Value
+			}
+
+			if key.Tag == "!!merge" {
+				continue
+			}
+			if property, ok := schema.Properties[key.Value]; ok {
+				checkKnownKeys(value, property, path, file, errs)
+				continue
+			}
+			if matchesPatternProperty(schema, key.Value) {
+				continue
+			}
+			if additional, ok := schema.AdditionalProperties.(*SchemaNode); ok {
+				checkKnownKeys(value, additional, path, file, errs)
+				continue
+			}
+			if schema.Properties == nil {
+				continue
+			}
+
+			known := make([]string, 0, len(schema.Properties))
+			for name := range schema.Properties {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			message := fmt.Sprintf("unknown key %q", key.Value)
+			if field != "" {
+				message += " in " + field
+			}
+			if suggestion := suggestKey(key.Value, known); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			} else {
+				message += fmt.Sprintf(" (known keys: %s)", strings.Join(known, ", "))
+			}
+			*errs = append(*errs, &DecodeError{Position: nodePosition(key, file), Message: message})
+		}
+	case yaml.SequenceNode:
+		if schema.Items != nil {
+			for i, child := range node.Content {
+				checkKnownKeys(child, schema.Items, fmt.Sprintf("%s[%d]", field, i), file, errs)
+			}
+		}
+	}
+}
+
+// matchesPatternProperty reports whether a key matches one of the
+// patternProperties of a schema node.
+func matchesPatternProperty(schema *SchemaNode, key string) bool {
+	for pattern := range schema.PatternProperties {
+		if regexp.MustCompile(pattern).MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestKey returns the known key closest to a misspelled one, or "".
+func suggestKey(key string, known []string) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+	}
+
+	best, bestDistance := "", len(key)/3+2
+	for _, candidate := range known {
+		if normalize(candidate) == normalize(key) {
+			return candidate
+		}
+		if d := levenshtein(key, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// recordPositions stores where the top-level keys, tools and hooks of a
+// decoded configuration were set.
+func recordPositions(config *Config, node *yaml.Node, file string) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	config.Positions = Positions{"": nodePosition(node, file)}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		config.Positions[key.Value] = nodePosition(key, file)
+
+		switch key.Value {
+		case "tools":
+			if value.Kind == yaml.SequenceNode && len(value.Content) == len(config.Tools) {
+				for j, item := range value.Content {
+					config.Tools[j].Positions = entryPositions(item, file)
+				}
+			}
+		case "hooks":
+			recordHookPositions(config, value, file)
+		}
+	}
+}
+
+func recordHookPositions(config *Config, node *yaml.Node, file string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		groupKey, groupNode := node.Content[i], node.Content[i+1]
+		group := groupKey.Value
+		config.Positions["hooks."+group] = nodePosition(groupKey, file)
+		if groupNode.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for j := 0; j+1 < len(groupNode.Content); j += 2 {
+			typeKey, typeNode := groupNode.Content[j], groupNode.Content[j+1]
+			hookType := typeKey.Value
+			config.Positions["hooks."+group+"."+hookType] = nodePosition(typeKey, file)
+
+			hooks := config.Hooks[group][hookType]
+			if typeNode.Kind != yaml.SequenceNode || len(typeNode.Content) != len(hooks) {
+				continue
+			}
+			for k, item := range typeNode.Content {
+				hooks[k].Positions = entryPositions(item, file)
+			}
+		}
+	}
+}
+
+// entryPositions maps the keys of a mapping, and of its nested mappings, to
+// the position of their values.
+func entryPositions(node *yaml.Node, file string) Positions {
+	positions := Positions{"": nodePosition(node, file)}
+	var walk func(node *yaml.Node, prefix string)
+	walk = func(node *yaml.Node, prefix string) {
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			positions[prefix+key.Value] = nodePosition(value, file)
+			walk(value, prefix+key.Value+".")
+		}
+	}
+	walk(node, "")
+	return positions
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule IDs of the ConfigValidator checks. They are stable: the validation
+// section of quality.yml refers to them to disable a check or change its
+// severity.
+const (
+	RuleToolsEmpty           = "tools-empty"
+	RuleToolNameEmpty        = "tool-name-empty"
+	RuleToolCheckEmpty       = "tool-check-command-empty"
+	RuleToolInstallEmpty     = "tool-install-command-empty"
+	RuleToolNotFound         = "tool-not-found"
+	RuleToolNotConfigured    = "tool-not-configured"
+	RuleDuplicateTool        = "duplicate-tool"
+	RuleHooksEmpty           = "hooks-empty"
+	RuleHookGroupNameEmpty   = "hook-group-name-empty"
+	RuleHookGroupEmpty       = "hook-group-empty"
+	RuleHookTypeEmpty        = "hook-type-empty"
+	RuleHookNameEmpty        = "hook-name-empty"
+	RuleHookCommandEmpty     = "hook-command-empty"
+	RuleBuiltinUnknown       = "builtin-unknown"
+	RuleBuiltinOptions       = "builtin-options"
+	RuleCommandAndBuiltin    = "command-and-builtin"
+	RuleDangerousCommand     = "dangerous-command"
+	RuleShellSyntax          = "shell-syntax"
+	RuleToolTypo             = "tool-typo"
+	RuleShowOnInvalid        = "show-on-invalid"
+	RuleOutputRuleInvalid    = "output-rule-invalid"
+	RuleMessageTemplate      = "message-template"
+	RuleSecurityHooksMissing = "security-hooks-missing"
+	RuleMinVersionInvalid    = "min-version-invalid"
+	RuleVersionOutdated      = "version-outdated"
+	RuleUndefinedVariable    = "undefined-variable"
+	RuleConfigFileAccess     = "config-file-access"
+	RuleValidationConfig     = "validation-config"
+	RuleUnknownKey           = "unknown-key"
+	RuleConfigLoad           = "config-load"
+)
+
+// builtinRules lists the rules of the ConfigValidator. Unknown keys and
+// files that cannot be loaded are reported before validation runs, so their
+// rules cannot be configured.
+var builtinRules = []string{
+	RuleToolsEmpty, RuleToolNameEmpty, RuleToolCheckEmpty, RuleToolInstallEmpty,
+	RuleToolNotFound, RuleToolNotConfigured, RuleDuplicateTool,
+	RuleHooksEmpty, RuleHookGroupNameEmpty, RuleHookGroupEmpty, RuleHookTypeEmpty,
+	RuleHookNameEmpty, RuleHookCommandEmpty,
+	RuleBuiltinUnknown, RuleBuiltinOptions, RuleCommandAndBuiltin,
+	RuleDangerousCommand, RuleShellSyntax, RuleToolTypo,
+	RuleShowOnInvalid, RuleOutputRuleInvalid, RuleMessageTemplate, RuleSecurityHooksMissing,
+	RuleMinVersionInvalid, RuleVersionOutdated, RuleUndefinedVariable, RuleConfigFileAccess,
+	RuleValidationConfig,
+}
+
+// severityNames are the severities accepted by the validation section.
+var severityNames = []string{"warning", "error", "critical"}
+
+// ParseSeverity returns the severity named "warning", "error" or "critical".
+func ParseSeverity(name string) (ValidationSeverity, bool) {
+	switch strings.ToLower(name) {
+	case "warning":
+		return SeverityWarning, true
+	case "error":
+		return SeverityError, true
+	case "critical":
+		return SeverityCritical, true
+	}
+	return 0, false
+}
+
+// validateCustomRules reports the commands matching the custom rules of the
+// validation section, and the rules that cannot be applied.
+func (v *ConfigValidator) validateCustomRules(result *ValidationResult) {
+	known := append([]string{}, builtinRules...)
+	for i, rule := range v.config.Validation.Rules {
+		field := fmt.Sprintf("validation.rules[%d]", i)
+		known = append(known, rule.ID)
+
+		if strings.TrimSpace(rule.ID) == "" {
+			result.Errors = append(result.Errors, v.configError(field+".id", rule.ID, "Custom rule has no id", "Give the rule an id, used to disable it or change its severity"))
+		} else if contains(builtinRules, rule.ID) {
+			result.Errors = append(result.Errors, v.configError(field+".id", rule.ID, fmt.Sprintf("Custom rule id '%s' is a builtin rule", rule.ID), "Choose another id"))
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if rule.Pattern == "" || err != nil {
+			issue := "Custom rule has no pattern"
+			if err != nil {
+				issue = fmt.Sprintf("Invalid pattern: %v", err)
+			}
+			result.Errors = append(result.Errors, v.configError(field+".pattern", rule.Pattern, issue, "Use a Go regular expression matched against every command"))
+			continue
+		}
+		severity := SeverityError
+		if rule.Severity != "" {
+			var ok bool
+			if severity, ok = ParseSeverity(rule.Severity); !ok {
+				result.Errors = append(result.Errors, v.configError(field+".severity", rule.Severity, "Invalid severity", "Use "+quotedList(severityNames)))
+				severity = SeverityError
+			}
+		}
+
+		issue := rule.Message
+		if issue == "" {
+			issue = fmt.Sprintf("Command matches the forbidden pattern %s", rule.Pattern)
+		}
+		v.eachCommand(func(field, command string) {
+			if pattern.MatchString(command) {
+				result.Errors = append(result.Errors, ValidationError{
+					Rule:       rule.ID,
+					Field:      field,
+					Value:      command,
+					Issue:      issue,
+					Suggestion: rule.Suggestion,
+					Severity:   severity,
+				})
+			}
+		})
+	}
+
+	sort.Strings(known)
+	checkRule := func(field, id string) {
+		if contains(known, id) {
+			return
+		}
+		suggestion := "Use one of the rule ids shown by 'quality-gate validate'"
+		if closest := suggestKey(id, known); closest != "" {
+			suggestion = fmt.Sprintf("Did you mean '%s'?", closest)
+		}
+		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleValidationConfig,
+			Field:      field,
+			Value:      id,
+			Issue:      fmt.Sprintf("Unknown rule '%s'", id),
+			Suggestion: suggestion,
+			Severity:   SeverityWarning,
+		})
+	}
+	for i, id := range v.config.Validation.Disable {
+		checkRule(fmt.Sprintf("validation.disable[%d]", i), id)
+	}
+	for _, id := range sortedKeys(v.config.Validation.Severity) {
+		field := "validation.severity." + id
+		checkRule(field, id)
+		if _, ok := ParseSeverity(v.config.Validation.Severity[id]); !ok {
+			result.Errors = append(result.Errors, v.configError(field, v.config.Validation.Severity[id], "Invalid severity", "Use "+quotedList(severityNames)))
+		}
+	}
+}
+
+// applyRuleSettings removes the findings of disabled rules and applies the
+// severity overrides of the validation section.
+func (v *ConfigValidator) applyRuleSettings(result *ValidationResult) {
+	settings := v.config.Validation
+	kept := result.Errors[:0]
+	for _, err := range result.Errors {
+		if contains(settings.Disable, err.Rule) {
+			continue
+		}
+		if severity, ok := ParseSeverity(settings.Severity[err.Rule]); ok {
+			err.Severity = severity
+		}
+		kept = append(kept, err)
+	}
+	result.Errors = kept
+}
+
+// eachCommand calls fn with the field path and text of every shell command
+// of the configuration.
+func (v *ConfigValidator) eachCommand(fn func(field, command string)) {
+	for i, tool := range v.config.Tools {
+		prefix := fmt.Sprintf("tools[%d]", i)
+		if tool.CheckCommand != "" {
+			fn(prefix+".check_command", tool.CheckCommand)
+		}
+		if tool.InstallCommand != "" {
+			fn(prefix+".install_command", tool.InstallCommand)
+		}
+	}
+	for _, group := range sortedKeys(v.config.Hooks) {
+		for _, hookType := range sortedKeys(v.config.Hooks[group]) {
+			for i, hook := range v.config.Hooks[group][hookType] {
+				prefix := fmt.Sprintf("hooks.%s.%s[%d]", group, hookType, i)
+				if hook.Command != "" {
+					fn(prefix+".command", hook.Command)
+				}
+				if hook.FixCommand != "" {
+					fn(prefix+".fix_command", hook.FixCommand)
+				}
+			}
+		}
+	}
+}
+
+// configError reports an error in the validation section itself.
+func (v *ConfigValidator) configError(field, value, issue, suggestion string) ValidationError {
+	return ValidationError{
+		Rule:       RuleValidationConfig,
+		Field:      field,
+		Value:      value,
+		Issue:      issue,
+		Suggestion: suggestion,
+		Severity:   SeverityError,
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/builtin"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaURL is where the published JSON Schema of the configuration lives.
+const SchemaURL = "https://dmux.github.io/go-quality-gate/quality.schema.json"
+
+// SchemaNode is a JSON Schema (draft-07) node.
+type SchemaNode struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Properties           map[string]*SchemaNode `json:"properties,omitempty"`
+	PatternProperties    map[string]*SchemaNode `json:"patternProperties,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                *SchemaNode            `json:"items,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	OneOf                []*SchemaNode          `json:"oneOf,omitempty"`
+}
+
+// fieldRule holds what the Go types of a configuration field do not say:
+// its documentation and constraints. The rules feed both the JSON Schema and
+// the ConfigValidator.
+type fieldRule struct {
+	Description string
+	Enum        []string
+	Pattern     string
+	Required    bool
+}
+
+// versionPattern matches the versions accepted by min_version.
+const versionPattern = `^v?[0-9]+(\.[0-9]+){0,2}(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`
+
+// fieldRules is keyed by "Type.Field" of the configuration structs.
+var fieldRules = map[string]fieldRule{
+	"Config.Version":    {Description: fmt.Sprintf("Configuration version of the file, 1 when omitted. The current version is %d; run 'quality-gate migrate' to upgrade.", CurrentVersion)},
+	"Config.MinVersion": {Description: "Oldest quality-gate version able to run this configuration.", Pattern: versionPattern},
+	"Config.Extends":    {Description: "Base configurations merged before this file: local paths or files in a git repository at a pinned ref."},
+	"Config.Include":    {Description: "Local configuration fragments merged after the bases and before this file."},
+	"Config.Tools":      {Description: "Tools required by the hooks, checked and installed before they run."},
+	"Config.Hooks":      {Description: "Hook groups, each mapping git hook types (pre-commit, pre-push) to the hooks they run."},
+	"Config.Disable":    {Description: "Names of hooks not to run, whatever their group."},
+	"Config.Env":        {Description: "Environment variables added to hook and fix commands."},
+	"Config.Validation": {Description: "Rules of 'quality-gate validate': disabled rules, severity overrides and custom rules."},
+
+	"Validation.Disable":  {Description: "IDs of the validation rules not to apply."},
+	"Validation.Severity": {Description: "Severity of validation rules, by ID."},
+	"Validation.Rules":    {Description: "Custom rules reporting the commands that match a regular expression."},
+
+	"ValidationRule.ID":         {Description: "Stable ID of the rule, used to disable it or change its severity.", Required: true},
+	"ValidationRule.Pattern":    {Description: "Go regular expression matched against every tool, hook and fix command.", Required: true},
+	"ValidationRule.Message":    {Description: "Issue reported for a matching command."},
+	"ValidationRule.Suggestion": {Description: "How to fix a matching command."},
+	"ValidationRule.Severity":   {Description: "Severity of the issue (default: error).", Enum: severityNames},
+
+	"Extend.Path": {Description: "Path of the base configuration, relative to this file or to the repository root for git bases."},
+	"Extend.Git":  {Description: "URL of a git repository holding the base configuration."},
+	"Extend.Ref":  {Description: "Tag, branch or commit of the git repository. Required with git."},
+
+	"Tool.Name":           {Description: "Human-readable tool name.", Required: true},
+	"Tool.CheckCommand":   {Description: "Command that succeeds when the tool is installed."},
+	"Tool.InstallCommand": {Description: "Command installing the tool when check_command fails."},
+	"Tool.Remove":         {Description: "Remove the inherited tool with this name."},
+
+	"Hook.Name":        {Description: "Name of the hook, used in output and to match inherited hooks.", Required: true},
+	"Hook.Command":     {Description: "Shell command running the check. Mutually exclusive with builtin."},
+	"Hook.Builtin":     {Description: "Native check run instead of a command.", Enum: builtin.Names()},
+	"Hook.Options":     {Description: "Options of the builtin check."},
+	"Hook.Files":       {Description: "Glob patterns selecting the files the hook applies to; the hook is skipped when none match."},
+	"Hook.Exclude":     {Description: "Glob patterns of files the hook ignores."},
+	"Hook.FixCommand":  {Description: "Shell command fixing the issues, run by --fix."},
+	"Hook.OutputRules": {Description: "When and how the hook output is shown."},
+	"Hook.Remove":      {Description: "Remove the inherited hook with this name."},
+
+	"OutputRules.ShowOn":           {Description: "When to show the command output.", Enum: []string{"always", "failure", "success"}},
+	"OutputRules.OnFailureMessage": {Description: "Message printed when the hook fails, a Go template with .Name, .HookType, .Duration, .ExitCode, .Files, .Output, .OutputTail and .FixCommand."},
+	"OutputRules.OnSuccessMessage": {Description: "Message printed when the hook passes, a Go template like on_failure_message."},
+	"OutputRules.MaxLines":         {Description: "Maximum number of output lines shown; 0 shows all. JSON output keeps the full output."},
+	"OutputRules.Truncate":         {Description: "Lines kept when the output exceeds max_lines: the first (head, the default) or the last (tail).", Enum: []string{"head", "tail"}},
+	"OutputRules.Filter":           {Description: "Regular expression; only the output lines matching it are shown."},
+	"OutputRules.Strip":            {Description: "Regular expression whose matches are removed from the shown output, such as color codes."},
+}
+
+// allowedValues returns the values accepted by a field, or nil when any value is.
+func allowedValues(field string) []string {
+	return fieldRules[field].Enum
+}
+
+// Schema returns the JSON Schema of the configuration file, generated from
+// the Config types and fieldRules.
+func Schema() *SchemaNode {
+	root := schemaFor(reflect.TypeOf(Config{}))
+	root.Schema = "http://json-schema.org/draft-07/schema#"
+	root.ID = SchemaURL
+	root.Title = "quality-gate configuration"
+	root.Description = "Configuration of quality-gate (quality.yml)."
+	// x- keys hold YAML anchors reused elsewhere in the file.
+	root.PatternProperties = map[string]*SchemaNode{"^x-": {}}
+
+	// A hook group or hook type set to null clears the inherited one.
+	group := root.Properties["hooks"].AdditionalProperties.(*SchemaNode)
+	group.Type = []string{"object", "null"}
+	hookType := group.AdditionalProperties.(*SchemaNode)
+	hookType.Type = []string{"array", "null"}
+
+	// Option and env values are decoded as strings but may be written as
+	// numbers or booleans.
+	scalar := []string{"string", "number", "boolean"}
+	hookType.Items.Properties["options"].AdditionalProperties.(*SchemaNode).Type = scalar
+	root.Properties["env"].AdditionalProperties.(*SchemaNode).Type = scalar
+
+	root.Properties["validation"].Properties["severity"].AdditionalProperties.(*SchemaNode).Enum = severityNames
+	return root
+}
+
+// SchemaJSON returns the indented JSON Schema of the configuration file.
+func SchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+var yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+func schemaFor(t reflect.Type) *SchemaNode {
+	switch t.Kind() {
+	case reflect.String:
+		return &SchemaNode{Type: "string"}
+	case reflect.Bool:
+		return &SchemaNode{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &SchemaNode{Type: "integer"}
+	case reflect.Slice:
+		return &SchemaNode{Type: "array", Items: schemaFor(t.Elem())}
+	case reflect.Map:
+		return &SchemaNode{Type: "object", AdditionalProperties: schemaFor(t.Elem())}
+	case reflect.Struct:
+		node := &SchemaNode{Type: "object", Properties: make(map[string]*SchemaNode), AdditionalProperties: false}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "-" || name == "" {
+				continue
+			}
+
+			property := schemaFor(field.Type)
+			rule := fieldRules[t.Name()+"."+field.Name]
+			property.Description = rule.Description
+			property.Enum = rule.Enum
+			property.Pattern = rule.Pattern
+			if rule.Required {
+				node.Required = append(node.Required, name)
+			}
+			node.Properties[name] = property
+		}
+		// Types with custom decoding also accept a plain string.
+		if reflect.PtrTo(t).Implements(yamlUnmarshalerType) {
+			return &SchemaNode{OneOf: []*SchemaNode{{Type: "string"}, node}}
+		}
+		return node
+	}
+	return &SchemaNode{}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var updateSchema = flag.Bool("update", false, "rewrite docs/quality.schema.json")
+
+const schemaFile = "../../docs/quality.schema.json"
+
+// TestSchema_UpToDate keeps the published schema in sync with the config
+// types. Run `go test ./internal/config -run Schema -update` after changing them.
+func TestSchema_UpToDate(t *testing.T) {
+	generated, err := SchemaJSON()
+	if err != nil {
+		t.Fatalf("SchemaJSON failed: %v", err)
+	}
+
+	if *updateSchema {
+		if err := os.WriteFile(schemaFile, generated, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	committed, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", schemaFile, err)
+	}
+	if !bytes.Equal(committed, generated) {
+		t.Errorf("%s is out of date; run: go test ./internal/config -run Schema -update", schemaFile)
+	}
+}
+
+// TestSchema_DocumentsEveryField ensures fieldRules covers every field of the
+// config types, so new fields get a description and stale rules are removed.
+func TestSchema_DocumentsEveryField(t *testing.T) {
+	fields := make(map[string]bool)
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf(Config{}), reflect.TypeOf(Extend{}), reflect.TypeOf(Tool{}),
+		reflect.TypeOf(Hook{}), reflect.TypeOf(OutputRules{}), reflect.TypeOf(Validation{}),
+		reflect.TypeOf(ValidationRule{}),
+	} {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if strings.Split(field.Tag.Get("yaml"), ",")[0] == "-" {
+				continue
+			}
+			key := typ.Name() + "." + field.Name
+			fields[key] = true
+			if fieldRules[key].Description == "" {
+				t.Errorf("Field %s has no description in fieldRules", key)
+			}
+		}
+	}
+	for key := range fieldRules {
+		if !fields[key] {
+			t.Errorf("fieldRules has an entry for unknown field %s", key)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+
+	if schema.Properties["tools"].Items.Properties["check_command"] == nil {
+		t.Errorf("Expected tools items to describe check_command")
+	}
+
+	hook := schema.Properties["hooks"].AdditionalProperties.(*SchemaNode).AdditionalProperties.(*SchemaNode).Items
+	if !reflect.DeepEqual(hook.Required, []string{"name"}) {
+		t.Errorf("Expected hooks to require a name, got %v", hook.Required)
+	}
+	if hook.AdditionalProperties != false {
+		t.Errorf("Expected unknown hook keys to be rejected")
+	}
+	showOn := hook.Properties["output_rules"].Properties["show_on"]
+	if !reflect.DeepEqual(showOn.Enum, allowedValues("OutputRules.ShowOn")) {
+		t.Errorf("Expected show_on enum to match the validator, got %v", showOn.Enum)
+	}
+	if len(hook.Properties["builtin"].Enum) == 0 {
+		t.Errorf("Expected builtin enum to list the builtin checks")
+	}
+
+	extends := schema.Properties["extends"].Items
+	if len(extends.OneOf) != 2 || extends.OneOf[0].Type != "string" {
+		t.Errorf("Expected extends items to accept a string or an object, got %+v", extends)
+	}
+	for _, name := range []string{"path", "sources", "undefined"} {
+		if _, ok := schema.Properties[name]; ok {
+			t.Errorf("Did not expect internal field %s in the schema", name)
+		}
+	}
+}
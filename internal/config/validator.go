@@ -5,16 +5,27 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/builtin"
+	"github.com/dmux/go-quality-gate/internal/domain"
+	"github.com/dmux/go-quality-gate/internal/shellparse"
 )
 
 // ValidationError represents a configuration validation error
 type ValidationError struct {
-	Field      string
-	Value      string
-	Issue      string
-	Suggestion string
-	Severity   ValidationSeverity
+	// Rule is the stable ID of the check that reported the issue.
+	Rule       string             `json:"rule"`
+	Field      string             `json:"field"`
+	Value      string             `json:"value,omitempty"`
+	Issue      string             `json:"issue"`
+	Suggestion string             `json:"suggestion,omitempty"`
+	Severity   ValidationSeverity `json:"severity"`
+	// Position locates Field in the configuration files, when known.
+	Position Position `json:"position"`
+	// Fix resolves the issue automatically, when it is mechanical.
+	Fix *Fix `json:"fix,omitempty"`
 }
 
 // ValidationSeverity indicates the severity of a validation issue
@@ -39,10 +50,15 @@ func (s ValidationSeverity) String() string {
 	}
 }
 
+// MarshalText encodes the severity as a lower-case name, such as "warning".
+func (s ValidationSeverity) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(s.String())), nil
+}
+
 // ValidationResult holds the result of configuration validation
 type ValidationResult struct {
-	Valid  bool
-	Errors []ValidationError
+	Valid  bool              `json:"valid"`
+	Errors []ValidationError `json:"errors"`
 }
 
 // ConfigValidator validates quality.yml configurations
@@ -76,6 +92,18 @@ func (v *ConfigValidator) Validate() *ValidationResult {
 	// Check for common configuration issues
 	v.validateCommonIssues(result)
 
+	// Apply the custom rules, then the disabled rules and severities, of the
+	// validation section
+	v.validateCustomRules(result)
+	v.applyRuleSettings(result)
+
+	// Locate every finding in the configuration files
+	for i := range result.Errors {
+		if result.Errors[i].Position.IsZero() {
+			result.Errors[i].Position = v.locate(result.Errors[i].Field)
+		}
+	}
+
 	// Set overall validity
 	result.Valid = !v.hasCriticalOrErrorSeverity(result.Errors)
 
@@ -86,6 +114,7 @@ func (v *ConfigValidator) Validate() *ValidationResult {
 func (v *ConfigValidator) validateTools(result *ValidationResult) {
 	if len(v.config.Tools) == 0 {
 		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleToolsEmpty,
 			Field:      "tools",
 			Value:      "empty",
 			Issue:      "No tools configured",
@@ -101,6 +130,7 @@ func (v *ConfigValidator) validateTools(result *ValidationResult) {
 		// Validate tool name
 		if strings.TrimSpace(tool.Name) == "" {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleToolNameEmpty,
 				Field:      fieldPrefix + ".name",
 				Value:      tool.Name,
 				Issue:      "Tool name is empty",
@@ -112,6 +142,7 @@ func (v *ConfigValidator) validateTools(result *ValidationResult) {
 		// Validate check command
 		if strings.TrimSpace(tool.CheckCommand) == "" {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleToolCheckEmpty,
 				Field:      fieldPrefix + ".check_command",
 				Value:      tool.CheckCommand,
 				Issue:      "Check command is empty",
@@ -125,6 +156,7 @@ func (v *ConfigValidator) validateTools(result *ValidationResult) {
 		// Validate install command
 		if strings.TrimSpace(tool.InstallCommand) == "" {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleToolInstallEmpty,
 				Field:      fieldPrefix + ".install_command",
 				Value:      tool.InstallCommand,
 				Issue:      "Install command is empty",
@@ -144,6 +176,7 @@ func (v *ConfigValidator) validateTools(result *ValidationResult) {
 func (v *ConfigValidator) validateHooks(result *ValidationResult) {
 	if len(v.config.Hooks) == 0 {
 		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleHooksEmpty,
 			Field:      "hooks",
 			Value:      "empty",
 			Issue:      "No hooks configured",
@@ -159,6 +192,7 @@ func (v *ConfigValidator) validateHooks(result *ValidationResult) {
 		// Validate hook group name
 		if strings.TrimSpace(hookName) == "" {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleHookGroupNameEmpty,
 				Field:      fieldPrefix,
 				Value:      hookName,
 				Issue:      "Hook group name is empty",
@@ -179,6 +213,7 @@ func (v *ConfigValidator) validateHooks(result *ValidationResult) {
 		// Ensure at least one hook type is configured
 		if !hasAnyHooks {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleHookGroupEmpty,
 				Field:      fieldPrefix,
 				Value:      "no hooks",
 				Issue:      "No hook types configured (pre-commit, pre-push)",
@@ -193,6 +228,7 @@ func (v *ConfigValidator) validateHooks(result *ValidationResult) {
 func (v *ConfigValidator) validateCommands(commands []Hook, fieldPrefix string, result *ValidationResult) {
 	if len(commands) == 0 {
 		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleHookTypeEmpty,
 			Field:      fieldPrefix,
 			Value:      "empty",
 			Issue:      "No commands configured for this hook",
@@ -208,6 +244,7 @@ func (v *ConfigValidator) validateCommands(commands []Hook, fieldPrefix string,
 		// Validate command name
 		if strings.TrimSpace(cmd.Name) == "" {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleHookNameEmpty,
 				Field:      cmdFieldPrefix + ".name",
 				Value:      cmd.Name,
 				Issue:      "Command name is empty",
@@ -216,13 +253,16 @@ func (v *ConfigValidator) validateCommands(commands []Hook, fieldPrefix string,
 			})
 		}
 
-		// Validate main command
-		if strings.TrimSpace(cmd.Command) == "" {
+		// Validate main command or builtin check
+		if cmd.Builtin != "" {
+			v.validateBuiltin(cmd, cmdFieldPrefix, result)
+		} else if strings.TrimSpace(cmd.Command) == "" {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleHookCommandEmpty,
 				Field:      cmdFieldPrefix + ".command",
 				Value:      cmd.Command,
 				Issue:      "Command is empty",
-				Suggestion: "Provide the command to execute",
+				Suggestion: "Provide the command to execute or a builtin check",
 				Severity:   SeverityCritical,
 			})
 		} else {
@@ -239,96 +279,166 @@ func (v *ConfigValidator) validateCommands(commands []Hook, fieldPrefix string,
 	}
 }
 
-// validateCommand validates individual command syntax and security
-func (v *ConfigValidator) validateCommand(command, fieldPath string, result *ValidationResult) {
-	// Check for potentially dangerous commands
-	dangerousPatterns := []string{
-		`rm\s+-rf\s+/`,      // Dangerous rm commands
-		`rm\s+-rf\s+\*`,     // Wildcard deletion
-		`sudo\s+rm`,         // Sudo deletion
-		`>\s*/dev/sd[a-z]`,  // Writing to disk devices
-		`dd\s+.*of=/dev`,    // DD to devices
-		`curl.*\|\s*sh`,     // Piping curl to shell
-		`wget.*\|\s*sh`,     // Piping wget to shell
-		`eval\s+\$\(.*curl`, // Eval with curl
-		`:\(\)\{.*;\}:`,     // Fork bomb pattern
-	}
-
-	for _, pattern := range dangerousPatterns {
-		if matched, _ := regexp.MatchString(pattern, command); matched {
+// validateBuiltin validates a hook that runs a builtin check
+func (v *ConfigValidator) validateBuiltin(cmd Hook, fieldPrefix string, result *ValidationResult) {
+	check, ok := builtin.Lookup(cmd.Builtin)
+	if !ok {
+		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleBuiltinUnknown,
+			Field:      fieldPrefix + ".builtin",
+			Value:      cmd.Builtin,
+			Issue:      fmt.Sprintf("Unknown builtin check '%s'", cmd.Builtin),
+			Suggestion: fmt.Sprintf("Use one of: %s", strings.Join(allowedValues("Hook.Builtin"), ", ")),
+			Severity:   SeverityCritical,
+		})
+	} else if check.Validate != nil {
+		if err := check.Validate(cmd.Options); err != nil {
 			result.Errors = append(result.Errors, ValidationError{
-				Field:      fieldPath,
-				Value:      command,
-				Issue:      "Potentially dangerous command detected",
-				Suggestion: "Review the command for security implications",
-				Severity:   SeverityCritical,
+				Rule:       RuleBuiltinOptions,
+				Field:      fieldPrefix + ".options",
+				Value:      cmd.Builtin,
+				Issue:      fmt.Sprintf("Invalid options for builtin '%s': %v", cmd.Builtin, err),
+				Suggestion: "Fix the hook options as described in the builtin documentation",
+				Severity:   SeverityError,
 			})
-			break
 		}
 	}
 
-	// Check for common command issues
-	v.validateCommandSyntax(command, fieldPath, result)
-}
-
-// validateCommandSyntax checks for common command syntax issues
-func (v *ConfigValidator) validateCommandSyntax(command, fieldPath string, result *ValidationResult) {
-	// Check for unmatched quotes
-	singleQuotes := strings.Count(command, "'")
-	doubleQuotes := strings.Count(command, "\"")
-
-	if singleQuotes%2 != 0 {
+	if strings.TrimSpace(cmd.Command) != "" {
 		result.Errors = append(result.Errors, ValidationError{
-			Field:      fieldPath,
-			Value:      command,
-			Issue:      "Unmatched single quotes in command",
-			Suggestion: "Ensure all single quotes are properly paired",
+			Rule:       RuleCommandAndBuiltin,
+			Field:      fieldPrefix + ".command",
+			Value:      cmd.Command,
+			Issue:      "Hook defines both a command and a builtin check",
+			Suggestion: "Remove either 'command' or 'builtin'",
 			Severity:   SeverityError,
 		})
 	}
+}
+
+// dangerousPatterns flag dangerous commands that cannot be parsed
+var dangerousPatterns = []string{
+	`rm\s+-rf\s+/`,      // Dangerous rm commands
+	`rm\s+-rf\s+\*`,     // Wildcard deletion
+	`sudo\s+rm`,         // Sudo deletion
+	`>\s*/dev/sd[a-z]`,  // Writing to disk devices
+	`dd\s+.*of=/dev`,    // DD to devices
+	`curl.*\|\s*sh`,     // Piping curl to shell
+	`wget.*\|\s*sh`,     // Piping wget to shell
+	`eval\s+\$\(.*curl`, // Eval with curl
+	`:\(\)\{.*;\}:`,     // Fork bomb pattern
+}
 
-	if doubleQuotes%2 != 0 {
+// validateCommand validates individual command syntax and security
+func (v *ConfigValidator) validateCommand(command, fieldPath string, result *ValidationResult) {
+	script, err := shellparse.Parse(command)
+
+	// Check for potentially dangerous operations, structurally when the
+	// command parses
+	reason := ""
+	if script != nil {
+		reason = dangerousOperation(script)
+	} else {
+		for _, pattern := range dangerousPatterns {
+			if matched, _ := regexp.MatchString(pattern, command); matched {
+				reason = "matches " + pattern
+				break
+			}
+		}
+	}
+	if reason != "" {
 		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleDangerousCommand,
 			Field:      fieldPath,
 			Value:      command,
-			Issue:      "Unmatched double quotes in command",
-			Suggestion: "Ensure all double quotes are properly paired",
-			Severity:   SeverityError,
+			Issue:      "Potentially dangerous command detected: " + reason,
+			Suggestion: "Review the command for security implications",
+			Severity:   SeverityCritical,
 		})
 	}
 
-	// Check for common typos in popular tools
-	v.validateToolNames(command, fieldPath, result)
-}
-
-// validateToolNames checks for common typos in tool names
-func (v *ConfigValidator) validateToolNames(command, fieldPath string, result *ValidationResult) {
-	commonTypos := map[string]string{
-		"prettier":      "prettier",
-		"pretier":       "prettier",
-		"pretter":       "prettier",
-		"eslint":        "eslint",
-		"esslint":       "eslint",
-		"eslinter":      "eslint",
-		"pytest":        "pytest",
-		"py.test":       "pytest",
-		"ruf":           "ruff",
-		"ruff ":         "ruff",
-		"gofmt ":        "gofmt",
-		"go fmt":        "gofmt",
-		"golangci":      "golangci-lint",
-		"golangci-lint": "golangci-lint",
-	}
-
-	cmdLower := strings.ToLower(command)
-	for typo, correct := range commonTypos {
-		if strings.Contains(cmdLower, typo) && typo != correct {
+	// Check for common command issues
+	v.validateCommandSyntax(command, err, fieldPath, result)
+	if script != nil {
+		v.validateToolNames(script, command, fieldPath, result)
+	}
+}
+
+// validateCommandSyntax reports the shell syntax error of a command
+func (v *ConfigValidator) validateCommandSyntax(command string, err error, fieldPath string, result *ValidationResult) {
+	if err == nil {
+		return
+	}
+	result.Errors = append(result.Errors, ValidationError{
+		Rule:       RuleShellSyntax,
+		Field:      fieldPath,
+		Value:      command,
+		Issue:      syntaxErrorIssue(command, err),
+		Suggestion: "Fix the command so that it parses as a bash command",
+		Severity:   SeverityError,
+	})
+}
+
+// knownTools are the tools hook commands are expected to configure, with
+// the configuration quality-gate --init generates for them
+var knownTools = map[string]Tool{
+	"prettier":      {Name: "Prettier (Code Formatter)", CheckCommand: "npx prettier --version", InstallCommand: "npm install --save-dev prettier"},
+	"eslint":        {Name: "ESLint (Linter)", CheckCommand: "npx eslint --version", InstallCommand: "npm install --save-dev eslint"},
+	"ruff":          {Name: "Ruff (Python Linter/Formatter)", CheckCommand: "ruff --version", InstallCommand: "pip install ruff"},
+	"black":         {Name: "Black (Python Formatter)", CheckCommand: "black --version", InstallCommand: "pip install black"},
+	"pytest":        {Name: "Pytest", CheckCommand: "pytest --version", InstallCommand: "pip install pytest"},
+	"gofmt":         {Name: "Gofmt", CheckCommand: "gofmt -h", InstallCommand: "# gofmt is included with Go installation"},
+	"golangci-lint": {Name: "Golangci-lint", CheckCommand: "golangci-lint --version", InstallCommand: "go install github.com/golangci/golangci-lint/cmd/golangci-lint@latest"},
+	"rustfmt":       {Name: "Rustfmt", CheckCommand: "rustfmt --version", InstallCommand: "rustup component add rustfmt"},
+	"cargo":         {Name: "Cargo", CheckCommand: "cargo --version", InstallCommand: "# Install Rust with rustup: https://rustup.rs"},
+	"php-cs-fixer":  {Name: "PHP CS Fixer", CheckCommand: "php-cs-fixer --version", InstallCommand: "composer global require friendsofphp/php-cs-fixer"},
+	"phpstan":       {Name: "PHPStan", CheckCommand: "phpstan --version", InstallCommand: "composer require --dev phpstan/phpstan"},
+	"gitleaks":      {Name: "Gitleaks", CheckCommand: "gitleaks version", InstallCommand: "go install github.com/gitleaks/gitleaks/v8@latest"},
+}
+
+// KnownTools returns the configuration of the known tools the commands run,
+// in order of first use.
+func KnownTools(commands ...string) []Tool {
+	var tools []Tool
+	seen := make(map[string]bool)
+	for _, command := range commands {
+		script, err := shellparse.Parse(command)
+		if err != nil {
+			continue
+		}
+		for _, call := range commandCalls(script) {
+			if tool, ok := knownTools[call.Name]; ok && !seen[call.Name] {
+				seen[call.Name] = true
+				tools = append(tools, tool)
+			}
+		}
+	}
+	return tools
+}
+
+// commonTypos maps misspelled tool names to the tool
+var commonTypos = map[string]string{
+	"pretier":  "prettier",
+	"pretter":  "prettier",
+	"esslint":  "eslint",
+	"eslinter": "eslint",
+	"py.test":  "pytest",
+	"ruf":      "ruff",
+	"golangci": "golangci-lint",
+}
+
+// validateToolNames checks for common typos in the commands run
+func (v *ConfigValidator) validateToolNames(script *shellparse.Script, command, fieldPath string, result *ValidationResult) {
+	for _, call := range commandCalls(script) {
+		if correct, ok := commonTypos[call.Name]; ok {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleToolTypo,
 				Field:      fieldPath,
 				Value:      command,
-				Issue:      fmt.Sprintf("Possible typo: '%s' should be '%s'", typo, correct),
-				Suggestion: fmt.Sprintf("Check if you meant '%s' instead of '%s'", correct, typo),
+				Issue:      fmt.Sprintf("Possible typo: '%s' should be '%s'", call.Name, correct),
+				Suggestion: fmt.Sprintf("Check if you meant '%s' instead of '%s'", correct, call.Name),
 				Severity:   SeverityWarning,
+				Fix:        renameCommandFix(call.Name, correct),
 			})
 		}
 	}
@@ -337,33 +447,70 @@ func (v *ConfigValidator) validateToolNames(command, fieldPath string, result *V
 // validateOutputRules validates output rule configurations
 func (v *ConfigValidator) validateOutputRules(rules OutputRules, fieldPath string, result *ValidationResult) {
 	if rules.ShowOn != "" {
-		validShowOnValues := []string{"always", "failure", "success"}
+		validShowOnValues := allowedValues("OutputRules.ShowOn")
 		if !contains(validShowOnValues, rules.ShowOn) {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleShowOnInvalid,
 				Field:      fieldPath + ".show_on",
 				Value:      rules.ShowOn,
 				Issue:      "Invalid show_on value",
-				Suggestion: "Use 'always', 'failure', or 'success'",
+				Suggestion: "Use " + quotedList(validShowOnValues),
 				Severity:   SeverityError,
+				Fix:        showOnFix(rules.ShowOn),
 			})
 		}
 	}
 
+	if rules.MaxLines < 0 {
+		result.Errors = append(result.Errors, v.outputRuleError(fieldPath+".max_lines", fmt.Sprint(rules.MaxLines),
+			"max_lines cannot be negative", "Use 0 to show all lines", SeverityError))
+	}
+	if rules.Truncate != "" {
+		if validTruncate := allowedValues("OutputRules.Truncate"); !contains(validTruncate, rules.Truncate) {
+			result.Errors = append(result.Errors, v.outputRuleError(fieldPath+".truncate", rules.Truncate,
+				"Invalid truncate value", "Use "+quotedList(validTruncate), SeverityError))
+		} else if rules.MaxLines == 0 {
+			result.Errors = append(result.Errors, v.outputRuleError(fieldPath+".truncate", rules.Truncate,
+				"truncate has no effect without max_lines", "Set max_lines to the number of lines to keep", SeverityWarning))
+		}
+	}
+	for _, pattern := range []struct{ key, value string }{{"filter", rules.Filter}, {"strip", rules.Strip}} {
+		if _, err := regexp.Compile(pattern.value); err != nil {
+			result.Errors = append(result.Errors, v.outputRuleError(fieldPath+"."+pattern.key, pattern.value,
+				fmt.Sprintf("Invalid %s pattern: %v", pattern.key, err), "Use a Go regular expression matched against every output line", SeverityError))
+		}
+	}
+
 	// Validate message templates
 	if rules.OnFailureMessage != "" {
 		v.validateMessageTemplate(rules.OnFailureMessage, fieldPath+".on_failure_message", result)
 	}
+	if rules.OnSuccessMessage != "" {
+		v.validateMessageTemplate(rules.OnSuccessMessage, fieldPath+".on_success_message", result)
+	}
+}
+
+func (v *ConfigValidator) outputRuleError(field, value, issue, suggestion string, severity ValidationSeverity) ValidationError {
+	return ValidationError{
+		Rule:       RuleOutputRuleInvalid,
+		Field:      field,
+		Value:      value,
+		Issue:      issue,
+		Suggestion: suggestion,
+		Severity:   severity,
+	}
 }
 
-// validateMessageTemplate validates message template syntax
+// validateMessageTemplate validates that a message is a Go template using
+// only the fields of domain.MessageData
 func (v *ConfigValidator) validateMessageTemplate(message, fieldPath string, result *ValidationResult) {
-	// Check for template variable syntax (basic validation)
-	if strings.Contains(message, "{{") && !strings.Contains(message, "}}") {
+	if err := domain.CheckMessage(message); err != nil {
 		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleMessageTemplate,
 			Field:      fieldPath,
 			Value:      message,
-			Issue:      "Unclosed template variable in message",
-			Suggestion: "Ensure all {{ variables }} are properly closed",
+			Issue:      "Invalid message template: " + err.Error(),
+			Suggestion: "Use fields such as {{.Name}}, {{.ExitCode}}, {{.Files}}, {{.OutputTail}} and {{.FixCommand}}",
 			Severity:   SeverityWarning,
 		})
 	}
@@ -371,22 +518,21 @@ func (v *ConfigValidator) validateMessageTemplate(message, fieldPath string, res
 
 // validateToolAvailability checks if tools are actually available
 func (v *ConfigValidator) validateToolAvailability(tool Tool, fieldPrefix string, result *ValidationResult) {
-	// Extract command name from check command
-	parts := strings.Fields(tool.CheckCommand)
-	if len(parts) == 0 {
+	// Find the command run by the check command
+	script, err := shellparse.Parse(tool.CheckCommand)
+	if err != nil {
 		return
 	}
-
-	cmdName := parts[0]
-
-	// Skip shell built-ins and complex commands
-	if strings.Contains(cmdName, "/") || strings.Contains(cmdName, "|") || strings.Contains(cmdName, "&") {
+	calls := commandCalls(script)
+	if len(calls) == 0 {
 		return
 	}
+	cmdName := calls[0].Name
 
 	// Check if command exists
 	if _, err := exec.LookPath(cmdName); err != nil {
 		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleToolNotFound,
 			Field:      fieldPrefix + ".check_command",
 			Value:      tool.CheckCommand,
 			Issue:      fmt.Sprintf("Tool '%s' not found in PATH", cmdName),
@@ -398,13 +544,13 @@ func (v *ConfigValidator) validateToolAvailability(tool Tool, fieldPrefix string
 
 // validateToolReferences ensures commands reference existing tools
 func (v *ConfigValidator) validateToolReferences(result *ValidationResult) {
-	// Create a map of available tools by checking their command names
+	// Collect the commands run by the check commands of the tools
 	availableTools := make(map[string]bool)
 	for _, tool := range v.config.Tools {
-		parts := strings.Fields(tool.CheckCommand)
-		if len(parts) > 0 {
-			cmdName := parts[0]
-			availableTools[cmdName] = true
+		if script, err := shellparse.Parse(tool.CheckCommand); err == nil {
+			for _, call := range commandCalls(script) {
+				availableTools[call.Name] = true
+			}
 		}
 	}
 
@@ -420,33 +566,29 @@ func (v *ConfigValidator) validateToolReferences(result *ValidationResult) {
 
 // checkCommandToolReferences checks if commands reference configured tools
 func (v *ConfigValidator) checkCommandToolReferences(commands []Hook, availableTools map[string]bool, fieldPrefix string, result *ValidationResult) {
-	commonTools := []string{
-		"prettier", "eslint", "ruff", "black", "pytest", "gofmt", "golangci-lint",
-		"rustfmt", "cargo", "php-cs-fixer", "phpstan", "gitleaks",
-	}
-
 	for i, cmd := range commands {
 		cmdFieldPrefix := fmt.Sprintf("%s[%d]", fieldPrefix, i)
 
-		// Extract command name
-		parts := strings.Fields(cmd.Command)
-		if len(parts) == 0 {
+		// Check every command of pipelines, lists and substitutions
+		script, err := shellparse.Parse(cmd.Command)
+		if err != nil {
 			continue
 		}
-
-		cmdName := parts[0]
-		if strings.HasPrefix(cmdName, "npx") && len(parts) > 1 {
-			cmdName = parts[1] // For npx commands, get the actual tool name
-		}
-
-		// Check if it's a common tool that should be configured
-		if contains(commonTools, cmdName) && !availableTools[cmdName] {
+		reported := make(map[string]bool)
+		for _, call := range commandCalls(script) {
+			cmdName := call.Name
+			if _, known := knownTools[cmdName]; !known || availableTools[cmdName] || reported[cmdName] {
+				continue
+			}
+			reported[cmdName] = true
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleToolNotConfigured,
 				Field:      cmdFieldPrefix + ".command",
 				Value:      cmd.Command,
 				Issue:      fmt.Sprintf("Command uses '%s' but no tool configuration found", cmdName),
 				Suggestion: fmt.Sprintf("Add a tool configuration for '%s' in the tools section", cmdName),
 				Severity:   SeverityWarning,
+				Fix:        addToolFix(cmdName),
 			})
 		}
 	}
@@ -463,6 +605,13 @@ func (v *ConfigValidator) validateCommonIssues(result *ValidationResult) {
 	// Check for missing essential hooks
 	v.validateEssentialHooks(result)
 
+	// Check the minimum quality-gate version and the configuration version
+	v.validateMinVersion(result)
+	v.validateVersion(result)
+
+	// Check for references to undefined environment variables
+	v.validateVariables(result)
+
 	// Check file permissions and existence
 	v.validateFileSystem(result)
 }
@@ -473,6 +622,7 @@ func (v *ConfigValidator) validateDuplicateToolNames(result *ValidationResult) {
 	for i, tool := range v.config.Tools {
 		if prevIndex, exists := seen[tool.Name]; exists {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleDuplicateTool,
 				Field:      fmt.Sprintf("tools[%d].name", i),
 				Value:      tool.Name,
 				Issue:      fmt.Sprintf("Duplicate tool name (also defined at tools[%d])", prevIndex),
@@ -490,47 +640,125 @@ func (v *ConfigValidator) validateDuplicateHookGroups(result *ValidationResult)
 	// This validation could be extended for other duplicate checks
 }
 
+// detectsSecrets reports whether a hook scans for secrets, with the secrets
+// builtin or gitleaks.
+func detectsSecrets(hook Hook) bool {
+	if hook.Builtin == "secrets" {
+		return true
+	}
+	script, err := shellparse.Parse(hook.Command)
+	return err == nil && runsAny(script, []string{"gitleaks"}) != ""
+}
+
 // validateEssentialHooks suggests essential hooks that might be missing
 func (v *ConfigValidator) validateEssentialHooks(result *ValidationResult) {
 	hasSecurityHooks := false
 
-	for hookName := range v.config.Hooks {
+	for hookName, hookTypes := range v.config.Hooks {
 		if strings.Contains(strings.ToLower(hookName), "security") {
 			hasSecurityHooks = true
 			break
 		}
+		// Secret scanning counts whatever the group is named
+		for _, hooks := range hookTypes {
+			for _, hook := range hooks {
+				hasSecurityHooks = hasSecurityHooks || detectsSecrets(hook)
+			}
+		}
 	}
 
 	if !hasSecurityHooks {
 		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleSecurityHooksMissing,
 			Field:      "hooks",
 			Value:      "missing security",
 			Issue:      "No security hooks configured",
 			Suggestion: "Consider adding a security hook group with tools like gitleaks for secret detection",
 			Severity:   SeverityWarning,
+			Fix:        securityHooksFix,
+		})
+	}
+}
+
+// validateMinVersion checks that min_version is a valid semantic version
+func (v *ConfigValidator) validateMinVersion(result *ValidationResult) {
+	if v.config.MinVersion == "" {
+		return
+	}
+	if _, ok := parseVersion(v.config.MinVersion); !ok {
+		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleMinVersionInvalid,
+			Field:      "min_version",
+			Value:      v.config.MinVersion,
+			Issue:      "min_version is not a valid version",
+			Suggestion: "Use a semantic version such as '1.2.0'",
+			Severity:   SeverityError,
+		})
+	}
+}
+
+// validateVersion warns about configuration files of an older version.
+// Configurations built in code have no version and are not checked.
+func (v *ConfigValidator) validateVersion(result *ValidationResult) {
+	if v.config.Version == 0 || v.config.Version >= CurrentVersion {
+		return
+	}
+	result.Errors = append(result.Errors, ValidationError{
+		Rule:       RuleVersionOutdated,
+		Field:      "version",
+		Value:      strconv.Itoa(v.config.Version),
+		Issue:      fmt.Sprintf("Configuration version %d is outdated; the current version is %d", v.config.Version, CurrentVersion),
+		Suggestion: "Run 'quality-gate migrate' to upgrade the file",
+		Severity:   SeverityWarning,
+		Fix:        migrateFix(v.config.Version),
+	})
+}
+
+// validateVariables warns about ${VAR} references to unset variables without
+// default that no env entry sets
+func (v *ConfigValidator) validateVariables(result *ValidationResult) {
+	for _, variable := range v.config.Undefined {
+		if _, ok := v.config.Env[variable.Name]; ok {
+			continue
+		}
+		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleUndefinedVariable,
+			Field:      variable.Field,
+			Value:      "${" + variable.Name + "}",
+			Issue:      fmt.Sprintf("Environment variable %s is not set and has no default; it is left for the shell, which expands it to an empty string unless it is set when the hook runs", variable.Name),
+			Suggestion: fmt.Sprintf("Set %s or provide a default: ${%s:-value}", variable.Name, variable.Name),
+			Severity:   SeverityWarning,
+			Position:   variable.Position,
 		})
 	}
 }
 
 // validateFileSystem checks file system related issues
 func (v *ConfigValidator) validateFileSystem(result *ValidationResult) {
-	// Check if quality.yml is readable
-	if info, err := os.Stat("quality.yml"); err != nil {
+	path := v.config.Path
+	if path == "" {
+		path = "quality.yml"
+	}
+
+	// Check if the configuration file is readable
+	if info, err := os.Stat(path); err != nil {
 		result.Errors = append(result.Errors, ValidationError{
+			Rule:       RuleConfigFileAccess,
 			Field:      "file",
-			Value:      "quality.yml",
-			Issue:      "Cannot access quality.yml file",
-			Suggestion: "Ensure quality.yml exists and is readable",
+			Value:      path,
+			Issue:      fmt.Sprintf("Cannot access %s file", path),
+			Suggestion: fmt.Sprintf("Ensure %s exists and is readable", path),
 			Severity:   SeverityCritical,
 		})
 	} else {
 		// Check file permissions
 		if info.Mode().Perm()&0044 == 0 {
 			result.Errors = append(result.Errors, ValidationError{
+				Rule:       RuleConfigFileAccess,
 				Field:      "file",
-				Value:      "quality.yml",
-				Issue:      "quality.yml is not readable",
-				Suggestion: "Fix file permissions: chmod 644 quality.yml",
+				Value:      path,
+				Issue:      fmt.Sprintf("%s is not readable", path),
+				Suggestion: fmt.Sprintf("Fix file permissions: chmod 644 %s", path),
 				Severity:   SeverityError,
 			})
 		}
@@ -539,6 +767,68 @@ func (v *ConfigValidator) validateFileSystem(result *ValidationResult) {
 
 // Helper functions
 
+var (
+	toolFieldPattern = regexp.MustCompile(`^tools\[(\d+)\](?:\.(.+))?$`)
+	hookFieldPattern = regexp.MustCompile(`^hooks\.(.+)\.([^.\[]+)\[(\d+)\](?:\.(.+))?$`)
+)
+
+// locate returns the position of a validation field path such as
+// "tools[2].check_command" or "hooks.go.pre-commit[0].output_rules.show_on",
+// falling back to the enclosing entry and then to the configuration file.
+func (v *ConfigValidator) locate(field string) Position {
+	if m := toolFieldPattern.FindStringSubmatch(field); m != nil {
+		var i int
+		fmt.Sscanf(m[1], "%d", &i)
+		if i < len(v.config.Tools) {
+			return entryPosition(v.config.Tools[i].Positions, m[2], v.config.Path)
+		}
+	}
+	if m := hookFieldPattern.FindStringSubmatch(field); m != nil {
+		var i int
+		fmt.Sscanf(m[3], "%d", &i)
+		if hooks := v.config.Hooks[m[1]][m[2]]; i < len(hooks) {
+			return entryPosition(hooks[i].Positions, m[4], v.config.Path)
+		}
+	}
+
+	for key := field; key != ""; {
+		if pos, ok := v.config.Positions[key]; ok {
+			return pos
+		}
+		i := strings.LastIndexAny(key, ".[")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return Position{File: v.config.Path}
+}
+
+// entryPosition returns the position of a key of an entry, or of the entry.
+func entryPosition(positions Positions, key, file string) Position {
+	for ; key != ""; key = key[:max(strings.LastIndex(key, "."), 0)] {
+		if pos, ok := positions[key]; ok {
+			return pos
+		}
+	}
+	if pos, ok := positions[""]; ok {
+		return pos
+	}
+	return Position{File: file}
+}
+
+// quotedList formats values as 'a', 'b', or 'c'.
+func quotedList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + value + "'"
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", or " + quoted[len(quoted)-1]
+}
+
 func (v *ConfigValidator) hasCriticalOrErrorSeverity(errors []ValidationError) bool {
 	for _, err := range errors {
 		if err.Severity == SeverityCritical || err.Severity == SeverityError {
@@ -564,7 +854,11 @@ func (r *ValidationResult) GetFormattedErrors() string {
 	}
 
 	var lines []string
-	lines = append(lines, fmt.Sprintf("❌ Found %d validation issues:", len(r.Errors)))
+	if r.onlyWarnings() {
+		lines = append(lines, fmt.Sprintf("⚠️  Found %d validation warning(s):", len(r.Errors)))
+	} else {
+		lines = append(lines, fmt.Sprintf("❌ Found %d validation issues:", len(r.Errors)))
+	}
 
 	for _, err := range r.Errors {
 		icon := "⚠️"
@@ -574,7 +868,15 @@ func (r *ValidationResult) GetFormattedErrors() string {
 			icon = "🚨"
 		}
 
-		lines = append(lines, fmt.Sprintf("  %s [%s] %s: %s", icon, err.Severity, err.Field, err.Issue))
+		location := err.Field
+		if err.Position.Line > 0 {
+			location = fmt.Sprintf("%s (%s)", err.Field, err.Position)
+		}
+		label := err.Severity.String()
+		if err.Rule != "" {
+			label += " " + err.Rule
+		}
+		lines = append(lines, fmt.Sprintf("  %s [%s] %s: %s", icon, label, location, err.Issue))
 		if err.Suggestion != "" {
 			lines = append(lines, fmt.Sprintf("     💡 %s", err.Suggestion))
 		}
@@ -583,6 +885,22 @@ func (r *ValidationResult) GetFormattedErrors() string {
 	return strings.Join(lines, "\n")
 }
 
+// onlyWarnings reports whether every issue is a warning.
+func (r *ValidationResult) onlyWarnings() bool {
+	for _, err := range r.Errors {
+		if err.Severity != SeverityWarning {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed reports whether the configuration fails validation: on errors and
+// critical issues, and also on warnings when strict.
+func (r *ValidationResult) Failed(strict bool) bool {
+	return !r.Valid || (strict && len(r.Errors) > 0)
+}
+
 // GetErrorsBySeverity returns errors grouped by severity
 func (r *ValidationResult) GetErrorsBySeverity() map[ValidationSeverity][]ValidationError {
 	result := make(map[ValidationSeverity][]ValidationError)
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -277,6 +279,60 @@ func TestConfigValidator_ValidateTools(t *testing.T) {
 	})
 }
 
+func TestConfigValidator_ValidateBuiltins(t *testing.T) {
+	config := &Config{
+		Hooks: map[string]map[string][]Hook{
+			"hygiene": {
+				"pre-commit": []Hook{
+					{Name: "Whitespace", Builtin: "trailing-whitespace"},
+					{Name: "Unknown", Builtin: "no-such-check"},
+					{Name: "Both", Builtin: "end-of-file", Command: "echo"},
+				},
+			},
+		},
+	}
+
+	validator := NewConfigValidator(config)
+	result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
+	validator.validateHooks(result)
+
+	fields := make(map[string]ValidationSeverity)
+	for _, err := range result.Errors {
+		fields[err.Field] = err.Severity
+	}
+
+	if _, ok := fields["hooks.hygiene.pre-commit[0].command"]; ok {
+		t.Errorf("Did not expect builtin hook without command to be reported")
+	}
+	if fields["hooks.hygiene.pre-commit[1].builtin"] != SeverityCritical {
+		t.Errorf("Expected critical error for unknown builtin, got %v", result.Errors)
+	}
+	if fields["hooks.hygiene.pre-commit[2].command"] != SeverityError {
+		t.Errorf("Expected error for hook with both command and builtin, got %v", result.Errors)
+	}
+}
+
+func TestConfigValidator_ValidateVariables(t *testing.T) {
+	config := &Config{
+		Undefined: []UndefinedVariable{{Name: "API_URL", Field: "hooks.api.pre-push[0].command", Position: Position{File: "quality.yml", Line: 7, Column: 18}}},
+	}
+
+	validator := NewConfigValidator(config)
+	result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
+	validator.validateVariables(result)
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 warning, got %v", result.Errors)
+	}
+	err := result.Errors[0]
+	if err.Severity != SeverityWarning || err.Field != "hooks.api.pre-push[0].command" || err.Value != "${API_URL}" || err.Position.Line != 7 {
+		t.Errorf("Unexpected warning: %+v", err)
+	}
+	if !strings.Contains(err.Suggestion, "${API_URL:-value}") {
+		t.Errorf("Expected suggestion to mention a default, got %q", err.Suggestion)
+	}
+}
+
 func TestConfigValidator_ValidateCommand(t *testing.T) {
 	config := &Config{}
 	validator := NewConfigValidator(config)
@@ -327,6 +383,129 @@ func TestConfigValidator_ValidateCommand(t *testing.T) {
 			command:     `echo "hello world"`,
 			expectError: false,
 		},
+		{
+			name:        "ApostropheInDoubleQuotes",
+			command:     `echo "it's fine"`,
+			expectError: false,
+		},
+		{
+			name:        "PatternInComment",
+			command:     "go test ./... # never rm -rf /",
+			expectError: false,
+		},
+		{
+			name:        "RmOfBuildDirectory",
+			command:     "rm -rf ./build && go build ./...",
+			expectError: false,
+		},
+		{
+			name:        "DdToDevNull",
+			command:     "dd if=/dev/zero of=/dev/null count=1",
+			expectError: false,
+		},
+		{
+			name:        "RmInChain",
+			command:     "go test ./... && sudo rm -rf /tmp/cache",
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "RmOfUnguardedVariable",
+			command:     `rm -rf "$BUILD_DIR/"`,
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "CurlPipeToSudoShell",
+			command:     "curl -fsSL https://example.com/install.sh | sudo bash -s",
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "CurlPipeToPython",
+			command:     "curl -fsSL https://example.com/get.py | python3",
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "CurlPipeToBashWithArguments",
+			command:     "curl -fsSL https://example.com/install.sh | bash -s -- --yes",
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "CurlPipeToPythonModule",
+			command:     "curl -s $URL | python3 -m json.tool",
+			expectError: false,
+		},
+		{
+			name:        "CurlPipeToPerlOneLiner",
+			command:     `curl -s $URL | perl -ne 'print if /version/'`,
+			expectError: false,
+		},
+		{
+			name:        "CurlPipeToNodeEval",
+			command:     `curl -s $URL | node -e 'process.stdin.pipe(process.stdout)'`,
+			expectError: false,
+		},
+		{
+			name:        "CurlPipeToPythonScript",
+			command:     "curl -s $URL | python3 scripts/check.py",
+			expectError: false,
+		},
+		{
+			name:        "RmInBashInlineScript",
+			command:     `bash -c "rm -rf /"`,
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "RmInBashInlineScriptWithOptions",
+			command:     `bash -o pipefail -ec 'cd build && rm -rf ~'`,
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "RmInShInlineScript",
+			command:     `sh -c 'rm -rf /'`,
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "RmInEval",
+			command:     `eval "rm -rf /"`,
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "SafeBashInlineScript",
+			command:     `bash -c "rm -rf ./build"`,
+			expectError: false,
+		},
+		{
+			name:        "ShellRunningDownload",
+			command:     `sh -c "$(wget -qO- https://example.com/install.sh)"`,
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "ForkBomb",
+			command:     ":(){ :|:& };:",
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "WriteToDisk",
+			command:     "cat image.iso > /dev/sda",
+			expectError: true,
+			severity:    SeverityCritical,
+		},
+		{
+			name:        "UnclosedIf",
+			command:     "if [ -f go.mod ]; then go vet ./...",
+			expectError: true,
+			severity:    SeverityError,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -356,6 +535,63 @@ func TestConfigValidator_ValidateCommand(t *testing.T) {
 	}
 }
 
+func TestConfigValidator_ValidateCommandIssues(t *testing.T) {
+	validator := NewConfigValidator(&Config{})
+
+	result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
+	validator.validateCommand("echo 'hello world", "test.command", result)
+	if len(result.Errors) != 1 || result.Errors[0].Rule != RuleShellSyntax {
+		t.Fatalf("Expected one shell-syntax error, got %v", result.Errors)
+	}
+	if expected := "Shell syntax error at column 6: unterminated single quote"; result.Errors[0].Issue != expected {
+		t.Errorf("Expected issue %q, got %q", expected, result.Errors[0].Issue)
+	}
+
+	result = &ValidationResult{Valid: true, Errors: []ValidationError{}}
+	validator.validateCommand("make lint && sudo rm -rf /", "test.command", result)
+	if len(result.Errors) != 1 || result.Errors[0].Rule != RuleDangerousCommand {
+		t.Fatalf("Expected one dangerous-command error, got %v", result.Errors)
+	}
+	if expected := "Potentially dangerous command detected: runs rm with sudo"; result.Errors[0].Issue != expected {
+		t.Errorf("Expected issue %q, got %q", expected, result.Errors[0].Issue)
+	}
+
+	result = &ValidationResult{Valid: true, Errors: []ValidationError{}}
+	validator.validateCommand("npx pretier --check . | tee out.log", "test.command", result)
+	if len(result.Errors) != 1 || result.Errors[0].Rule != RuleToolTypo {
+		t.Fatalf("Expected one tool-typo warning, got %v", result.Errors)
+	}
+}
+
+func TestConfigValidator_ToolReferences(t *testing.T) {
+	config := &Config{
+		Tools: []Tool{
+			{Name: "Go", CheckCommand: "go version", InstallCommand: "true"},
+		},
+		Hooks: Hooks{
+			"go": {
+				"pre-commit": {
+					{Name: "Format", Command: `test -z "$(gofmt -l .)" && npx eslint . || exit 1`},
+					{Name: "Vet", Command: "go vet ./... 2>&1 | tee vet.log"},
+				},
+			},
+		},
+	}
+
+	result := NewConfigValidator(config).Validate()
+
+	var unconfigured []string
+	for _, err := range result.Errors {
+		if err.Rule == RuleToolNotConfigured {
+			unconfigured = append(unconfigured, err.Issue)
+		}
+	}
+	issues := strings.Join(unconfigured, "\n")
+	if len(unconfigured) != 2 || !strings.Contains(issues, "'gofmt'") || !strings.Contains(issues, "'eslint'") {
+		t.Errorf("Expected gofmt and eslint to be reported as not configured, got %v", result.Errors)
+	}
+}
+
 func TestConfigValidator_ValidateOutputRules(t *testing.T) {
 	config := &Config{}
 	validator := NewConfigValidator(config)
@@ -401,7 +637,7 @@ func TestConfigValidator_ValidateOutputRules(t *testing.T) {
 		// Should have warning for unclosed template variable
 		found := false
 		for _, err := range result.Errors {
-			if strings.Contains(err.Issue, "Unclosed template variable") && err.Severity == SeverityWarning {
+			if strings.Contains(err.Issue, "Invalid message template") && err.Severity == SeverityWarning {
 				found = true
 				break
 			}
@@ -411,6 +647,204 @@ func TestConfigValidator_ValidateOutputRules(t *testing.T) {
 			t.Errorf("Expected warning for unclosed template variable")
 		}
 	})
+
+	t.Run("OutputLimits", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			rules  OutputRules
+			issues []string
+		}{
+			{"Valid", OutputRules{ShowOn: "success", MaxLines: 20, Truncate: "tail", Filter: "^FAIL", Strip: `\x1b\[[0-9;]*m`, OnSuccessMessage: "{{.Name}} passed"}, nil},
+			{"NegativeMaxLines", OutputRules{MaxLines: -1}, []string{"max_lines cannot be negative"}},
+			{"InvalidTruncate", OutputRules{MaxLines: 5, Truncate: "end"}, []string{"Invalid truncate value"}},
+			{"TruncateWithoutMaxLines", OutputRules{Truncate: "tail"}, []string{"truncate has no effect without max_lines"}},
+			{"InvalidPatterns", OutputRules{Filter: "(", Strip: "[a-"}, []string{
+				"Invalid filter pattern: error parsing regexp: missing closing ): `(`",
+				"Invalid strip pattern: error parsing regexp: missing closing ]: `[a-`",
+			}},
+			{"SuccessMessage", OutputRules{OnSuccessMessage: "{{.Nam}}"}, []string{"Invalid message template: 1:2: can't evaluate field Nam"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
+				validator.validateOutputRules(tt.rules, "test", result)
+
+				var issues []string
+				for _, err := range result.Errors {
+					issues = append(issues, err.Issue)
+				}
+				if !reflect.DeepEqual(issues, tt.issues) {
+					t.Errorf("Expected issues %q, got %q", tt.issues, issues)
+				}
+			})
+		}
+	})
+
+	t.Run("MessageTemplates", func(t *testing.T) {
+		tests := []struct {
+			message string
+			issue   string
+		}{
+			{"Run `{{.FixCommand}}` to fix {{len .Files}} files", ""},
+			{"{{.Name}} exited with {{.ExitCode}} after {{.Duration}}:\n{{.OutputTail}}", ""},
+			{"Check {{join .Files \", \"}}", ""},
+			{"Plain message with {braces}", ""},
+			{"Run {{.Fix}}", "Invalid message template: 1:6: can't evaluate field Fix"},
+			{"{{shout .Name}}", `Invalid message template: 1: function "shout" not defined`},
+		}
+
+		for _, tt := range tests {
+			result := &ValidationResult{Valid: true, Errors: []ValidationError{}}
+			validator.validateOutputRules(OutputRules{OnFailureMessage: tt.message}, "test", result)
+
+			var issues []string
+			for _, err := range result.Errors {
+				issues = append(issues, err.Issue)
+			}
+			if tt.issue == "" && len(issues) > 0 {
+				t.Errorf("%q: expected no issues, got %v", tt.message, issues)
+			}
+			if tt.issue != "" && (len(issues) != 1 || issues[0] != tt.issue) {
+				t.Errorf("%q: expected issue %q, got %v", tt.message, tt.issue, issues)
+			}
+		}
+	})
+}
+
+func TestConfigValidator_Positions(t *testing.T) {
+	config := &Config{
+		Path:  "quality.yml",
+		Tools: Tools{{Name: "", Positions: Positions{"": {File: "base.yml", Line: 3, Column: 5}}}},
+		Hooks: Hooks{"go": {"pre-commit": {{
+			Name:        "Vet",
+			Command:     "go vet ./...",
+			OutputRules: OutputRules{ShowOn: "sometimes"},
+			Positions: Positions{
+				"":                     {File: "quality.yml", Line: 7, Column: 9},
+				"output_rules.show_on": {File: "quality.yml", Line: 10, Column: 20},
+			},
+		}}}},
+		Positions: Positions{"hooks": {File: "quality.yml", Line: 5, Column: 1}},
+	}
+
+	result := NewConfigValidator(config).Validate()
+
+	locations := make(map[string]Position)
+	for _, err := range result.Errors {
+		locations[err.Field] = err.Position
+	}
+	if pos := locations["tools[0].name"]; pos != (Position{File: "base.yml", Line: 3, Column: 5}) {
+		t.Errorf("Expected the empty name to be located at its tool, got %v", pos)
+	}
+	if pos := locations["hooks.go.pre-commit[0].output_rules.show_on"]; pos != (Position{File: "quality.yml", Line: 10, Column: 20}) {
+		t.Errorf("Expected show_on to be located at its value, got %v", pos)
+	}
+	if pos, ok := locations["hooks"]; ok && pos != (Position{File: "quality.yml", Line: 5, Column: 1}) {
+		t.Errorf("Expected hooks to be located at its key, got %v", pos)
+	}
+	if !strings.Contains(result.GetFormattedErrors(), "output_rules.show_on (quality.yml:10:20)") {
+		t.Errorf("Expected formatted errors to show the location, got:\n%s", result.GetFormattedErrors())
+	}
+}
+
+func TestConfigValidator_ValidationRules(t *testing.T) {
+	newConfig := func(validation Validation) *Config {
+		return &Config{
+			Tools: Tools{{Name: "Lint", CheckCommand: "sh -c true", InstallCommand: "true"}},
+			Hooks: Hooks{"go": {"pre-push": {
+				{Name: "Push", Command: "git push --no-verify"},
+				{Name: "Format", Command: "gofmt -l ."},
+			}}},
+			Validation: validation,
+		}
+	}
+	findRule := func(result *ValidationResult, rule string) *ValidationError {
+		for i := range result.Errors {
+			if result.Errors[i].Rule == rule {
+				return &result.Errors[i]
+			}
+		}
+		return nil
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		result := NewConfigValidator(newConfig(Validation{})).Validate()
+		if err := findRule(result, RuleSecurityHooksMissing); err == nil || err.Severity != SeverityWarning {
+			t.Errorf("Expected a security-hooks-missing warning, got %+v", err)
+		}
+		if err := findRule(result, RuleToolNotConfigured); err == nil || err.Severity != SeverityWarning {
+			t.Errorf("Expected a tool-not-configured warning, got %+v", err)
+		}
+	})
+
+	t.Run("SecretScanningInAnyGroup", func(t *testing.T) {
+		for _, hook := range []Hook{
+			{Name: "Secrets", Builtin: "secrets"},
+			{Name: "Gitleaks", Command: "gitleaks protect --staged --redact"},
+		} {
+			cfg := newConfig(Validation{})
+			cfg.Hooks["hygiene"] = map[string][]Hook{"pre-commit": {hook}}
+			if err := findRule(NewConfigValidator(cfg).Validate(), RuleSecurityHooksMissing); err != nil {
+				t.Errorf("Expected %s in the hygiene group to count as a security hook, got %+v", hook.Name, err)
+			}
+		}
+	})
+
+	t.Run("DisableAndSeverity", func(t *testing.T) {
+		result := NewConfigValidator(newConfig(Validation{
+			Disable:  []string{RuleSecurityHooksMissing},
+			Severity: map[string]string{RuleToolNotConfigured: "error"},
+		})).Validate()
+		if err := findRule(result, RuleSecurityHooksMissing); err != nil {
+			t.Errorf("Expected security-hooks-missing to be disabled, got %+v", err)
+		}
+		if err := findRule(result, RuleToolNotConfigured); err == nil || err.Severity != SeverityError {
+			t.Errorf("Expected tool-not-configured to be an error, got %+v", err)
+		}
+		if result.Valid {
+			t.Error("Expected the raised severity to make the configuration invalid")
+		}
+	})
+
+	t.Run("CustomRule", func(t *testing.T) {
+		result := NewConfigValidator(newConfig(Validation{Rules: []ValidationRule{{
+			ID:         "no-verify",
+			Pattern:    `--no-verify\b`,
+			Message:    "Commands must not skip git hooks",
+			Suggestion: "Remove --no-verify",
+		}}})).Validate()
+		err := findRule(result, "no-verify")
+		if err == nil {
+			t.Fatalf("Expected the custom rule to match, got %+v", result.Errors)
+		}
+		if err.Field != "hooks.go.pre-push[0].command" || err.Severity != SeverityError || err.Issue != "Commands must not skip git hooks" {
+			t.Errorf("Unexpected custom rule finding: %+v", err)
+		}
+	})
+
+	t.Run("InvalidSettings", func(t *testing.T) {
+		result := NewConfigValidator(newConfig(Validation{
+			Disable:  []string{"security-hook-missing"},
+			Severity: map[string]string{RuleToolTypo: "fatal"},
+			Rules:    []ValidationRule{{ID: "broken", Pattern: "(", Severity: "high"}, {ID: RuleToolTypo, Pattern: "x"}},
+		})).Validate()
+
+		issues := make(map[string]ValidationError)
+		for _, err := range result.Errors {
+			if err.Rule == RuleValidationConfig {
+				issues[err.Field] = err
+			}
+		}
+		if err := issues["validation.disable[0]"]; err.Suggestion != "Did you mean 'security-hooks-missing'?" {
+			t.Errorf("Expected a suggestion for the unknown rule, got %+v", err)
+		}
+		for _, field := range []string{"validation.severity.tool-typo", "validation.rules[0].pattern", "validation.rules[1].id"} {
+			if err, ok := issues[field]; !ok || err.Severity != SeverityError {
+				t.Errorf("Expected an error for %s, got %+v", field, issues)
+			}
+		}
+	})
 }
 
 func TestValidationResult_GetFormattedErrors(t *testing.T) {
@@ -475,6 +909,22 @@ func TestValidationResult_GetFormattedErrors(t *testing.T) {
 	}
 }
 
+func TestValidationResult_GetFormattedWarnings(t *testing.T) {
+	result := &ValidationResult{
+		Valid: true,
+		Errors: []ValidationError{{
+			Field:    "hooks",
+			Issue:    "No security hooks configured",
+			Severity: SeverityWarning,
+		}},
+	}
+
+	formatted := result.GetFormattedErrors()
+	if !strings.HasPrefix(formatted, "⚠️  Found 1 validation warning(s):") || strings.Contains(formatted, "❌") {
+		t.Errorf("Expected a warning header, got:\n%s", formatted)
+	}
+}
+
 func TestValidationResult_GetErrorsBySeverity(t *testing.T) {
 	result := &ValidationResult{
 		Valid: false,
@@ -501,6 +951,45 @@ func TestValidationResult_GetErrorsBySeverity(t *testing.T) {
 	}
 }
 
+func TestValidationResult_Failed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		result   ValidationResult
+		strict   bool
+		expected bool
+	}{
+		{"Clean", ValidationResult{Valid: true}, true, false},
+		{"Warnings", ValidationResult{Valid: true, Errors: []ValidationError{{Severity: SeverityWarning}}}, false, false},
+		{"StrictWarnings", ValidationResult{Valid: true, Errors: []ValidationError{{Severity: SeverityWarning}}}, true, true},
+		{"Errors", ValidationResult{Valid: false, Errors: []ValidationError{{Severity: SeverityError}}}, false, true},
+	}
+
+	for _, tc := range testCases {
+		if failed := tc.result.Failed(tc.strict); failed != tc.expected {
+			t.Errorf("%s: expected Failed(%v) = %v, got %v", tc.name, tc.strict, tc.expected, failed)
+		}
+	}
+}
+
+func TestValidationResult_JSON(t *testing.T) {
+	result := ValidationResult{Valid: false, Errors: []ValidationError{{
+		Rule:     RuleDangerousCommand,
+		Field:    "hooks.go.pre-commit[0].command",
+		Issue:    "Potentially dangerous command detected",
+		Severity: SeverityCritical,
+		Position: Position{File: "quality.yml", Line: 6, Column: 18},
+	}}}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"valid":false,"errors":[{"rule":"dangerous-command","field":"hooks.go.pre-commit[0].command","issue":"Potentially dangerous command detected","severity":"critical","position":{"file":"quality.yml","line":6,"column":18}}]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
 func TestValidationSeverity_String(t *testing.T) {
 	testCases := []struct {
 		severity ValidationSeverity
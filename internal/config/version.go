@@ -0,0 +1,111 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CheckMinVersion returns an error when the running quality-gate version is
+// older than the min_version required by the configuration. Versions that are
+// not semantic versions, such as local builds, are not checked.
+func CheckMinVersion(cfg *Config, current string) error {
+	if cfg == nil || cfg.MinVersion == "" {
+		return nil
+	}
+	if _, ok := parseVersion(current); !ok {
+		return nil
+	}
+	cmp, err := CompareVersions(current, cfg.MinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid min_version: %w", err)
+	}
+	if cmp < 0 {
+		name := "quality.yml"
+		if cfg.Path != "" {
+			name = filepath.Base(cfg.Path)
+		}
+		return fmt.Errorf("%s requires quality-gate %s or newer, but %s is running; please upgrade", name, cfg.MinVersion, current)
+	}
+	return nil
+}
+
+// CompareVersions compares two semantic versions, returning -1, 0 or 1. A
+// leading "v" is accepted and pre-releases sort before their release.
+func CompareVersions(a, b string) (int, error) {
+	va, ok := parseVersion(a)
+	if !ok {
+		return 0, fmt.Errorf("%q is not a valid version", a)
+	}
+	vb, ok := parseVersion(b)
+	if !ok {
+		return 0, fmt.Errorf("%q is not a valid version", b)
+	}
+
+	for i := 0; i < 3; i++ {
+		if va.numbers[i] != vb.numbers[i] {
+			if va.numbers[i] < vb.numbers[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+
+	switch {
+	case va.prerelease == vb.prerelease:
+		return 0, nil
+	case va.prerelease == "":
+		return 1, nil
+	case vb.prerelease == "":
+		return -1, nil
+	case va.prerelease < vb.prerelease:
+		return -1, nil
+	default:
+		return 1, nil
+	}
+}
+
+type version struct {
+	numbers    [3]int
+	prerelease string
+}
+
+// parseVersion parses MAJOR[.MINOR[.PATCH]][-PRERELEASE][+BUILD].
+func parseVersion(s string) (version, bool) {
+	var v version
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.prerelease = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.numbers[i] = n
+	}
+	return v, true
+}
+
+// Checksum returns the SHA-256 checksum of a configuration file as "sha256:<hex>".
+func Checksum(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"v1.2.0", "1.2", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"2.0.0", "1.9.9", 1},
+		{"1.2.0-dev", "1.2.0", -1},
+		{"1.2.0", "1.2.0-rc.1", 1},
+		{"1.2.0+abc", "1.2.0", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := CompareVersions(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("CompareVersions(%q, %q) returned error: %v", tt.a, tt.b, err)
+		}
+		if got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if _, err := CompareVersions("latest", "1.0.0"); err == nil {
+		t.Error("Expected error for invalid version")
+	}
+}
+
+func TestCheckMinVersion(t *testing.T) {
+	cfg := &Config{MinVersion: "1.3.0"}
+
+	err := CheckMinVersion(cfg, "1.2.0")
+	if err == nil || !strings.Contains(err.Error(), "requires quality-gate 1.3.0") {
+		t.Errorf("Expected min_version error, got %v", err)
+	}
+	if err := CheckMinVersion(cfg, "1.3.1"); err != nil {
+		t.Errorf("Expected newer version to pass, got %v", err)
+	}
+	if err := CheckMinVersion(cfg, "development"); err != nil {
+		t.Errorf("Expected non-semantic versions to be skipped, got %v", err)
+	}
+	if err := CheckMinVersion(&Config{}, "0.1.0"); err != nil {
+		t.Errorf("Expected no min_version to pass, got %v", err)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quality.yml")
+	if err := os.WriteFile(path, []byte("tools: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Checksum(path)
+	if err != nil {
+		t.Fatalf("Checksum failed: %v", err)
+	}
+	if !strings.HasPrefix(first, "sha256:") || len(first) != len("sha256:")+64 {
+		t.Errorf("Unexpected checksum format: %s", first)
+	}
+
+	if err := os.WriteFile(path, []byte("tools: [] \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := Checksum(path)
+	if first == second {
+		t.Error("Expected checksum to change with the file content")
+	}
+}
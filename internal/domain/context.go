@@ -0,0 +1,19 @@
+package domain
+
+import "os"
+
+// HookContext carries information about the git invocation that triggered a hook run.
+
+type HookContext struct {
+	HookType string
+	Push     *PushInfo
+	// Files lists the files the hook run applies to, relative to the
+	// repository root: staged files for pre-commit, pushed files for pre-push.
+	Files []string
+	// ReadFile returns the content of a file as it will be committed or
+	// pushed. When nil, files are read from the working tree.
+	ReadFile func(path string) ([]byte, error)
+	// Stat returns the size and mode of a file as it will be committed or
+	// pushed. When nil, files are stat'ed in the working tree.
+	Stat func(path string) (os.FileInfo, error)
+}
@@ -1,10 +1,19 @@
 package domain
 
+// HookBackupSuffix is appended to the name of a foreign git hook when the
+// installer moves it aside to chain or restore it.
+
+const HookBackupSuffix = ".pre-quality-gate"
+
 // Hook represents a command to be executed as part of a git hook.
 
 type Hook struct {
 	Name        string
 	Command     string
+	Builtin     string            `json:",omitempty"`
+	Options     map[string]string `json:",omitempty"`
+	Files       []string          `json:",omitempty"`
+	Exclude     []string          `json:",omitempty"`
 	FixCommand  string
 	OutputRules OutputRules
 }
@@ -14,4 +23,13 @@ type Hook struct {
 type OutputRules struct {
 	ShowOn           string
 	OnFailureMessage string
+	OnSuccessMessage string `json:",omitempty"`
+	// MaxLines limits the shown output to its first lines, or its last lines
+	// when Truncate is "tail". Zero shows all lines.
+	MaxLines int    `json:",omitempty"`
+	Truncate string `json:",omitempty"`
+	// Filter keeps only the output lines matching it, after Strip removed its
+	// matches from every line.
+	Filter string `json:",omitempty"`
+	Strip  string `json:",omitempty"`
 }
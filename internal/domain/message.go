@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// OutputTailLines is the number of output lines MessageData.OutputTail keeps.
+
+const OutputTailLines = 10
+
+// MessageData is the data output rule messages are rendered with as Go
+// templates, as in "Run `{{.FixCommand}}` to fix {{len .Files}} files".
+
+type MessageData struct {
+	Name     string
+	HookType string
+	Duration time.Duration
+	ExitCode int
+	// Files lists the files the result concerns: the files with findings of
+	// builtin checks, else the hook's files named in the output, else all of
+	// the hook's files.
+	Files      []string
+	Output     string
+	OutputTail string
+	FixCommand string
+}
+
+// NewMessageData returns the message data of a hook result. Files are the
+// files the hook ran on, narrowed down as described by MessageData.Files.
+
+func NewMessageData(result ExecutionResult, hookType string, files []string) MessageData {
+	return MessageData{
+		Name:       result.Hook.Name,
+		HookType:   hookType,
+		Duration:   result.Duration.Round(time.Millisecond),
+		ExitCode:   result.ExitCode,
+		Files:      files,
+		Output:     result.Output,
+		OutputTail: tailLines(result.Output, OutputTailLines),
+		FixCommand: result.Hook.FixCommand,
+	}
+}
+
+var messageFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+// ParseMessage parses a message template.
+
+func ParseMessage(message string) (*template.Template, error) {
+	return template.New("message").Funcs(messageFuncs).Parse(message)
+}
+
+// CheckMessage parses a message template and renders it with sample data,
+// reporting syntax errors and unknown fields and functions.
+
+func CheckMessage(message string) error {
+	tmpl, err := ParseMessage(message)
+	if err == nil {
+		err = tmpl.Execute(io.Discard, MessageData{Files: []string{"file"}})
+	}
+	if err != nil {
+		return errors.New(templateErrorNoise.ReplaceAllString(err.Error(), ""))
+	}
+	return nil
+}
+
+// templateErrorNoise matches the parts of text/template errors naming the
+// template and the Go type of the data.
+var templateErrorNoise = regexp.MustCompile(`^template: message:|executing "message" at <[^>]*>: | in type domain\.MessageData`)
+
+// RenderMessage renders a message template with the data of a hook result.
+
+func RenderMessage(message string, data MessageData) (string, error) {
+	tmpl, err := ParseMessage(message)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// EscapeMessage returns a message template printing text as written.
+
+func EscapeMessage(text string) string {
+	return strings.ReplaceAll(text, "{{", "{{`{{`}}")
+}
+
+// tailLines returns the last n lines of output, without trailing blank lines.
+
+func tailLines(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
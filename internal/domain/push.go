@@ -0,0 +1,80 @@
+package domain
+
+import "strings"
+
+// RefUpdate represents a single ref update that git reports to the pre-push hook.
+
+type RefUpdate struct {
+	LocalRef  string
+	LocalSHA  string
+	RemoteRef string
+	RemoteSHA string
+}
+
+// IsDelete reports whether the update deletes the remote ref.
+
+func (u RefUpdate) IsDelete() bool {
+	return isZeroSHA(u.LocalSHA)
+}
+
+// IsNew reports whether the update creates a ref that does not exist on the remote yet.
+
+func (u RefUpdate) IsNew() bool {
+	return isZeroSHA(u.RemoteSHA)
+}
+
+// PushInfo holds the remote and ref updates of a pre-push invocation.
+
+type PushInfo struct {
+	RemoteName string
+	RemoteURL  string
+	Updates    []RefUpdate
+}
+
+// Primary returns the first update that is not a deletion, which is the ref
+// being pushed in the common single-branch case.
+
+func (p *PushInfo) Primary() (RefUpdate, bool) {
+	if p == nil {
+		return RefUpdate{}, false
+	}
+	for _, update := range p.Updates {
+		if !update.IsDelete() {
+			return update, true
+		}
+	}
+	return RefUpdate{}, false
+}
+
+// Range returns a single git revision covering the commits being pushed by
+// the primary update: "<remote>..<local>" for existing refs and the local SHA
+// for new ones, which Exclusions then limits to commits unknown to the remote.
+
+func (p *PushInfo) Range() string {
+	update, ok := p.Primary()
+	if !ok {
+		return ""
+	}
+	if !update.IsNew() {
+		return update.RemoteSHA + ".." + update.LocalSHA
+	}
+	return update.LocalSHA
+}
+
+// Exclusions returns the git log arguments excluding commits the remote
+// already has when the primary update creates a new ref.
+
+func (p *PushInfo) Exclusions() []string {
+	update, ok := p.Primary()
+	if !ok || !update.IsNew() {
+		return nil
+	}
+	if p.RemoteName != "" && !strings.Contains(p.RemoteName, "/") && !strings.Contains(p.RemoteName, ":") {
+		return []string{"--not", "--remotes=" + p.RemoteName}
+	}
+	return nil
+}
+
+func isZeroSHA(sha string) bool {
+	return sha != "" && strings.Trim(sha, "0") == ""
+}
@@ -9,4 +9,11 @@ type ExecutionResult struct {
 	Success  bool
 	Output   string
 	Duration time.Duration
+	Skipped  bool
+	// ExitCode is the exit status of the hook command, 1 for failed builtin
+	// checks and commands that could not be run.
+	ExitCode int
+	// Message is the rendered on_failure_message of a failed hook, or the
+	// on_success_message of a passed one.
+	Message string
 }
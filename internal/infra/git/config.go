@@ -0,0 +1,51 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// RealGitConfig is a real implementation of the GitConfig interface.
+
+type RealGitConfig struct{}
+
+// GetGlobal implements the GitConfig interface.
+
+func (c *RealGitConfig) GetGlobal(key string) (string, error) {
+	output, err := exec.Command("git", "config", "--global", "--get", key).Output()
+	if err != nil {
+		// git config exits with 1 when the key is not set.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read git config %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SetGlobal implements the GitConfig interface.
+
+func (c *RealGitConfig) SetGlobal(key, value string) error {
+	if output, err := exec.Command("git", "config", "--global", key, value).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set git config %s: %w\n%s", key, err, output)
+	}
+	return nil
+}
+
+// UnsetGlobal implements the GitConfig interface.
+
+func (c *RealGitConfig) UnsetGlobal(key string) error {
+	output, err := exec.Command("git", "config", "--global", "--unset", key).CombinedOutput()
+	if err != nil {
+		// git config exits with 5 when the key is not set.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 5 {
+			return nil
+		}
+		return fmt.Errorf("failed to unset git config %s: %w\n%s", key, err, output)
+	}
+	return nil
+}
@@ -3,7 +3,13 @@ package git
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
 )
 
 // RealGitRepository is a real implementation of the GitRepository interface.
@@ -13,13 +19,11 @@ type RealGitRepository struct{}
 // InstallHook implements the GitRepository interface.
 
 func (r *RealGitRepository) InstallHook(hookType string, content string) error {
-	gitDir, err := findGitDir()
+	hookPath, err := hookPath(hookType)
 	if err != nil {
 		return err
 	}
 
-	hookPath := filepath.Join(gitDir, "hooks", hookType)
-
 	f, err := os.Create(hookPath)
 	if err != nil {
 		return err
@@ -34,6 +38,113 @@ func (r *RealGitRepository) InstallHook(hookType string, content string) error {
 	return os.Chmod(hookPath, 0755)
 }
 
+// ReadHook implements the GitRepository interface.
+
+func (r *RealGitRepository) ReadHook(hookType string) (string, bool, error) {
+	hookPath, err := hookPath(hookType)
+	if err != nil {
+		return "", false, err
+	}
+
+	data, err := os.ReadFile(hookPath)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// RemoveHook implements the GitRepository interface.
+
+func (r *RealGitRepository) RemoveHook(hookType string) error {
+	hookPath, err := hookPath(hookType)
+	if err != nil {
+		return err
+	}
+	return os.Remove(hookPath)
+}
+
+// BackupHook implements the GitRepository interface.
+
+func (r *RealGitRepository) BackupHook(hookType string) error {
+	hookPath, err := hookPath(hookType)
+	if err != nil {
+		return err
+	}
+
+	backupPath := hookPath + domain.HookBackupSuffix
+	if _, err := os.Lstat(backupPath); err == nil {
+		return fmt.Errorf("a backup of the %s hook already exists at %s", hookType, backupPath)
+	}
+	return os.Rename(hookPath, backupPath)
+}
+
+// HasHookBackup implements the GitRepository interface.
+
+func (r *RealGitRepository) HasHookBackup(hookType string) bool {
+	hookPath, err := hookPath(hookType)
+	if err != nil {
+		return false
+	}
+	_, err = os.Lstat(hookPath + domain.HookBackupSuffix)
+	return err == nil
+}
+
+// RestoreHook implements the GitRepository interface.
+
+func (r *RealGitRepository) RestoreHook(hookType string) error {
+	hookPath, err := hookPath(hookType)
+	if err != nil {
+		return err
+	}
+	return os.Rename(hookPath+domain.HookBackupSuffix, hookPath)
+}
+
+// HooksDir returns the directory git runs hooks from, honouring
+// core.hooksPath, linked worktrees and submodules.
+
+func (r *RealGitRepository) HooksDir() (string, error) {
+	return hooksDir()
+}
+
+// hookPath returns the path of the given hook inside the hooks directory,
+// creating the directory when it does not exist yet.
+
+func hookPath(hookType string) (string, error) {
+	dir, err := hooksDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	return filepath.Join(dir, hookType), nil
+}
+
+// hooksDir asks git for the hooks directory, which resolves core.hooksPath
+// and the common directory of worktrees the same way git itself does. When
+// git cannot answer, the layout is resolved from the .git directory or file.
+
+func hooksDir() (string, error) {
+	if output, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output(); err == nil {
+		if dir := strings.TrimSpace(string(output)); dir != "" {
+			return filepath.Abs(dir)
+		}
+	}
+
+	gitDir, err := findGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(commonDir(gitDir), "hooks"), nil
+}
+
+// findGitDir walks up from the working directory to the git directory. A
+// .git file, as used by linked worktrees and submodules, is followed to the
+// directory named by its "gitdir:" line.
+
 func findGitDir() (string, error) {
 	path, err := os.Getwd()
 	if err != nil {
@@ -42,8 +153,11 @@ func findGitDir() (string, error) {
 
 	for {
 		gitDir := filepath.Join(path, ".git")
-		if info, err := os.Stat(gitDir); err == nil && info.IsDir() {
-			return gitDir, nil
+		if info, err := os.Stat(gitDir); err == nil {
+			if info.IsDir() {
+				return gitDir, nil
+			}
+			return readGitDirFile(gitDir)
 		}
 
 		parent := filepath.Dir(path)
@@ -53,3 +167,243 @@ func findGitDir() (string, error) {
 		path = parent
 	}
 }
+
+// readGitDirFile resolves a .git file of the form "gitdir: <path>".
+
+func readGitDirFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("invalid .git file %s: missing gitdir", file)
+	}
+
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(file), target)
+	}
+	return filepath.Clean(target), nil
+}
+
+// commonDir returns the directory shared by all worktrees of a repository,
+// which is where hooks live. Linked worktrees point to it from a commondir file.
+
+func commonDir(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+
+	dir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// TopLevel returns the root of the working tree, which the paths listed by
+// git are relative to.
+
+func (r *RealGitRepository) TopLevel() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// StagedFiles returns the files added, copied, modified or renamed in the index.
+
+func (r *RealGitRepository) StagedFiles() ([]string, error) {
+	return gitFileList("diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z")
+}
+
+// TrackedFiles returns every file tracked by git.
+
+func (r *RealGitRepository) TrackedFiles() ([]string, error) {
+	return gitFileList("ls-files", "-z")
+}
+
+// PushedFiles returns the files changed by the ref updates of a push. New refs
+// have no remote base to compare against, so the files changed by the commits
+// the remote does not have yet are returned (see domain.PushInfo.Exclusions),
+// or every file of the pushed commit when the remote cannot be excluded.
+
+func (r *RealGitRepository) PushedFiles(push *domain.PushInfo) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, update := range push.Updates {
+		if update.IsDelete() {
+			continue
+		}
+
+		var list []string
+		var err error
+		if update.IsNew() {
+			list, err = newRefFiles(push.RemoteName, update)
+		} else {
+			list, err = gitFileList("diff", "--name-only", "--diff-filter=ACMR", "-z", update.RemoteSHA, update.LocalSHA)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range list {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// newRefFiles lists the files changed by the commits of a new ref that the
+// remote does not have, keeping those still present in the pushed commit.
+
+func newRefFiles(remoteName string, update domain.RefUpdate) ([]string, error) {
+	tree, err := gitFileList("ls-tree", "-r", "--name-only", "-z", update.LocalSHA)
+	if err != nil {
+		return nil, err
+	}
+	single := &domain.PushInfo{RemoteName: remoteName, Updates: []domain.RefUpdate{update}}
+	exclusions := single.Exclusions()
+	if len(exclusions) == 0 {
+		return tree, nil
+	}
+
+	args := append([]string{"log", "--name-only", "--format=", "--diff-filter=ACMR", "-z", update.LocalSHA}, exclusions...)
+	changed, err := gitFileList(args...)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(tree))
+	for _, file := range tree {
+		present[file] = true
+	}
+	var files []string
+	for _, file := range changed {
+		if present[file] {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// ReadStaged returns the content of a file as staged in the index.
+
+func (r *RealGitRepository) ReadStaged(path string) ([]byte, error) {
+	return r.ReadAt("", path)
+}
+
+// ReadAt returns the content of a file at the given revision; an empty
+// revision reads from the index.
+
+func (r *RealGitRepository) ReadAt(rev, path string) ([]byte, error) {
+	output, err := exec.Command("git", "show", rev+":"+filepath.ToSlash(path)).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from git: %w", path, err)
+	}
+	return output, nil
+}
+
+// gitFileList runs a git command producing a NUL-separated list of paths.
+
+func gitFileList(args ...string) ([]string, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// StatAt returns the size and mode git records for a file at the given
+// revision; an empty revision reads from the index. Files git does not know
+// are reported as not existing.
+
+func (r *RealGitRepository) StatAt(rev, path string) (os.FileInfo, error) {
+	path = filepath.ToSlash(path)
+	if rev == "" {
+		output, err := exec.Command("git", "ls-files", "-s", "-z", "--", path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s in the index: %w", path, err)
+		}
+		// <mode> <object> <stage>\t<path>
+		for _, entry := range strings.Split(string(output), "\x00") {
+			meta, name, ok := strings.Cut(entry, "\t")
+			fields := strings.Fields(meta)
+			if !ok || name != path || len(fields) != 3 {
+				continue
+			}
+			size, err := exec.Command("git", "cat-file", "-s", fields[1]).Output()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the size of %s: %w", path, err)
+			}
+			return newGitFileInfo(path, fields[0], strings.TrimSpace(string(size)))
+		}
+		return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+	}
+
+	output, err := exec.Command("git", "ls-tree", "-l", "-z", rev, "--", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s at %s: %w", path, rev, err)
+	}
+	// <mode> <type> <object> <size>\t<path>
+	for _, entry := range strings.Split(string(output), "\x00") {
+		meta, name, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || name != path || len(fields) != 4 {
+			continue
+		}
+		return newGitFileInfo(path, fields[0], fields[3])
+	}
+	return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+}
+
+// gitFileInfo is an os.FileInfo built from a git tree or index entry.
+
+type gitFileInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+}
+
+func newGitFileInfo(path, mode, size string) (os.FileInfo, error) {
+	info := &gitFileInfo{name: filepath.Base(path)}
+	switch {
+	case mode == "100755":
+		info.mode = 0755
+	case strings.HasPrefix(mode, "100"):
+		info.mode = 0644
+	case mode == "120000":
+		info.mode = os.ModeSymlink | 0777
+	default:
+		// Trees and submodules
+		info.mode = os.ModeDir | 0755
+	}
+	if size != "-" {
+		n, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size %q for %s", size, path)
+		}
+		info.size = n
+	}
+	return info, nil
+}
+
+func (i *gitFileInfo) Name() string       { return i.name }
+func (i *gitFileInfo) Size() int64        { return i.size }
+func (i *gitFileInfo) Mode() os.FileMode  { return i.mode }
+func (i *gitFileInfo) ModTime() time.Time { return time.Time{} }
+func (i *gitFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *gitFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,98 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
+)
+
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// initRepo creates a git repository with a first commit on main pushed to a
+// bare origin, and switches into it for the duration of the test.
+func initRepo(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	runGit(t, "init", "--quiet", "--bare", "origin.git")
+	if err := os.Mkdir("work", 0755); err != nil {
+		t.Fatalf("Failed to create work: %v", err)
+	}
+	if err := os.Chdir("work"); err != nil {
+		t.Fatalf("Failed to chdir: %v", err)
+	}
+	runGit(t, "init", "--quiet", "-b", "main")
+	runGit(t, "config", "user.email", "test@example.com")
+	runGit(t, "config", "user.name", "Test")
+	runGit(t, "remote", "add", "origin", "../origin.git")
+	commitFile(t, "base.go", "package base\n")
+	runGit(t, "push", "--quiet", "origin", "main")
+}
+
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func commitFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	runGit(t, "add", name)
+	runGit(t, "commit", "--quiet", "-m", "add "+name)
+}
+
+func TestPushedFiles_NewRefListsPushedCommits(t *testing.T) {
+	initRepo(t)
+	runGit(t, "checkout", "--quiet", "-b", "feature")
+	commitFile(t, "feature.go", "package feature\n")
+	commitFile(t, "removed.go", "package removed\n")
+	runGit(t, "rm", "--quiet", "removed.go")
+	runGit(t, "commit", "--quiet", "-m", "remove removed.go")
+	localSHA := runGit(t, "rev-parse", "HEAD")
+
+	// The index no longer matches the pushed commit
+	if err := os.WriteFile("staged.go", []byte("package staged\n"), 0644); err != nil {
+		t.Fatalf("Failed to write staged.go: %v", err)
+	}
+	runGit(t, "add", "staged.go")
+
+	repo := &RealGitRepository{}
+	update := domain.RefUpdate{LocalRef: "refs/heads/feature", LocalSHA: localSHA, RemoteRef: "refs/heads/feature", RemoteSHA: zeroSHA}
+
+	files, err := repo.PushedFiles(&domain.PushInfo{RemoteName: "origin", Updates: []domain.RefUpdate{update}})
+	if err != nil {
+		t.Fatalf("PushedFiles failed: %v", err)
+	}
+	if want := []string{"feature.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+
+	// Without a remote name to exclude, every file of the pushed commit
+	files, err = repo.PushedFiles(&domain.PushInfo{RemoteName: "../origin.git", Updates: []domain.RefUpdate{update}})
+	if err != nil {
+		t.Fatalf("PushedFiles failed: %v", err)
+	}
+	sort.Strings(files)
+	if want := []string{"base.go", "feature.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+}
@@ -12,15 +12,25 @@ type RealShellRunner struct{}
 // Run implements the ShellRunner interface.
 
 func (r *RealShellRunner) Run(command string) (string, error) {
-	shell := getPreferredShell()
+	return r.RunWithEnv(command, nil)
+}
+
+// RunWithEnv implements the ShellRunner interface, adding env to the inherited environment.
+
+func (r *RealShellRunner) RunWithEnv(command string, env []string) (string, error) {
+	shell := PreferredShell()
 	cmd := exec.Command(shell, "-c", command)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
 
-// getPreferredShell returns the preferred shell to use, falling back to bash if not found.
+// PreferredShell returns the shell commands are run with: $SHELL, else the
+// first of zsh, bash and sh found, falling back to bash.
 
-func getPreferredShell() string {
+func PreferredShell() string {
 	if shell := os.Getenv("SHELL"); shell != "" {
 		return shell
 	}
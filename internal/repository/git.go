@@ -4,4 +4,16 @@ package repository
 
 type GitRepository interface {
 	InstallHook(hookType string, content string) error
+	// ReadHook returns the content of an installed hook and whether it exists.
+	ReadHook(hookType string) (string, bool, error)
+	// RemoveHook deletes an installed hook.
+	RemoveHook(hookType string) error
+	// BackupHook moves an existing hook aside so it can be chained or restored.
+	BackupHook(hookType string) error
+	// HasHookBackup reports whether a backup of the hook exists.
+	HasHookBackup(hookType string) bool
+	// RestoreHook moves a backed up hook back into place.
+	RestoreHook(hookType string) error
+	// HooksDir returns the directory git runs hooks from.
+	HooksDir() (string, error)
 }
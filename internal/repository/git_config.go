@@ -0,0 +1,12 @@
+package repository
+
+// GitConfig defines the interface for reading and writing the user's global git configuration.
+
+type GitConfig interface {
+	// GetGlobal returns the value of a global setting, or "" when it is unset.
+	GetGlobal(key string) (string, error)
+	// SetGlobal sets a global setting.
+	SetGlobal(key, value string) error
+	// UnsetGlobal removes a global setting.
+	UnsetGlobal(key string) error
+}
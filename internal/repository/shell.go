@@ -4,4 +4,5 @@ package repository
 
 type ShellRunner interface {
 	Run(command string) (string, error)
+	RunWithEnv(command string, env []string) (string, error)
 }
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"github.com/dmux/go-quality-gate/internal/shellparse"
+)
+
+// CIProviders lists the CI systems pipelines can be generated for.
+var CIProviders = []string{"github", "gitlab", "azure", "jenkins"}
+
+// ciPipelineFiles are where each CI system reads its pipeline from.
+var ciPipelineFiles = map[string]string{
+	"github":  ".github/workflows/quality-gate.yml",
+	"gitlab":  ".gitlab-ci.yml",
+	"azure":   "azure-pipelines.yml",
+	"jenkins": "Jenkinsfile",
+}
+
+// ciReleaseURL is where the release binaries of quality-gate are published.
+const ciReleaseURL = "https://github.com/dmux/go-quality-gate/releases"
+
+// ciReportDir is the directory the JSON reports of the hook runs go to.
+const ciReportDir = "quality-gate-reports"
+
+// CIPipelineFile returns the default path of the pipeline of a CI system.
+func CIPipelineFile(provider string) string {
+	return ciPipelineFiles[provider]
+}
+
+// CIOptions configures a generated pipeline.
+type CIOptions struct {
+	Provider string
+	// Version is the quality-gate release to install, or "latest".
+	Version string
+	// ConfigPath is the configuration file, relative to the repository root.
+	ConfigPath string
+	// File is the path of the pipeline file, relative to the repository root.
+	File string
+	// ProjectPath is the repository root, where lock files are looked up.
+	ProjectPath string
+}
+
+// ciEcosystem is a toolchain run by hooks or tools, with the caches of the
+// packages and tools it downloads.
+type ciEcosystem struct {
+	name     string
+	commands []string
+	// paths are the default cache directories, cached as they are on GitHub.
+	paths []string
+	// env points the caches into a cache directory, on CI systems that only
+	// cache directories they are told about.
+	env       []ciCacheVar
+	lockFiles []string
+	// image is the GitLab image providing the toolchain.
+	image string
+}
+
+type ciCacheVar struct {
+	name string
+	dir  string
+}
+
+// ciEcosystems are the toolchains pipelines set up and cache, in the order
+// they are set up.
+var ciEcosystems = []ciEcosystem{
+	{
+		name:      "node",
+		commands:  []string{"node", "npm", "npx", "yarn", "pnpm", "eslint", "prettier", "tsc", "jest"},
+		paths:     []string{"~/.npm"},
+		env:       []ciCacheVar{{"npm_config_cache", "npm"}},
+		lockFiles: []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml"},
+		image:     "node:lts",
+	},
+	{
+		name:      "python",
+		commands:  []string{"python", "python3", "pip", "pip3", "pytest", "black", "ruff", "flake8", "mypy", "isort", "poetry", "uv"},
+		paths:     []string{"~/.cache/pip"},
+		env:       []ciCacheVar{{"PIP_CACHE_DIR", "pip"}},
+		lockFiles: []string{"requirements.txt", "poetry.lock", "uv.lock", "Pipfile.lock"},
+		image:     "python:3",
+	},
+	{
+		name:      "go",
+		commands:  []string{"go", "gofmt", "golangci-lint"},
+		paths:     []string{"~/go/pkg/mod", "~/.cache/go-build"},
+		env:       []ciCacheVar{{"GOMODCACHE", "go-mod"}, {"GOCACHE", "go-build"}},
+		lockFiles: []string{"go.sum"},
+		image:     "golang",
+	},
+	{
+		name:      "rust",
+		commands:  []string{"cargo", "rustc", "rustfmt", "rustup"},
+		paths:     []string{"~/.cargo/registry"},
+		lockFiles: []string{"Cargo.lock"},
+		image:     "rust",
+	},
+	{
+		name:      "php",
+		commands:  []string{"php", "composer", "php-cs-fixer", "phpstan"},
+		paths:     []string{"~/.cache/composer"},
+		env:       []ciCacheVar{{"COMPOSER_CACHE_DIR", "composer"}},
+		lockFiles: []string{"composer.lock"},
+		image:     "composer",
+	},
+}
+
+// ciPlan is what a pipeline does, derived from the configuration.
+type ciPlan struct {
+	opts CIOptions
+	// hookTypes are the hook types with hooks, each run as a stage.
+	hookTypes  []string
+	ecosystems []ciEcosystem
+	// keyFiles are the files whose content keys the cache.
+	keyFiles []string
+}
+
+// GenerateCI renders a pipeline that installs quality-gate and runs the hook
+// types of the configuration on every tracked file, writing their JSON
+// reports as artifacts and caching the downloads of the tools they run.
+// Hook commands are read from quality.yml when the pipeline runs, so only
+// the hook types, toolchains and caches are written in the pipeline; its
+// first step fails when they no longer match the configuration.
+func GenerateCI(cfg *config.Config, opts CIOptions) (string, error) {
+	render, ok := ciRenderers[opts.Provider]
+	if !ok {
+		return "", fmt.Errorf("unknown provider %q; use %s", opts.Provider, strings.Join(CIProviders, ", "))
+	}
+	if opts.Version == "" {
+		opts.Version = "latest"
+	}
+	if opts.ConfigPath == "" {
+		opts.ConfigPath = "quality.yml"
+	}
+	if opts.File == "" {
+		opts.File = CIPipelineFile(opts.Provider)
+	}
+
+	plan := &ciPlan{opts: opts, keyFiles: []string{opts.ConfigPath}}
+	for _, hookType := range managedHookTypes {
+		for _, hookTypes := range cfg.Hooks {
+			if len(hookTypes[hookType]) > 0 {
+				plan.hookTypes = append(plan.hookTypes, hookType)
+				break
+			}
+		}
+	}
+	if len(plan.hookTypes) == 0 {
+		return "", fmt.Errorf("%s has no %s hooks to run", opts.ConfigPath, strings.Join(managedHookTypes, " or "))
+	}
+
+	used := ciCommandNames(cfg)
+	for _, ecosystem := range ciEcosystems {
+		for _, command := range ecosystem.commands {
+			if used[command] {
+				plan.ecosystems = append(plan.ecosystems, ecosystem)
+				break
+			}
+		}
+	}
+	for _, ecosystem := range plan.ecosystems {
+		for _, lockFile := range ecosystem.lockFiles {
+			if _, err := os.Stat(filepath.Join(opts.ProjectPath, lockFile)); err == nil {
+				plan.keyFiles = append(plan.keyFiles, lockFile)
+			}
+		}
+	}
+
+	var b strings.Builder
+	render(&b, plan)
+	return b.String(), nil
+}
+
+// ciCommandNames returns the names of the commands run by the tools and
+// hooks of a configuration.
+func ciCommandNames(cfg *config.Config) map[string]bool {
+	var commands []string
+	for _, tool := range cfg.Tools {
+		commands = append(commands, tool.CheckCommand, tool.InstallCommand)
+	}
+	for _, hookTypes := range cfg.Hooks {
+		for _, hooks := range hookTypes {
+			for _, hook := range hooks {
+				commands = append(commands, hook.Command, hook.FixCommand)
+			}
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, command := range commands {
+		script, err := shellparse.Parse(command)
+		if err != nil {
+			continue
+		}
+		for _, call := range shellparse.SimpleCommands(script) {
+			names[call.Name()] = true
+		}
+	}
+	return names
+}
+
+// header returns the comment lines opening the pipeline file.
+func (p *ciPlan) header() []string {
+	return []string{
+		fmt.Sprintf("Generated by 'quality-gate ci generate' from %s: runs the %s hooks on every tracked file.", p.opts.ConfigPath, strings.Join(p.hookTypes, " and ")),
+		"Hook commands are read from the configuration when the pipeline runs. Regenerate this file",
+		fmt.Sprintf("with '%s' when hook types or tools change.", p.generateCommand(false)),
+	}
+}
+
+// generateCommand returns the command regenerating the pipeline, or with
+// check set, the one failing when the pipeline is out of date.
+func (p *ciPlan) generateCommand(check bool) string {
+	words := []string{"quality-gate", "ci", "generate", "--provider", p.opts.Provider}
+	if p.opts.ConfigPath != "quality.yml" {
+		words = append(words, "--config", shellWord(p.opts.ConfigPath))
+	}
+	if p.opts.File != CIPipelineFile(p.opts.Provider) {
+		words = append(words, "--file", shellWord(p.opts.File))
+	}
+	if check {
+		words = append(words, "--install-version", p.opts.Version, "--check")
+	}
+	return strings.Join(words, " ")
+}
+
+// runCommand returns the command running a hook type on every tracked file
+// and writing its JSON report.
+func (p *ciPlan) runCommand(hookType string) string {
+	words := []string{"quality-gate"}
+	if p.opts.ConfigPath != "quality.yml" {
+		words = append(words, "--config", shellWord(p.opts.ConfigPath))
+	}
+	words = append(words, "--all-files", "--output", "json", hookType)
+	if hookType == "pre-push" {
+		// Without ref updates on stdin, pre-push runs like a manual run
+		words = append(words, "< /dev/null")
+	}
+	return strings.Join(append(words, ">", ciReportDir+"/"+hookType+".json"), " ")
+}
+
+// installScript returns the commands installing the quality-gate binary
+// into dir.
+func (p *ciPlan) installScript(dir string) []string {
+	url := ciReleaseURL + "/latest/download/quality-gate-linux-amd64"
+	if p.opts.Version != "latest" {
+		url = ciReleaseURL + "/download/v" + strings.TrimPrefix(p.opts.Version, "v") + "/quality-gate-linux-amd64"
+	}
+	return []string{
+		fmt.Sprintf(`mkdir -p "%s"`, dir),
+		fmt.Sprintf(`curl -sSfL %s -o "%s/quality-gate"`, url, dir),
+		fmt.Sprintf(`chmod +x "%s/quality-gate"`, dir),
+	}
+}
+
+func (p *ciPlan) uses(name string) bool {
+	for _, ecosystem := range p.ecosystems {
+		if ecosystem.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ciPlan) cacheVars() []ciCacheVar {
+	var vars []ciCacheVar
+	for _, ecosystem := range p.ecosystems {
+		vars = append(vars, ecosystem.env...)
+	}
+	return vars
+}
+
+var ciRenderers = map[string]func(b *strings.Builder, p *ciPlan){
+	"github":  renderGitHub,
+	"gitlab":  renderGitLab,
+	"azure":   renderAzure,
+	"jenkins": renderJenkins,
+}
+
+// renderGitHub renders a GitHub Actions workflow.
+func renderGitHub(b *strings.Builder, p *ciPlan) {
+	for _, line := range p.header() {
+		fmt.Fprintf(b, "# %s\n", line)
+	}
+	b.WriteString(`name: Quality Gate
+
+on:
+  push:
+    branches: [main]
+  pull_request:
+
+jobs:
+  quality-gate:
+    name: Quality Gate
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+`)
+	if p.uses("node") {
+		b.WriteString("\n      - uses: actions/setup-node@v4\n        with:\n          node-version: lts/*\n")
+	}
+	if p.uses("python") {
+		b.WriteString("\n      - uses: actions/setup-python@v5\n        with:\n          python-version: \"3.x\"\n")
+	}
+	if p.uses("go") {
+		b.WriteString("\n      - uses: actions/setup-go@v5\n        with:\n")
+		if _, err := os.Stat(filepath.Join(p.opts.ProjectPath, "go.mod")); err == nil {
+			b.WriteString("          go-version-file: go.mod\n")
+		} else {
+			b.WriteString("          go-version: stable\n")
+		}
+		b.WriteString("          cache: false\n")
+	}
+
+	var paths []string
+	for _, ecosystem := range p.ecosystems {
+		paths = append(paths, ecosystem.paths...)
+	}
+	if len(paths) > 0 {
+		b.WriteString("\n      - name: Cache tool downloads\n        uses: actions/cache@v4\n        with:\n          path: |\n")
+		for _, path := range paths {
+			fmt.Fprintf(b, "            %s\n", path)
+		}
+		fmt.Fprintf(b, "          key: quality-gate-${{ runner.os }}-${{ hashFiles('%s') }}\n", strings.Join(p.keyFiles, "', '"))
+		b.WriteString("          restore-keys: |\n            quality-gate-${{ runner.os }}-\n")
+	}
+
+	b.WriteString("\n      - name: Install quality-gate\n        run: |\n")
+	for _, line := range append(p.installScript("$HOME/.local/bin"), `echo "$HOME/.local/bin" >> "$GITHUB_PATH"`) {
+		fmt.Fprintf(b, "          %s\n", line)
+	}
+	fmt.Fprintf(b, "\n      - name: Check the pipeline is up to date\n        run: %s\n", p.generateCommand(true))
+	fmt.Fprintf(b, "\n      - name: Create the report directory\n        run: mkdir -p %s\n", ciReportDir)
+	for _, hookType := range p.hookTypes {
+		fmt.Fprintf(b, "\n      - name: Run %s hooks\n        if: ${{ !cancelled() }}\n        run: %s\n", hookType, p.runCommand(hookType))
+	}
+	fmt.Fprintf(b, `
+      - name: Upload reports
+        if: ${{ !cancelled() }}
+        uses: actions/upload-artifact@v4
+        with:
+          name: quality-gate-reports
+          path: %s/
+`, ciReportDir)
+}
+
+// renderGitLab renders a GitLab CI configuration with a job per hook type.
+func renderGitLab(b *strings.Builder, p *ciPlan) {
+	for _, line := range p.header() {
+		fmt.Fprintf(b, "# %s\n", line)
+	}
+	b.WriteString("stages:\n  - quality-gate\n\nvariables:\n  GIT_DEPTH: \"0\"\n")
+	for _, v := range p.cacheVars() {
+		fmt.Fprintf(b, "  %s: \"$CI_PROJECT_DIR/.cache/%s\"\n", v.name, v.dir)
+	}
+
+	image := "buildpack-deps:bookworm-scm"
+	if len(p.ecosystems) > 0 {
+		image = p.ecosystems[0].image
+	}
+	b.WriteString("\n.quality-gate:\n  stage: quality-gate\n")
+	if len(p.ecosystems) > 1 {
+		var others []string
+		for _, ecosystem := range p.ecosystems[1:] {
+			others = append(others, ecosystem.name)
+		}
+		fmt.Fprintf(b, "  # The image must also provide %s\n", strings.Join(others, ", "))
+	}
+	fmt.Fprintf(b, "  image: %s\n", image)
+	if len(p.cacheVars()) > 0 {
+		b.WriteString("  cache:\n    key:\n      files:\n")
+		// GitLab keys caches on at most two files
+		for _, file := range p.keyFiles[:min(2, len(p.keyFiles))] {
+			fmt.Fprintf(b, "        - %s\n", file)
+		}
+		b.WriteString("      prefix: quality-gate\n    paths:\n      - .cache/\n")
+	}
+	b.WriteString("  before_script:\n")
+	for _, line := range p.installScript("/usr/local/bin") {
+		fmt.Fprintf(b, "    - %s\n", line)
+	}
+	fmt.Fprintf(b, "    - %s\n    - mkdir -p %s\n", p.generateCommand(true), ciReportDir)
+	fmt.Fprintf(b, "  artifacts:\n    when: always\n    paths:\n      - %s/\n", ciReportDir)
+	b.WriteString(`  rules:
+    - if: $CI_PIPELINE_SOURCE == "merge_request_event"
+    - if: $CI_COMMIT_BRANCH == $CI_DEFAULT_BRANCH
+`)
+	for _, hookType := range p.hookTypes {
+		fmt.Fprintf(b, "\nquality-gate:%s:\n  extends: .quality-gate\n  script:\n    - %s\n", hookType, p.runCommand(hookType))
+	}
+}
+
+// renderAzure renders an Azure Pipelines definition.
+func renderAzure(b *strings.Builder, p *ciPlan) {
+	for _, line := range p.header() {
+		fmt.Fprintf(b, "# %s\n", line)
+	}
+	b.WriteString(`trigger:
+  branches:
+    include: [main]
+
+pr:
+  branches:
+    include: ["*"]
+
+pool:
+  vmImage: ubuntu-latest
+`)
+	if vars := p.cacheVars(); len(vars) > 0 {
+		b.WriteString("\nvariables:\n")
+		for _, v := range vars {
+			fmt.Fprintf(b, "  %s: $(Pipeline.Workspace)/.cache/%s\n", v.name, v.dir)
+		}
+	}
+	b.WriteString("\nsteps:\n  - checkout: self\n    fetchDepth: 0\n")
+	if p.uses("node") {
+		b.WriteString("\n  - task: UseNode@1\n    inputs:\n      version: \"20.x\"\n")
+	}
+	if p.uses("python") {
+		b.WriteString("\n  - task: UsePythonVersion@0\n    inputs:\n      versionSpec: \"3.x\"\n")
+	}
+	if len(p.cacheVars()) > 0 {
+		fmt.Fprintf(b, `
+  - task: Cache@2
+    displayName: Cache tool downloads
+    inputs:
+      key: '"quality-gate" | "$(Agent.OS)" | %s'
+      restoreKeys: '"quality-gate" | "$(Agent.OS)"'
+      path: $(Pipeline.Workspace)/.cache
+`, strings.Join(p.keyFiles, " | "))
+	}
+
+	b.WriteString("\n  - script: |\n")
+	for _, line := range append(p.installScript("$HOME/.local/bin"), `echo "##vso[task.prependpath]$HOME/.local/bin"`) {
+		fmt.Fprintf(b, "      %s\n", line)
+	}
+	b.WriteString("    displayName: Install quality-gate\n")
+	fmt.Fprintf(b, "\n  - script: %s\n    displayName: Check the pipeline is up to date\n", p.generateCommand(true))
+	fmt.Fprintf(b, "\n  - script: mkdir -p %s\n    displayName: Create the report directory\n", ciReportDir)
+	for _, hookType := range p.hookTypes {
+		fmt.Fprintf(b, "\n  - script: %s\n    displayName: Run %s hooks\n    condition: succeededOrFailed()\n", p.runCommand(hookType), hookType)
+	}
+	fmt.Fprintf(b, `
+  - publish: %s
+    artifact: quality-gate-reports
+    displayName: Publish reports
+    condition: succeededOrFailed()
+`, ciReportDir)
+}
+
+// renderJenkins renders a declarative Jenkinsfile. Jenkins has no cache of
+// its own: tool downloads stay in the home directory of the agent.
+func renderJenkins(b *strings.Builder, p *ciPlan) {
+	for _, line := range p.header() {
+		fmt.Fprintf(b, "// %s\n", line)
+	}
+	b.WriteString("pipeline {\n    agent any\n")
+	if len(p.ecosystems) > 0 {
+		var names []string
+		for _, ecosystem := range p.ecosystems {
+			names = append(names, ecosystem.name)
+		}
+		fmt.Fprintf(b, "    // The agent must provide %s; tool downloads are cached in its home directory.\n", strings.Join(names, ", "))
+	}
+	b.WriteString("\n    environment {\n        PATH+QUALITY_GATE = \"${env.HOME}/.local/bin\"\n    }\n\n    stages {\n")
+	b.WriteString("        stage('Install quality-gate') {\n            steps {\n                sh '''\n")
+	for _, line := range p.installScript("$HOME/.local/bin") {
+		fmt.Fprintf(b, "                    %s\n", line)
+	}
+	b.WriteString("                '''\n            }\n        }\n")
+	fmt.Fprintf(b, "        stage('Check the pipeline is up to date') {\n            steps {\n                sh '%s'\n            }\n        }\n", p.generateCommand(true))
+	for _, hookType := range p.hookTypes {
+		fmt.Fprintf(b, "        stage('%s') {\n            steps {\n                sh 'mkdir -p %s'\n                sh '%s'\n            }\n        }\n", hookType, ciReportDir, p.runCommand(hookType))
+	}
+	fmt.Fprintf(b, `    }
+
+    post {
+        always {
+            archiveArtifacts artifacts: '%s/*.json', allowEmptyArchive: true
+        }
+    }
+}
+`, ciReportDir)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+)
+
+func TestGenerateCI(t *testing.T) {
+	cfg := &config.Config{
+		Tools: config.Tools{{Name: "Ruff", CheckCommand: "ruff --version", InstallCommand: "pip install ruff"}},
+		Hooks: config.Hooks{
+			"web": {
+				"pre-commit": {{Name: "ESLint", Command: "npx eslint {files}"}},
+				"pre-push":   {{Name: "Tests", Command: "cd web && npm test"}},
+			},
+			"security": {"pre-commit": {{Name: "Secrets", Builtin: "secrets"}}},
+		},
+	}
+	dir := writeProjectFiles(t, map[string]string{"package-lock.json": "{}", "go.sum": ""})
+
+	tests := []struct {
+		provider string
+		expected []string
+	}{
+		{"github", []string{
+			"      - uses: actions/setup-node@v4\n",
+			"      - uses: actions/setup-python@v5\n",
+			"            ~/.npm\n            ~/.cache/pip\n",
+			"key: quality-gate-${{ runner.os }}-${{ hashFiles('quality.yml', 'package-lock.json') }}",
+			"https://github.com/dmux/go-quality-gate/releases/download/v1.4.0/quality-gate-linux-amd64",
+			"run: quality-gate ci generate --provider github --install-version 1.4.0 --check\n",
+			"run: quality-gate --all-files --output json pre-commit > quality-gate-reports/pre-commit.json\n",
+			"run: quality-gate --all-files --output json pre-push < /dev/null > quality-gate-reports/pre-push.json\n",
+			"uses: actions/upload-artifact@v4",
+		}},
+		{"gitlab", []string{
+			"  npm_config_cache: \"$CI_PROJECT_DIR/.cache/npm\"\n  PIP_CACHE_DIR: \"$CI_PROJECT_DIR/.cache/pip\"\n",
+			"  # The image must also provide python\n  image: node:lts\n",
+			"      files:\n        - quality.yml\n        - package-lock.json\n",
+			"quality-gate:pre-commit:\n  extends: .quality-gate\n",
+			"quality-gate:pre-push:\n  extends: .quality-gate\n",
+		}},
+		{"azure", []string{
+			"  npm_config_cache: $(Pipeline.Workspace)/.cache/npm\n",
+			"      key: '\"quality-gate\" | \"$(Agent.OS)\" | quality.yml | package-lock.json'\n",
+			"    displayName: Run pre-push hooks\n    condition: succeededOrFailed()\n",
+			"  - publish: quality-gate-reports\n",
+		}},
+		{"jenkins", []string{
+			"// The agent must provide node, python",
+			"        stage('pre-commit') {\n",
+			"archiveArtifacts artifacts: 'quality-gate-reports/*.json'",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			pipeline, err := GenerateCI(cfg, CIOptions{Provider: tt.provider, Version: "1.4.0", ProjectPath: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(pipeline, expected) {
+					t.Errorf("Expected the pipeline to contain %q:\n%s", expected, pipeline)
+				}
+			}
+			// go.sum keys the cache only when the hooks use Go
+			if strings.Contains(pipeline, "go.sum") || strings.Contains(pipeline, "setup-go") {
+				t.Errorf("Expected no Go setup for hooks that do not use Go:\n%s", pipeline)
+			}
+		})
+	}
+}
+
+func TestGenerateCI_Options(t *testing.T) {
+	cfg := &config.Config{Hooks: config.Hooks{"go": {"pre-push": {{Name: "Test", Command: "go test ./..."}}}}}
+
+	pipeline, err := GenerateCI(cfg, CIOptions{Provider: "github", ConfigPath: "ci/quality.yml", File: ".github/workflows/gate.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"releases/latest/download/quality-gate-linux-amd64",
+		"quality-gate ci generate --provider github --config ci/quality.yml --file .github/workflows/gate.yml --install-version latest --check",
+		"quality-gate --config ci/quality.yml --all-files --output json pre-push",
+		"          go-version: stable\n",
+	} {
+		if !strings.Contains(pipeline, expected) {
+			t.Errorf("Expected the pipeline to contain %q:\n%s", expected, pipeline)
+		}
+	}
+	if strings.Contains(pipeline, "pre-commit") {
+		t.Errorf("Expected only the configured hook types to run:\n%s", pipeline)
+	}
+
+	if _, err := GenerateCI(cfg, CIOptions{Provider: "travis"}); err == nil || !strings.Contains(err.Error(), `unknown provider "travis"`) {
+		t.Errorf("Expected an unknown provider error, got %v", err)
+	}
+	if _, err := GenerateCI(&config.Config{}, CIOptions{Provider: "gitlab"}); err == nil || !strings.Contains(err.Error(), "has no pre-commit or pre-push hooks") {
+		t.Errorf("Expected an error without hooks, got %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"github.com/dmux/go-quality-gate/internal/repository"
+)
+
+// DoctorStatus is the outcome of a single doctor check.
+
+type DoctorStatus string
+
+const (
+	DoctorOK      DoctorStatus = "ok"
+	DoctorWarning DoctorStatus = "warning"
+	DoctorError   DoctorStatus = "error"
+)
+
+// DoctorCheck is one line of the doctor checklist. Fix holds an actionable
+// suggestion when the check did not pass.
+
+type DoctorCheck struct {
+	Name   string       `json:"name"`
+	Status DoctorStatus `json:"status"`
+	Detail string       `json:"detail"`
+	Fix    string       `json:"fix,omitempty"`
+}
+
+// DoctorReport is the result of diagnosing the local setup.
+
+type DoctorReport struct {
+	Checks []DoctorCheck `json:"checks"`
+}
+
+// HasErrors reports whether any check failed.
+
+func (r DoctorReport) HasErrors() bool {
+	for _, check := range r.Checks {
+		if check.Status == DoctorError {
+			return true
+		}
+	}
+	return false
+}
+
+// DoctorOptions describes the environment being diagnosed.
+
+type DoctorOptions struct {
+	// ConfigPath is the quality.yml to load and validate.
+	ConfigPath string
+	// Version is the version of the running binary.
+	Version string
+	// Shell is the shell hook commands are run with.
+	Shell string
+}
+
+// DoctorService diagnoses the hooks, configuration, tools and shell used by quality-gate.
+
+type DoctorService struct {
+	gitRepo     repository.GitRepository
+	shellRunner repository.ShellRunner
+	lookPath    func(file string) (string, error)
+}
+
+// NewDoctorService creates a new DoctorService.
+
+func NewDoctorService(gitRepo repository.GitRepository, shellRunner repository.ShellRunner) *DoctorService {
+	return &DoctorService{gitRepo: gitRepo, shellRunner: shellRunner, lookPath: exec.LookPath}
+}
+
+// Diagnose runs every check and returns the checklist.
+
+func (s *DoctorService) Diagnose(opts DoctorOptions) DoctorReport {
+	var report DoctorReport
+	add := func(check DoctorCheck) {
+		report.Checks = append(report.Checks, check)
+	}
+
+	dir, err := s.gitRepo.HooksDir()
+	if err != nil {
+		add(DoctorCheck{
+			Name:   "Git repository",
+			Status: DoctorError,
+			Detail: err.Error(),
+			Fix:    "Run quality-gate inside a git repository, or create one with: git init",
+		})
+	} else {
+		add(DoctorCheck{Name: "Git repository", Status: DoctorOK, Detail: "hooks directory: " + dir})
+		checksum, _ := config.Checksum(opts.ConfigPath)
+		for _, hookType := range managedHookTypes {
+			add(s.checkHook(hookType, opts.Version, checksum))
+		}
+	}
+
+	cfg, check := s.checkConfig(opts)
+	add(check)
+	if cfg != nil {
+		for _, tool := range cfg.Tools {
+			add(s.checkTool(tool))
+		}
+	}
+
+	add(s.checkShell(opts.Shell))
+	return report
+}
+
+// checkHook inspects an installed hook script and the binary it runs, and
+// compares the version and config checksum it was installed with.
+
+func (s *DoctorService) checkHook(hookType, version, checksum string) DoctorCheck {
+	check := DoctorCheck{Name: hookType + " hook"}
+
+	content, exists, err := s.gitRepo.ReadHook(hookType)
+	switch {
+	case err != nil:
+		check.Status = DoctorError
+		check.Detail = err.Error()
+		return check
+	case !exists:
+		check.Status = DoctorError
+		check.Detail = "not installed"
+		check.Fix = "quality-gate --install"
+		return check
+	case !IsManagedHook(content):
+		check.Status = DoctorWarning
+		check.Detail = "an existing hook not managed by quality-gate is installed"
+		check.Fix = "quality-gate --install --chain to run it before quality-gate, or --force to replace it"
+		return check
+	}
+
+	binary := hookScriptValue(content, "QUALITY_GATE_BIN=")
+	if binary == "" {
+		binary = "quality-gate"
+	}
+	resolved, err := s.lookPath(binary)
+	if err != nil && binary != "quality-gate" {
+		resolved, err = s.lookPath("quality-gate")
+	}
+	if err != nil {
+		check.Status = DoctorError
+		check.Detail = fmt.Sprintf("runs %s, which cannot be found", binary)
+		check.Fix = "Install quality-gate, then re-run: quality-gate --install --force"
+		return check
+	}
+
+	check.Status = DoctorOK
+	check.Detail = "runs " + resolved
+	if strings.Contains(content, chainedHookMarker) {
+		check.Detail += " (chained with the previous hook)"
+	}
+
+	installed := hookHeaderValue(content, hookVersionHeader)
+	switch {
+	case installed == "":
+		check.Status = DoctorWarning
+		check.Detail += "; installed by an older quality-gate"
+		check.Fix = "quality-gate --install --force"
+	case version != "" && installed != version:
+		check.Status = DoctorWarning
+		check.Detail += fmt.Sprintf("; installed by %s, running %s", installed, version)
+		check.Fix = "quality-gate --install --force"
+	}
+
+	if recorded := hookHeaderValue(content, hookChecksumHeader); recorded != "" && checksum != "" && recorded != checksum {
+		check.Status = DoctorWarning
+		check.Detail += "; the configuration file changed since the hook was installed"
+		check.Fix = "Review the configuration changes, then re-run: quality-gate --install --force"
+	}
+	return check
+}
+
+// checkConfig loads and validates the configuration file.
+
+func (s *DoctorService) checkConfig(opts DoctorOptions) (*config.Config, DoctorCheck) {
+	check := DoctorCheck{Name: "Configuration"}
+
+	if _, err := os.Stat(opts.ConfigPath); err != nil {
+		check.Status = DoctorError
+		check.Detail = fmt.Sprintf("%s not found", opts.ConfigPath)
+		check.Fix = "quality-gate --init"
+		return nil, check
+	}
+
+	cfg, err := config.LoadConfig(opts.ConfigPath)
+	if err != nil {
+		check.Status = DoctorError
+		check.Detail = fmt.Sprintf("%s cannot be parsed: %v", opts.ConfigPath, err)
+		check.Fix = "Fix the YAML syntax in " + opts.ConfigPath
+		return nil, check
+	}
+
+	if err := config.CheckMinVersion(cfg, opts.Version); err != nil {
+		check.Status = DoctorError
+		check.Detail = err.Error()
+		check.Fix = "Upgrade quality-gate"
+		return cfg, check
+	}
+
+	result := config.NewConfigValidator(cfg).Validate()
+	bySeverity := result.GetErrorsBySeverity()
+	failures := len(bySeverity[config.SeverityCritical]) + len(bySeverity[config.SeverityError])
+	warnings := len(bySeverity[config.SeverityWarning])
+	switch {
+	case failures > 0:
+		check.Status = DoctorError
+		check.Detail = fmt.Sprintf("%s has %d error(s) and %d warning(s)", opts.ConfigPath, failures, warnings)
+		check.Fix = firstSuggestion(result, config.SeverityCritical, config.SeverityError)
+	case warnings > 0:
+		check.Status = DoctorWarning
+		check.Detail = fmt.Sprintf("%s is valid with %d warning(s)", opts.ConfigPath, warnings)
+		check.Fix = firstSuggestion(result, config.SeverityWarning)
+	default:
+		check.Status = DoctorOK
+		check.Detail = opts.ConfigPath + " is valid"
+	}
+	return cfg, check
+}
+
+// checkTool runs a tool's check command.
+
+func (s *DoctorService) checkTool(tool config.Tool) DoctorCheck {
+	check := DoctorCheck{Name: "Tool: " + tool.Name}
+	if tool.CheckCommand == "" {
+		check.Status = DoctorWarning
+		check.Detail = "no check_command defined"
+		return check
+	}
+	if _, err := s.shellRunner.Run(tool.CheckCommand); err != nil {
+		check.Status = DoctorError
+		check.Detail = fmt.Sprintf("'%s' failed: %v", tool.CheckCommand, err)
+		check.Fix = tool.InstallCommand
+		if check.Fix == "" {
+			check.Fix = fmt.Sprintf("Install %s and make sure it is in PATH", tool.Name)
+		}
+		return check
+	}
+	check.Status = DoctorOK
+	check.Detail = fmt.Sprintf("'%s' succeeded", tool.CheckCommand)
+	return check
+}
+
+// checkShell reports the shell used to run hook commands.
+
+func (s *DoctorService) checkShell(shell string) DoctorCheck {
+	check := DoctorCheck{Name: "Shell"}
+	resolved, err := s.lookPath(shell)
+	if err != nil {
+		check.Status = DoctorError
+		check.Detail = fmt.Sprintf("%s cannot be found", shell)
+		check.Fix = "Point $SHELL to an installed shell such as /bin/sh"
+		return check
+	}
+	check.Status = DoctorOK
+	check.Detail = "hook commands run with " + resolved
+	if os.Getenv("SHELL") != "" {
+		check.Detail += " (from $SHELL)"
+	}
+	return check
+}
+
+// hookHeaderValue returns the value of a "# key: value" header line of a hook script.
+
+func hookHeaderValue(content, header string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, header) {
+			return strings.TrimSpace(strings.TrimPrefix(line, header))
+		}
+	}
+	return ""
+}
+
+// hookScriptValue returns the single-quoted value assigned by a "NAME='value'" line.
+
+func hookScriptValue(content, assignment string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, assignment) {
+			value := strings.TrimPrefix(line, assignment)
+			if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+				return strings.ReplaceAll(value[1:len(value)-1], `'\''`, "'")
+			}
+		}
+	}
+	return ""
+}
+
+// firstSuggestion returns the first validation suggestion of the given severities.
+
+func firstSuggestion(result *config.ValidationResult, severities ...config.ValidationSeverity) string {
+	bySeverity := result.GetErrorsBySeverity()
+	for _, severity := range severities {
+		for _, err := range bySeverity[severity] {
+			if err.Suggestion != "" {
+				return fmt.Sprintf("%s: %s", err.Field, err.Suggestion)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+)
+
+func newTestDoctor(gitRepo *MockGitRepository, runner *MockShellRunner, found map[string]string) *DoctorService {
+	doctor := NewDoctorService(gitRepo, runner)
+	doctor.lookPath = func(file string) (string, error) {
+		if path, ok := found[file]; ok {
+			return path, nil
+		}
+		return "", errors.New("not found")
+	}
+	return doctor
+}
+
+func writeDoctorConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quality.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func findCheck(t *testing.T, report DoctorReport, name string) DoctorCheck {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("Expected a %q check, got %+v", name, report.Checks)
+	return DoctorCheck{}
+}
+
+func TestDoctorService_Diagnose(t *testing.T) {
+	configPath := writeDoctorConfig(t, `tools:
+  - name: "Lint"
+    check_command: "lint --version"
+    install_command: "install-lint"
+  - name: "Format"
+    check_command: "fmt --version"
+hooks:
+  security:
+    pre-commit:
+      - name: "Secrets"
+        builtin: secrets
+`)
+
+	gitRepo := NewMockGitRepository()
+	gitRepo.Hooks["pre-commit"] = hookScript("pre-commit", false, InstallOptions{BinaryPath: "/opt/bin/quality-gate", Version: "1.2.0"})
+	gitRepo.Hooks["pre-push"] = hookScript("pre-push", true, InstallOptions{BinaryPath: "/opt/bin/quality-gate", Version: "1.1.0"})
+
+	runner := &MockShellRunner{Commands: map[string]struct {
+		Output string
+		Err    error
+	}{
+		"fmt --version": {Output: "fmt 1.0"},
+	}}
+	doctor := newTestDoctor(gitRepo, runner, map[string]string{
+		"/opt/bin/quality-gate": "/opt/bin/quality-gate",
+		"/bin/sh":               "/bin/sh",
+	})
+
+	report := doctor.Diagnose(DoctorOptions{ConfigPath: configPath, Version: "1.2.0", Shell: "/bin/sh"})
+
+	if check := findCheck(t, report, "Git repository"); check.Status != DoctorOK || !strings.Contains(check.Detail, ".git/hooks") {
+		t.Errorf("Unexpected git check: %+v", check)
+	}
+	if check := findCheck(t, report, "pre-commit hook"); check.Status != DoctorOK || check.Detail != "runs /opt/bin/quality-gate" {
+		t.Errorf("Unexpected pre-commit check: %+v", check)
+	}
+	check := findCheck(t, report, "pre-push hook")
+	if check.Status != DoctorWarning || !strings.Contains(check.Detail, "chained") || !strings.Contains(check.Detail, "installed by 1.1.0") {
+		t.Errorf("Unexpected pre-push check: %+v", check)
+	}
+	if check.Fix != "quality-gate --install --force" {
+		t.Errorf("Expected re-install fix, got %q", check.Fix)
+	}
+	if check := findCheck(t, report, "Tool: Lint"); check.Status != DoctorError || check.Fix != "install-lint" {
+		t.Errorf("Unexpected Lint check: %+v", check)
+	}
+	if check := findCheck(t, report, "Tool: Format"); check.Status != DoctorOK {
+		t.Errorf("Unexpected Format check: %+v", check)
+	}
+	if check := findCheck(t, report, "Shell"); check.Status != DoctorOK || !strings.Contains(check.Detail, "/bin/sh") {
+		t.Errorf("Unexpected shell check: %+v", check)
+	}
+	if !report.HasErrors() {
+		t.Error("Expected the failing tool to make the report fail")
+	}
+}
+
+func TestDoctorService_DiagnoseProblems(t *testing.T) {
+	t.Run("NotARepository", func(t *testing.T) {
+		gitRepo := NewMockGitRepository()
+		gitRepo.Dir = ""
+		doctor := newTestDoctor(gitRepo, &MockShellRunner{}, nil)
+
+		report := doctor.Diagnose(DoctorOptions{ConfigPath: filepath.Join(t.TempDir(), "quality.yml"), Shell: "sh"})
+
+		if check := findCheck(t, report, "Git repository"); check.Status != DoctorError || check.Fix == "" {
+			t.Errorf("Unexpected git check: %+v", check)
+		}
+		for _, check := range report.Checks {
+			if strings.HasSuffix(check.Name, " hook") {
+				t.Errorf("Expected hooks not to be checked outside a repository, got %+v", check)
+			}
+		}
+		if check := findCheck(t, report, "Configuration"); check.Status != DoctorError || check.Fix != "quality-gate --init" {
+			t.Errorf("Unexpected config check: %+v", check)
+		}
+		if check := findCheck(t, report, "Shell"); check.Status != DoctorError {
+			t.Errorf("Unexpected shell check: %+v", check)
+		}
+	})
+
+	t.Run("Hooks", func(t *testing.T) {
+		gitRepo := NewMockGitRepository()
+		gitRepo.Hooks["pre-commit"] = "#!/bin/sh\nnpx lint-staged\n"
+		gitRepo.Hooks["pre-push"] = hookScript("pre-push", false, InstallOptions{BinaryPath: "/gone/quality-gate", Version: "1.2.0"})
+		doctor := newTestDoctor(gitRepo, &MockShellRunner{}, nil)
+
+		report := doctor.Diagnose(DoctorOptions{ConfigPath: writeDoctorConfig(t, "tools: []\n"), Version: "1.2.0", Shell: "sh"})
+
+		if check := findCheck(t, report, "pre-commit hook"); check.Status != DoctorWarning || !strings.Contains(check.Fix, "--chain") {
+			t.Errorf("Unexpected pre-commit check: %+v", check)
+		}
+		if check := findCheck(t, report, "pre-push hook"); check.Status != DoctorError || !strings.Contains(check.Detail, "/gone/quality-gate") {
+			t.Errorf("Unexpected pre-push check: %+v", check)
+		}
+	})
+
+	t.Run("ConfigChanged", func(t *testing.T) {
+		configPath := writeDoctorConfig(t, "tools: []\n")
+		installed, err := config.Checksum(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gitRepo := NewMockGitRepository()
+		for _, hookType := range managedHookTypes {
+			gitRepo.Hooks[hookType] = hookScript(hookType, false, InstallOptions{BinaryPath: "/opt/bin/quality-gate", Version: "1.2.0", ConfigChecksum: installed})
+		}
+		if err := os.WriteFile(configPath, []byte("tools: []\nhooks: {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		doctor := newTestDoctor(gitRepo, &MockShellRunner{}, map[string]string{"/opt/bin/quality-gate": "/opt/bin/quality-gate"})
+
+		report := doctor.Diagnose(DoctorOptions{ConfigPath: configPath, Version: "1.2.0", Shell: "sh"})
+
+		if check := findCheck(t, report, "pre-commit hook"); check.Status != DoctorWarning || !strings.Contains(check.Detail, "configuration file changed") {
+			t.Errorf("Expected a warning about the changed configuration, got %+v", check)
+		}
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		doctor := newTestDoctor(NewMockGitRepository(), &MockShellRunner{}, nil)
+
+		report := doctor.Diagnose(DoctorOptions{ConfigPath: writeDoctorConfig(t, "tools: [\n"), Shell: "sh"})
+
+		if check := findCheck(t, report, "Configuration"); check.Status != DoctorError || !strings.Contains(check.Detail, "cannot be parsed") {
+			t.Errorf("Unexpected config check: %+v", check)
+		}
+		if check := findCheck(t, report, "pre-commit hook"); check.Status != DoctorError || check.Fix != "quality-gate --install" {
+			t.Errorf("Unexpected pre-commit check: %+v", check)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// filterFiles returns the files matching at least one include pattern (all
+// files when there are none) and no exclude pattern.
+
+func filterFiles(files, include, exclude []string) []string {
+	var filtered []string
+	for _, file := range files {
+		slashed := filepath.ToSlash(file)
+		if len(include) > 0 && !matchesAny(include, slashed) {
+			continue
+		}
+		if matchesAny(exclude, slashed) {
+			continue
+		}
+		filtered = append(filtered, file)
+	}
+	return filtered
+}
+
+func matchesAny(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if matchesPattern(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPattern matches a slash-separated path against a glob pattern. It
+// supports "*", "?", "[...]", "{a,b}" and "**" across directories; patterns
+// without a slash are matched against the base name, like .gitignore.
+
+func matchesPattern(pattern, file string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	re, err := regexp.Compile(globToRegexp(pattern))
+	if err != nil {
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		return re.MatchString(path.Base(file))
+	}
+	return re.MatchString(file)
+}
+
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	inGroup := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case c == '{':
+			inGroup = true
+			b.WriteString("(?:")
+		case c == '}' && inGroup:
+			inGroup = false
+			b.WriteString(")")
+		case c == ',' && inGroup:
+			b.WriteString("|")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// shellQuoteFiles joins files into a single shell word list, quoting each one.
+
+func shellQuoteFiles(files []string) string {
+	quoted := make([]string, 0, len(files))
+	for _, file := range files {
+		quoted = append(quoted, shellQuote(file))
+	}
+	return strings.Join(quoted, " ")
+}
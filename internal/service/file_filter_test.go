@@ -0,0 +1,56 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchesPattern(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		file    string
+		match   bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/service/runner.go", true},
+		{"*.go", "main.py", false},
+		{"cmd/*.go", "cmd/main.go", true},
+		{"cmd/*.go", "cmd/sub/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"vendor/", "vendor/pkg/x.go", true},
+		{"vendor/**", "src/vendor.go", false},
+		{"*.{ts,tsx}", "app/view.tsx", true},
+		{"*.{ts,tsx}", "app/view.js", false},
+		{"file?.txt", "file1.txt", true},
+		{"[!a]*.md", "b.md", true},
+		{"[!a]*.md", "a.md", false},
+		{"./docs/*.md", "docs/index.md", true},
+	}
+
+	for _, tc := range testCases {
+		if got := matchesPattern(tc.pattern, tc.file); got != tc.match {
+			t.Errorf("matchesPattern(%q, %q) = %v, want %v", tc.pattern, tc.file, got, tc.match)
+		}
+	}
+}
+
+func TestFilterFiles(t *testing.T) {
+	files := []string{"main.go", "main_test.go", "vendor/lib/lib.go", "README.md"}
+
+	got := filterFiles(files, []string{"*.go"}, []string{"*_test.go", "vendor/"})
+	if want := []string{"main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got := filterFiles(files, nil, nil); !reflect.DeepEqual(got, files) {
+		t.Errorf("Expected all files without filters, got %v", got)
+	}
+}
+
+func TestShellQuoteFiles(t *testing.T) {
+	got := shellQuoteFiles([]string{"a.go", "it's.txt"})
+	if want := `'a.go' 'it'\''s.txt'`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"github.com/dmux/go-quality-gate/internal/repository"
+)
+
+// globalDispatcherMarker identifies the dispatcher scripts of a global installation.
+const globalDispatcherMarker = "# Global dispatcher: runs quality-gate only in repositories with a configuration file."
+
+// legacyGlobalDispatcherMarker identifies dispatchers installed before
+// configuration files other than quality.yml were looked for.
+const legacyGlobalDispatcherMarker = "# Global dispatcher: runs quality-gate only in repositories with a quality.yml."
+
+// globalPreviousFile stores the git setting replaced by a forced global
+// installation, so uninstalling can restore it.
+const globalPreviousFile = "quality-gate.previous"
+
+// GlobalMode selects how hooks are installed for every repository of the user.
+
+type GlobalMode string
+
+const (
+	// GlobalTemplateDir sets init.templateDir, so git copies the dispatchers
+	// into repositories created by git init and git clone.
+	GlobalTemplateDir GlobalMode = "template"
+	// GlobalHooksPath sets core.hooksPath, so every repository runs the
+	// dispatchers directly. Repository hooks still run through them.
+	GlobalHooksPath GlobalMode = "hooks-path"
+)
+
+// configKey returns the git setting the mode configures.
+
+func (m GlobalMode) configKey() string {
+	if m == GlobalHooksPath {
+		return "core.hooksPath"
+	}
+	return "init.templateDir"
+}
+
+// hooksDir returns the directory holding the dispatchers for a configured directory.
+
+func (m GlobalMode) hooksDir(dir string) string {
+	if m == GlobalHooksPath {
+		return dir
+	}
+	return filepath.Join(dir, "hooks")
+}
+
+// GlobalInstallOptions controls a global installation.
+
+type GlobalInstallOptions struct {
+	// Mode selects init.templateDir (default) or core.hooksPath.
+	Mode GlobalMode
+	// Dir is the directory git is pointed at; it defaults to a directory in
+	// the user's configuration directory.
+	Dir string
+	// Force replaces an existing setting or hooks not installed by quality-gate.
+	Force bool
+	// BinaryPath and Version are recorded in the dispatchers as for InstallOptions.
+	BinaryPath string
+	Version    string
+}
+
+// GlobalInstallationService installs the git hooks for every repository of the user.
+
+type GlobalInstallationService struct {
+	gitConfig repository.GitConfig
+	configDir func() (string, error)
+}
+
+// NewGlobalInstallationService creates a new GlobalInstallationService.
+
+func NewGlobalInstallationService(gitConfig repository.GitConfig) *GlobalInstallationService {
+	return &GlobalInstallationService{gitConfig: gitConfig, configDir: os.UserConfigDir}
+}
+
+// InstallGlobal writes the dispatcher scripts and points the user's git
+// configuration at them. It returns the configured directory.
+
+func (s *GlobalInstallationService) InstallGlobal(opts GlobalInstallOptions) (string, error) {
+	if opts.Mode == "" {
+		opts.Mode = GlobalTemplateDir
+	}
+	if opts.Mode != GlobalTemplateDir && opts.Mode != GlobalHooksPath {
+		return "", fmt.Errorf("unknown global installation mode: %s", opts.Mode)
+	}
+
+	dir, err := s.globalDir(opts)
+	if err != nil {
+		return "", err
+	}
+	key := opts.Mode.configKey()
+
+	current, err := s.gitConfig.GetGlobal(key)
+	if err != nil {
+		return "", err
+	}
+	replaced := current != "" && !samePath(current, dir)
+	if replaced && !opts.Force {
+		return "", fmt.Errorf("git config --global %s is already set to %s; use --force to replace it (it is restored by --uninstall --global)", key, current)
+	}
+
+	hooksDir := opts.Mode.hooksDir(dir)
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", hooksDir, err)
+	}
+	for _, hookType := range managedHookTypes {
+		path := filepath.Join(hooksDir, hookType)
+		if content, err := os.ReadFile(path); err == nil && !IsManagedHook(string(content)) && !opts.Force {
+			return "", fmt.Errorf("%s was not installed by quality-gate; use --force to replace it", path)
+		}
+	}
+
+	installOpts := InstallOptions{BinaryPath: opts.BinaryPath, Version: opts.Version}
+	for _, hookType := range managedHookTypes {
+		path := filepath.Join(hooksDir, hookType)
+		if err := os.WriteFile(path, []byte(globalDispatcherScript(hookType, opts.Mode, installOpts)), 0755); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		// WriteFile keeps the mode of an existing file.
+		if err := os.Chmod(path, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	if replaced {
+		if err := os.WriteFile(filepath.Join(dir, globalPreviousFile), []byte(current+"\n"), 0644); err != nil {
+			return "", fmt.Errorf("failed to record previous %s: %w", key, err)
+		}
+	}
+	if err := s.gitConfig.SetGlobal(key, dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// UninstallGlobal removes the dispatchers of every global installation and
+// restores or unsets the git settings pointing at them.
+
+func (s *GlobalInstallationService) UninstallGlobal() error {
+	uninstalled := false
+	for _, mode := range []GlobalMode{GlobalTemplateDir, GlobalHooksPath} {
+		key := mode.configKey()
+		dir, err := s.gitConfig.GetGlobal(key)
+		if err != nil {
+			return err
+		}
+		if dir == "" {
+			continue
+		}
+		dir = expandHome(dir)
+
+		hooksDir := mode.hooksDir(dir)
+		removed := false
+		for _, hookType := range managedHookTypes {
+			path := filepath.Join(hooksDir, hookType)
+			content, err := os.ReadFile(path)
+			if err != nil || !isGlobalDispatcher(string(content)) {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			removed = true
+		}
+		if !removed {
+			continue
+		}
+
+		previousPath := filepath.Join(dir, globalPreviousFile)
+		if previous, err := os.ReadFile(previousPath); err == nil {
+			if err := s.gitConfig.SetGlobal(key, strings.TrimSpace(string(previous))); err != nil {
+				return err
+			}
+			if err := os.Remove(previousPath); err != nil {
+				return err
+			}
+		} else if err := s.gitConfig.UnsetGlobal(key); err != nil {
+			return err
+		}
+		uninstalled = true
+	}
+
+	if !uninstalled {
+		return fmt.Errorf("quality-gate is not installed globally")
+	}
+	return nil
+}
+
+// globalDir returns the absolute directory git is pointed at.
+
+func (s *GlobalInstallationService) globalDir(opts GlobalInstallOptions) (string, error) {
+	if opts.Dir != "" {
+		return filepath.Abs(expandHome(opts.Dir))
+	}
+	base, err := s.configDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user configuration directory: %w", err)
+	}
+	name := "template"
+	if opts.Mode == GlobalHooksPath {
+		name = "hooks"
+	}
+	return filepath.Join(base, "quality-gate", name), nil
+}
+
+// globalDispatcherScript returns the script installed globally for a hook
+// type. It passes through in repositories without a configuration file. With
+// core.hooksPath, git ignores the repository's own hooks, so a repository
+// hook is run instead of quality-gate when one exists.
+
+func globalDispatcherScript(hookType string, mode GlobalMode, opts InstallOptions) string {
+	var b strings.Builder
+	writeHookHeader(&b, managedHookMarker+" Remove with: quality-gate --uninstall --global\n"+globalDispatcherMarker, opts)
+
+	if mode == GlobalHooksPath {
+		fmt.Fprintf(&b, "local_hook=\"$(git rev-parse --git-common-dir 2>/dev/null)/hooks/%s\"\n", hookType)
+		b.WriteString("if [ -x \"$local_hook\" ]; then\n")
+		b.WriteString("  exec \"$local_hook\" \"$@\"\n")
+		b.WriteString("fi\n")
+	}
+	b.WriteString("top=$(git rev-parse --show-toplevel 2>/dev/null) || exit 0\n")
+	b.WriteString("if [ -z \"$QUALITY_GATE_CONFIG\" ]")
+	for _, name := range config.FileNames {
+		fmt.Fprintf(&b, " && [ ! -f \"$top/%s\" ]", name)
+	}
+	b.WriteString("; then\n")
+	b.WriteString("  exit 0\n")
+	b.WriteString("fi\n\n")
+
+	writeBinaryLookup(&b, opts)
+	fmt.Fprintf(&b, "exec \"$QUALITY_GATE_BIN\" %s \"$@\"\n", hookType)
+	return b.String()
+}
+
+// isGlobalDispatcher reports whether a hook script is a dispatcher of a
+// global installation, including those of earlier versions.
+
+func isGlobalDispatcher(content string) bool {
+	return strings.Contains(content, globalDispatcherMarker) || strings.Contains(content, legacyGlobalDispatcherMarker)
+}
+
+// isSharedDispatcher reports whether a hook script is a core.hooksPath
+// dispatcher. Those serve every repository of the user, unlike template
+// dispatchers, which git copies into each repository; only the former look
+// for a repository hook to run instead.
+
+func isSharedDispatcher(content string) bool {
+	return isGlobalDispatcher(content) && strings.Contains(content, "local_hook=")
+}
+
+// expandHome expands a leading ~ the way git does for path settings.
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
+
+// samePath reports whether two paths name the same directory.
+
+func samePath(a, b string) bool {
+	return filepath.Clean(expandHome(a)) == filepath.Clean(expandHome(b))
+}
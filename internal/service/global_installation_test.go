@@ -0,0 +1,199 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestGlobalInstallation(t *testing.T) (*GlobalInstallationService, *MockGitConfig, string) {
+	t.Helper()
+	gitConfig := &MockGitConfig{Values: make(map[string]string)}
+	configDir := t.TempDir()
+	service := NewGlobalInstallationService(gitConfig)
+	service.configDir = func() (string, error) { return configDir, nil }
+	return service, gitConfig, configDir
+}
+
+func TestGlobalInstallationService_InstallGlobal(t *testing.T) {
+	service, gitConfig, configDir := newTestGlobalInstallation(t)
+
+	dir, err := service.InstallGlobal(GlobalInstallOptions{BinaryPath: "/usr/local/bin/quality-gate", Version: "1.2.0"})
+	if err != nil {
+		t.Fatalf("InstallGlobal failed: %v", err)
+	}
+
+	if expected := filepath.Join(configDir, "quality-gate", "template"); dir != expected {
+		t.Errorf("Expected template dir %s, got %s", expected, dir)
+	}
+	if gitConfig.Values["init.templateDir"] != dir {
+		t.Errorf("Expected init.templateDir to be set, got %v", gitConfig.Values)
+	}
+
+	for _, hookType := range []string{"pre-commit", "pre-push"} {
+		path := filepath.Join(dir, "hooks", hookType)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Expected %s dispatcher: %v", hookType, err)
+		}
+		if info.Mode().Perm()&0111 == 0 {
+			t.Errorf("Expected %s dispatcher to be executable", hookType)
+		}
+
+		content, _ := os.ReadFile(path)
+		script := string(content)
+		for _, expected := range []string{
+			globalDispatcherMarker,
+			`if [ -z "$QUALITY_GATE_CONFIG" ] && [ ! -f "$top/quality.yml" ] && [ ! -f "$top/.quality.yml" ] && [ ! -f "$top/.quality-gate.yml" ]; then`,
+			"QUALITY_GATE_BIN='/usr/local/bin/quality-gate'",
+			`exec "$QUALITY_GATE_BIN" ` + hookType + ` "$@"`,
+		} {
+			if !strings.Contains(script, expected) {
+				t.Errorf("Expected %s dispatcher to contain %q, got:\n%s", hookType, expected, script)
+			}
+		}
+		if strings.Contains(script, "local_hook") {
+			t.Errorf("Template dispatchers are the repository hooks and must not run them again")
+		}
+		if !IsManagedHook(script) {
+			t.Errorf("Expected dispatcher to be replaceable by a repository installation")
+		}
+	}
+}
+
+func TestGlobalInstallationService_HooksPath(t *testing.T) {
+	service, gitConfig, configDir := newTestGlobalInstallation(t)
+
+	dir, err := service.InstallGlobal(GlobalInstallOptions{Mode: GlobalHooksPath})
+	if err != nil {
+		t.Fatalf("InstallGlobal failed: %v", err)
+	}
+
+	if expected := filepath.Join(configDir, "quality-gate", "hooks"); dir != expected || gitConfig.Values["core.hooksPath"] != dir {
+		t.Errorf("Expected core.hooksPath %s, got %v", expected, gitConfig.Values)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "pre-push"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `local_hook="$(git rev-parse --git-common-dir 2>/dev/null)/hooks/pre-push"`) {
+		t.Errorf("Expected dispatcher to pass through to repository hooks, got:\n%s", content)
+	}
+
+	if err := service.UninstallGlobal(); err != nil {
+		t.Fatalf("UninstallGlobal failed: %v", err)
+	}
+	if _, ok := gitConfig.Values["core.hooksPath"]; ok {
+		t.Errorf("Expected core.hooksPath to be unset, got %v", gitConfig.Values)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pre-push")); !os.IsNotExist(err) {
+		t.Errorf("Expected dispatcher to be removed")
+	}
+}
+
+func TestGlobalInstallationService_ExistingSetting(t *testing.T) {
+	service, gitConfig, _ := newTestGlobalInstallation(t)
+	gitConfig.Values["init.templateDir"] = "/usr/share/company-template"
+
+	if _, err := service.InstallGlobal(GlobalInstallOptions{}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("Expected existing init.templateDir to be refused, got %v", err)
+	}
+	if gitConfig.Values["init.templateDir"] != "/usr/share/company-template" {
+		t.Errorf("Expected refusal to leave git config untouched")
+	}
+
+	if _, err := service.InstallGlobal(GlobalInstallOptions{Force: true}); err != nil {
+		t.Fatalf("Forced InstallGlobal failed: %v", err)
+	}
+	// Re-installing over our own directory does not need --force.
+	if _, err := service.InstallGlobal(GlobalInstallOptions{}); err != nil {
+		t.Fatalf("Re-installing failed: %v", err)
+	}
+
+	if err := service.UninstallGlobal(); err != nil {
+		t.Fatalf("UninstallGlobal failed: %v", err)
+	}
+	if gitConfig.Values["init.templateDir"] != "/usr/share/company-template" {
+		t.Errorf("Expected previous init.templateDir to be restored, got %v", gitConfig.Values)
+	}
+
+	if err := service.UninstallGlobal(); err == nil {
+		t.Error("Expected error when quality-gate is not installed globally")
+	}
+}
+
+func TestGlobalInstallationService_ForeignHooks(t *testing.T) {
+	service, _, _ := newTestGlobalInstallation(t)
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "hooks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "hooks", "pre-commit"), []byte("#!/bin/sh\nmake lint\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.InstallGlobal(GlobalInstallOptions{Dir: dir}); err == nil {
+		t.Fatal("Expected foreign hook in the template directory to be refused")
+	}
+	if _, err := service.InstallGlobal(GlobalInstallOptions{Dir: dir, Force: true}); err != nil {
+		t.Fatalf("Forced InstallGlobal failed: %v", err)
+	}
+}
+
+func TestInstallationService_RefusesSharedHooksDir(t *testing.T) {
+	gitRepo := NewMockGitRepository()
+	gitRepo.Dir = "/home/dev/.config/quality-gate/hooks"
+	for _, hookType := range managedHookTypes {
+		gitRepo.Hooks[hookType] = globalDispatcherScript(hookType, GlobalHooksPath, InstallOptions{})
+	}
+	dispatchers := map[string]string{"pre-commit": gitRepo.Hooks["pre-commit"], "pre-push": gitRepo.Hooks["pre-push"]}
+	service := NewInstallationService(gitRepo)
+
+	for name, run := range map[string]func() error{
+		"Install":   func() error { return service.InstallHooksWithOptions(InstallOptions{Force: true}) },
+		"Uninstall": service.UninstallHooks,
+	} {
+		err := run()
+		if err == nil || !strings.Contains(err.Error(), gitRepo.Dir+" holds the global dispatchers") {
+			t.Errorf("%s: expected the shared hooks directory to be refused, got %v", name, err)
+		}
+		if !reflect.DeepEqual(gitRepo.Hooks, dispatchers) {
+			t.Errorf("%s: expected the dispatchers to be left untouched", name)
+		}
+	}
+
+	// Template dispatchers are copies owned by the repository.
+	gitRepo.Hooks["pre-commit"] = globalDispatcherScript("pre-commit", GlobalTemplateDir, InstallOptions{})
+	gitRepo.Hooks["pre-push"] = globalDispatcherScript("pre-push", GlobalTemplateDir, InstallOptions{})
+	if err := service.InstallHooks(); err != nil {
+		t.Errorf("Expected template dispatchers to be replaced, got %v", err)
+	}
+}
+
+func TestGlobalInstallationService_UninstallsLegacyDispatchers(t *testing.T) {
+	service, gitConfig, _ := newTestGlobalInstallation(t)
+	dir, err := service.InstallGlobal(GlobalInstallOptions{Mode: GlobalHooksPath})
+	if err != nil {
+		t.Fatalf("InstallGlobal failed: %v", err)
+	}
+	for _, hookType := range managedHookTypes {
+		path := filepath.Join(dir, hookType)
+		content, _ := os.ReadFile(path)
+		legacy := strings.Replace(string(content), globalDispatcherMarker, legacyGlobalDispatcherMarker, 1)
+		if err := os.WriteFile(path, []byte(legacy), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := service.UninstallGlobal(); err != nil {
+		t.Fatalf("Expected dispatchers of earlier versions to be uninstalled, got %v", err)
+	}
+	if _, ok := gitConfig.Values["core.hooksPath"]; ok {
+		t.Errorf("Expected core.hooksPath to be unset, got %v", gitConfig.Values)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pre-commit")); !os.IsNotExist(err) {
+		t.Errorf("Expected the legacy dispatcher to be removed")
+	}
+}
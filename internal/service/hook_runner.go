@@ -1,9 +1,13 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/dmux/go-quality-gate/internal/builtin"
 	"github.com/dmux/go-quality-gate/internal/domain"
 	"github.com/dmux/go-quality-gate/internal/infra/logger"
 	"github.com/dmux/go-quality-gate/internal/repository"
@@ -14,6 +18,8 @@ import (
 type HookRunnerService struct {
 	shellRunner repository.ShellRunner
 	logger      logger.Logger
+	context     domain.HookContext
+	env         []string
 }
 
 // NewHookRunnerService creates a new HookRunnerService.
@@ -22,13 +28,50 @@ func NewHookRunnerService(shellRunner repository.ShellRunner, logger logger.Logg
 	return &HookRunnerService{shellRunner: shellRunner, logger: logger}
 }
 
+// SetContext sets the git invocation context exposed to hooks through
+// {placeholders} and QUALITY_GATE_* environment variables.
+
+func (s *HookRunnerService) SetContext(ctx domain.HookContext) {
+	s.context = ctx
+}
+
+// SetEnvironment sets the environment variables from the configuration added
+// to hook and fix commands. The QUALITY_GATE_* context variables take precedence.
+
+func (s *HookRunnerService) SetEnvironment(env map[string]string) {
+	s.env = nil
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s.env = append(s.env, key+"="+env[key])
+	}
+}
+
+// HasBuiltinFix reports whether the hook is a builtin check with a native fixer.
+
+func (s *HookRunnerService) HasBuiltinFix(hook domain.Hook) bool {
+	check, ok := builtin.Lookup(hook.Builtin)
+	return ok && check.Fix != nil
+}
+
 func (s *HookRunnerService) RunFixCommand(hook domain.Hook) (string, error) {
-	if hook.FixCommand == "" {
+	if hook.FixCommand == "" && !s.HasBuiltinFix(hook) {
 		return "", fmt.Errorf("no fix command defined for hook: %s", hook.Name)
 	}
 
+	files := s.hookFiles(hook)
+
 	s.logger.StartSpinner(fmt.Sprintf("Running fix command for %s...", hook.Name))
-	output, err := s.shellRunner.Run(hook.FixCommand)
+	var output string
+	var err error
+	if hook.FixCommand != "" {
+		output, err = s.run(hook.FixCommand, files)
+	} else {
+		output, err = s.fixBuiltin(hook, files)
+	}
 	s.logger.StopSpinner()
 
 	if err != nil {
@@ -44,10 +87,25 @@ func (s *HookRunnerService) RunHooks(hooks []domain.Hook) []domain.ExecutionResu
 	var results []domain.ExecutionResult
 
 	for _, hook := range hooks {
+		files := s.hookFiles(hook)
+		if hasFileFilter(hook) && len(files) == 0 {
+			results = append(results, domain.ExecutionResult{Hook: hook, Success: true, Skipped: true})
+			s.logger.Print("⏭️  %s skipped (no matching files)\n", hook.Name)
+			continue
+		}
+
 		s.logger.StartSpinner(fmt.Sprintf("Running %s...", hook.Name))
 
 		startTime := time.Now()
-		output, err := s.shellRunner.Run(hook.Command)
+		var output string
+		var failing []string
+		var err error
+		if hook.Builtin != "" {
+			output, failing, err = s.runBuiltin(hook, files)
+		} else {
+			output, err = s.run(hook.Command, files)
+			failing = filesInOutput(files, output)
+		}
 		duration := time.Since(startTime)
 
 		s.logger.StopSpinner()
@@ -57,25 +115,142 @@ func (s *HookRunnerService) RunHooks(hooks []domain.Hook) []domain.ExecutionResu
 			Success:  err == nil,
 			Output:   output,
 			Duration: duration,
+			ExitCode: exitCode(err),
+		}
+
+		message := hook.OutputRules.OnSuccessMessage
+		if result.Success {
+			s.logger.Print("✅ %s passed (%v)\n", hook.Name, duration.Round(time.Millisecond))
+			failing = files
+		} else {
+			s.logger.Print("❌ %s failed (%v)\n", hook.Name, duration.Round(time.Millisecond))
+			message = hook.OutputRules.OnFailureMessage
+		}
+		if message != "" {
+			result.Message = s.renderMessage(message, result, failing)
+			s.logger.Println(result.Message)
+		}
+		if showOutput(hook.OutputRules, result.Success) {
+			s.logger.Println(formatOutput(output, hook.OutputRules))
 		}
 
 		results = append(results, result)
+	}
 
-		if !result.Success {
-			s.logger.Print("❌ %s failed (%v)\n", hook.Name, duration.Round(time.Millisecond))
-			if hook.OutputRules.OnFailureMessage != "" {
-				s.logger.Println(hook.OutputRules.OnFailureMessage)
-			}
-			if hook.OutputRules.ShowOn == "failure" || hook.OutputRules.ShowOn == "always" {
-				s.logger.Println(output)
-			}
-		} else {
-			s.logger.Print("✅ %s passed (%v)\n", hook.Name, duration.Round(time.Millisecond))
-			if hook.OutputRules.ShowOn == "always" {
-				s.logger.Println(output)
+	return results
+}
+
+// run executes a hook command with the context placeholders expanded. The
+// {files} placeholder expands to the hook's filtered files, shell-quoted.
+
+func (s *HookRunnerService) run(command string, files []string) (string, error) {
+	command = s.expand(command, files)
+	env := append(append([]string{}, s.env...), hookEnvironment(s.context)...)
+	return s.shellRunner.RunWithEnv(command, env)
+}
+
+// renderMessage renders an output rule message of a hook result. The fix
+// command is expanded like the hook would run it, and messages that fail to
+// render are printed as written.
+
+func (s *HookRunnerService) renderMessage(message string, result domain.ExecutionResult, files []string) string {
+	data := domain.NewMessageData(result, s.context.HookType, files)
+	if data.FixCommand != "" {
+		data.FixCommand = s.expand(data.FixCommand, s.hookFiles(result.Hook))
+	} else if s.HasBuiltinFix(result.Hook) {
+		data.FixCommand = strings.TrimSpace("quality-gate --fix " + s.context.HookType)
+	}
+	rendered, err := domain.RenderMessage(message, data)
+	if err != nil {
+		return message
+	}
+	return rendered
+}
+
+// expand expands the context placeholders and {files} in a command.
+
+func (s *HookRunnerService) expand(command string, files []string) string {
+	placeholders := hookPlaceholders(s.context)
+	placeholders["{files}"] = shellQuoteFiles(files)
+	return expandPlaceholders(command, placeholders)
+}
+
+// runBuiltin runs a native check on the hook's files and returns its output
+// and the files with findings.
+
+func (s *HookRunnerService) runBuiltin(hook domain.Hook, files []string) (string, []string, error) {
+	check, ok := builtin.Lookup(hook.Builtin)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown builtin check: %s", hook.Builtin)
+	}
+
+	findings, err := check.Run(builtin.Input{Files: files, Options: hook.Options, ReadFile: s.context.ReadFile, Stat: s.context.Stat})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(findings) > 0 {
+		var failing []string
+		seen := make(map[string]bool)
+		for _, finding := range findings {
+			if !seen[finding.File] {
+				seen[finding.File] = true
+				failing = append(failing, finding.File)
 			}
 		}
+		return builtin.FormatFindings(findings), failing, fmt.Errorf("%s: %d issue(s) found", check.Name, len(findings))
 	}
+	return "", nil, nil
+}
 
-	return results
+// fixBuiltin applies the native fixer of a builtin check to the hook's files.
+
+func (s *HookRunnerService) fixBuiltin(hook domain.Hook, files []string) (string, error) {
+	check, _ := builtin.Lookup(hook.Builtin)
+	// Fixers rewrite the working tree but decide what to fix from the
+	// committed size and mode.
+	fixed, err := check.Fix(builtin.Input{Files: files, Options: hook.Options, Stat: s.context.Stat})
+	if len(fixed) == 0 {
+		return "", err
+	}
+	return "Fixed:\n  " + strings.Join(fixed, "\n  "), err
+}
+
+// hookFiles returns the context files that pass the hook's file filters.
+
+func (s *HookRunnerService) hookFiles(hook domain.Hook) []string {
+	return filterFiles(s.context.Files, hook.Files, hook.Exclude)
+}
+
+func hasFileFilter(hook domain.Hook) bool {
+	return len(hook.Files) > 0 || len(hook.Exclude) > 0
+}
+
+// filesInOutput returns the files named in the output of a failed command,
+// or all of them when the output names none.
+
+func filesInOutput(files []string, output string) []string {
+	var named []string
+	for _, file := range files {
+		if strings.Contains(output, file) {
+			named = append(named, file)
+		}
+	}
+	if len(named) == 0 {
+		return files
+	}
+	return named
+}
+
+// exitCode returns the exit status of a command error: 0 without error, 1
+// when the error carries no exit status.
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
 }
@@ -2,6 +2,9 @@ package service
 
 import (
 	"errors"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/dmux/go-quality-gate/internal/domain"
@@ -80,3 +83,139 @@ func TestHookRunnerService_RunHooks(t *testing.T) {
 		t.Error("Expected hook 2 to fail, but it succeeded")
 	}
 }
+
+func TestHookRunnerService_Builtins(t *testing.T) {
+	tmpDir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to chdir: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.WriteFile("main.go", []byte("package main "), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	mockRunner := &MockShellRunner{Commands: make(map[string]struct {
+		Output string
+		Err    error
+	})}
+	service := NewHookRunnerService(mockRunner, &MockLogger{})
+	service.SetContext(domain.HookContext{HookType: "pre-commit", Files: []string{"main.go"}})
+
+	hooks := []domain.Hook{
+		{Name: "Whitespace", Builtin: "trailing-whitespace"},
+		{Name: "Markdown only", Command: "markdownlint {files}", Files: []string{"*.md"}},
+	}
+
+	results := service.RunHooks(hooks)
+	if results[0].Success || !strings.Contains(results[0].Output, "main.go:1: trailing whitespace") {
+		t.Errorf("Expected trailing whitespace finding, got %+v", results[0])
+	}
+	if !results[1].Skipped || !results[1].Success {
+		t.Errorf("Expected hook without matching files to be skipped, got %+v", results[1])
+	}
+
+	if !service.HasBuiltinFix(hooks[0]) {
+		t.Fatal("Expected trailing-whitespace to be fixable")
+	}
+	if _, err := service.RunFixCommand(hooks[0]); err != nil {
+		t.Fatalf("Fix failed: %v", err)
+	}
+	if data, _ := os.ReadFile("main.go"); string(data) != "package main" {
+		t.Errorf("Expected whitespace to be removed, got %q", data)
+	}
+}
+
+func TestHookRunnerService_Environment(t *testing.T) {
+	mockRunner := &MockShellRunner{Commands: map[string]struct {
+		Output string
+		Err    error
+	}{
+		"make lint": {},
+	}}
+	service := NewHookRunnerService(mockRunner, &MockLogger{})
+	service.SetContext(domain.HookContext{HookType: "pre-commit"})
+	service.SetEnvironment(map[string]string{"LINT_LEVEL": "strict", "GOFLAGS": "-mod=mod", "QUALITY_GATE_HOOK_TYPE": "spoofed"})
+
+	service.RunHooks([]domain.Hook{{Name: "Lint", Command: "make lint"}})
+
+	expected := []string{"GOFLAGS=-mod=mod", "LINT_LEVEL=strict", "QUALITY_GATE_HOOK_TYPE=spoofed", "QUALITY_GATE_HOOK_TYPE=pre-commit"}
+	if len(mockRunner.LastEnv) < len(expected) {
+		t.Fatalf("Expected environment %v, got %v", expected, mockRunner.LastEnv)
+	}
+	for i, entry := range expected {
+		if mockRunner.LastEnv[i] != entry {
+			t.Errorf("Expected env[%d] = %q (context variables last so they win), got %q", i, entry, mockRunner.LastEnv[i])
+		}
+	}
+}
+
+type exitError int
+
+func (e exitError) Error() string { return "exit status " + strconv.Itoa(int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+func TestHookRunnerService_FailureMessage(t *testing.T) {
+	mockRunner := &MockShellRunner{Commands: map[string]struct {
+		Output string
+		Err    error
+	}{
+		"eslint": {"a.ts:1:1 error no-var\nfmt: not found\n", exitError(2)},
+	}}
+	mockLogger := &MockLogger{}
+	service := NewHookRunnerService(mockRunner, mockLogger)
+	service.SetContext(domain.HookContext{HookType: "pre-commit", Files: []string{"a.ts", "b.ts", "c.go"}})
+
+	hooks := []domain.Hook{
+		{
+			Name:       "ESLint",
+			Command:    "eslint",
+			Files:      []string{"*.ts"},
+			FixCommand: "eslint --fix {files}",
+			OutputRules: domain.OutputRules{
+				OnFailureMessage: "{{.Name}} ({{.HookType}}) exited with {{.ExitCode}}. Run `{{.FixCommand}}` to fix {{len .Files}} files: {{join .Files \", \"}}",
+			},
+		},
+		{
+			Name:        "Broken template",
+			Command:     "eslint",
+			Files:       []string{"*.ts"},
+			OutputRules: domain.OutputRules{OnFailureMessage: "Run {{.Fix}}"},
+		},
+	}
+
+	results := service.RunHooks(hooks)
+
+	expected := "ESLint (pre-commit) exited with 2. Run `eslint --fix 'a.ts' 'b.ts'` to fix 1 files: a.ts"
+	if results[0].ExitCode != 2 || results[0].Message != expected {
+		t.Errorf("Expected exit code 2 and message %q, got %d and %q", expected, results[0].ExitCode, results[0].Message)
+	}
+	if results[1].Message != "Run {{.Fix}}" {
+		t.Errorf("Expected a message that fails to render to be printed as written, got %q", results[1].Message)
+	}
+	if len(mockLogger.Messages) != 2 || mockLogger.Messages[0] != expected {
+		t.Errorf("Expected the rendered messages to be printed, got %v", mockLogger.Messages)
+	}
+}
+
+func TestHookRunnerService_BuiltinFailureMessage(t *testing.T) {
+	files := map[string]string{"a.go": "package a \n", "b.go": "package b\n"}
+	service := NewHookRunnerService(&MockShellRunner{}, &MockLogger{})
+	service.SetContext(domain.HookContext{
+		HookType: "pre-commit",
+		Files:    []string{"a.go", "b.go"},
+		ReadFile: func(path string) ([]byte, error) { return []byte(files[path]), nil },
+	})
+
+	results := service.RunHooks([]domain.Hook{{
+		Name:        "Whitespace",
+		Builtin:     "trailing-whitespace",
+		OutputRules: domain.OutputRules{OnFailureMessage: "{{.ExitCode}}: run `{{.FixCommand}}` for {{.Files}}\n{{.OutputTail}}"},
+	}})
+
+	expected := "1: run `quality-gate --fix pre-commit` for [a.go]\na.go:1: trailing whitespace"
+	if results[0].Message != expected {
+		t.Errorf("Expected message %q, got %q", expected, results[0].Message)
+	}
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportSources lists the hook managers whose configuration can be imported.
+var ImportSources = []string{"pre-commit", "lefthook", "husky"}
+
+// ImportResult is a configuration converted from another hook manager.
+type ImportResult struct {
+	From string
+	// Files lists the files read, relative to the project.
+	Files  []string
+	Config *config.Config
+	// Notes lists what could not be translated, or was translated approximately.
+	Notes []string
+}
+
+// Importer converts the configuration of pre-commit, lefthook and husky into
+// quality.yml hook groups and tool entries.
+type Importer struct {
+	projectPath string
+}
+
+// NewImporter creates an Importer reading the configuration files of a project.
+func NewImporter(projectPath string) *Importer {
+	return &Importer{projectPath: projectPath}
+}
+
+// Detect returns the hook managers configured in the project.
+func (i *Importer) Detect() []string {
+	var found []string
+	if _, err := i.findFile(preCommitFiles); err == nil {
+		found = append(found, "pre-commit")
+	}
+	if _, err := i.findFile(lefthookFiles); err == nil {
+		found = append(found, "lefthook")
+	}
+	if i.hasHusky() {
+		found = append(found, "husky")
+	}
+	return found
+}
+
+// Import converts the configuration of a hook manager.
+func (i *Importer) Import(from string) (*ImportResult, error) {
+	result := &ImportResult{From: from, Config: &config.Config{Version: config.CurrentVersion, Hooks: config.Hooks{}}}
+
+	var err error
+	switch from {
+	case "pre-commit":
+		err = i.importPreCommit(result)
+	case "lefthook":
+		err = i.importLefthook(result)
+	case "husky":
+		err = i.importHusky(result)
+	default:
+		return nil, fmt.Errorf("unknown source %q; use %s", from, strings.Join(ImportSources, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var commands []string
+	for _, group := range sortedKeys(result.Config.Hooks) {
+		for _, hookType := range sortedKeys(result.Config.Hooks[group]) {
+			for _, hook := range result.Config.Hooks[group][hookType] {
+				commands = append(commands, hook.Command)
+			}
+		}
+	}
+	result.Config.Tools = append(config.KnownTools(commands...), result.Config.Tools...)
+	result.Config.Tools = uniqueTools(result.Config.Tools)
+	return result, nil
+}
+
+// YAML renders the imported configuration as a quality.yml file. Strings are
+// double quoted, like the configuration generated by --init, and multi-line
+// commands are literal blocks.
+func (r *ImportResult) YAML() ([]byte, error) {
+	var root yaml.Node
+	if err := root.Encode(r.Config); err != nil {
+		return nil, err
+	}
+	quoteValues(&root)
+	root.HeadComment = fmt.Sprintf("yaml-language-server: $schema=%s\nImported from %s by quality-gate import",
+		config.SchemaURL, strings.Join(r.Files, ", "))
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// quoteValues double quotes the string values of a node tree, and writes
+// multi-line strings as literal blocks.
+func quoteValues(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			quoteValues(node.Content[i])
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			quoteValues(child)
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return
+		}
+		if strings.Contains(node.Value, "\n") {
+			node.Style = yaml.LiteralStyle
+		} else {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	}
+}
+
+// addHook appends a hook to a hook group, giving it a unique name.
+func (r *ImportResult) addHook(group, hookType string, hook config.Hook) {
+	if r.Config.Hooks[group] == nil {
+		r.Config.Hooks[group] = map[string][]config.Hook{}
+	}
+	name := hook.Name
+	for n := 2; r.hasHook(hook.Name); n++ {
+		hook.Name = fmt.Sprintf("%s (%d)", name, n)
+	}
+	r.Config.Hooks[group][hookType] = append(r.Config.Hooks[group][hookType], hook)
+}
+
+func (r *ImportResult) hasHook(name string) bool {
+	for _, hookTypes := range r.Config.Hooks {
+		for _, hooks := range hookTypes {
+			for _, hook := range hooks {
+				if hook.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// note records something that could not be translated as written.
+func (r *ImportResult) note(format string, args ...interface{}) {
+	r.Notes = append(r.Notes, fmt.Sprintf(format, args...))
+}
+
+// importHookTypes maps the hook types of other hook managers to those of
+// quality-gate.
+var importHookTypes = map[string]string{
+	"pre-commit": "pre-commit",
+	"commit":     "pre-commit",
+	"pre-push":   "pre-push",
+	"push":       "pre-push",
+}
+
+// findFile returns the first of the files that exists in the project.
+func (i *Importer) findFile(names []string) (string, error) {
+	for _, name := range names {
+		if info, err := os.Stat(filepath.Join(i.projectPath, name)); err == nil && !info.IsDir() {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no %s found", strings.Join(names, " or "))
+}
+
+func (i *Importer) readFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(i.projectPath, name))
+}
+
+// regexGlob matches the regular expressions translatable to globs: an
+// optional directory prefix, optionally followed by .* and a file name, an
+// extension or an alternation of extensions.
+var regexGlob = regexp.MustCompile(`^(\^)?((?:[\w-]+/)*)(\.\*)?(?:([\w-]+)\\\.(\w+)\$|\\\.(\w+)\$|\\\.\(([\w|]+)\)\$)?$`)
+
+// regexToGlobs translates the common forms of file regular expressions to
+// globs: extensions (\.py$, \.(ts|tsx)$), directories (^docs/), files below
+// a directory (^src/.*\.go$), file names (^setup\.py$), file name suffixes
+// (_test\.go$) and alternations of them. It reports false for others.
+func regexToGlobs(pattern string) ([]string, bool) {
+	if strings.HasPrefix(pattern, "(?x)") || pattern == "" {
+		return nil, false
+	}
+	alternatives := []string{pattern}
+	if inner := strings.TrimSuffix(strings.TrimPrefix(pattern, "^("), ")"); inner != pattern && !strings.ContainsAny(inner, "()") {
+		alternatives = strings.Split(inner, "|")
+		for i := range alternatives {
+			alternatives[i] = "^" + alternatives[i]
+		}
+	} else if !strings.ContainsAny(pattern, "()") {
+		alternatives = strings.Split(pattern, "|")
+	}
+
+	var globs []string
+	for _, alternative := range alternatives {
+		match := regexGlob.FindStringSubmatch(alternative)
+		if match == nil {
+			return nil, false
+		}
+		anchored, dir, anyPath := match[1] != "", match[2], match[3] != ""
+		var name string
+		switch {
+		case match[4] != "":
+			name = match[4] + "." + match[5]
+		case match[6] != "":
+			name = "*." + match[6]
+		case match[7] != "":
+			name = "*.{" + strings.ReplaceAll(match[7], "|", ",") + "}"
+		}
+
+		var glob string
+		switch {
+		case dir == "" && name == "":
+			return nil, false
+		case dir == "" && !anchored && match[4] != "":
+			// _test\.go$ matches any file name ending with _test.go
+			glob = "*" + name
+		case dir == "":
+			// Globs without a slash match the file name in any directory
+			glob = name
+		case name == "":
+			glob = dir + "**"
+		case match[4] == "" && !anyPath:
+			// ^src/\.go$ only matches a file named .go
+			return nil, false
+		case anyPath:
+			glob = dir + "**/" + name
+		default:
+			glob = dir + name
+		}
+		if !anchored && dir != "" {
+			glob = "**/" + glob
+		}
+		globs = append(globs, glob)
+	}
+	return globs, true
+}
+
+// uniqueTools drops the tools whose name appeared before.
+func uniqueTools(tools []config.Tool) config.Tools {
+	var unique config.Tools
+	seen := make(map[string]bool)
+	for _, tool := range tools {
+		if !seen[tool.Name] {
+			seen[tool.Name] = true
+			unique = append(unique, tool)
+		}
+	}
+	return unique
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"github.com/dmux/go-quality-gate/internal/shellparse"
+	"gopkg.in/yaml.v3"
+)
+
+var lintStagedFiles = []string{".lintstagedrc", ".lintstagedrc.json", ".lintstagedrc.yaml", ".lintstagedrc.yml"}
+
+// huskyBoilerplate matches the lines of husky scripts that only set husky up.
+var huskyBoilerplate = regexp.MustCompile(`(?m)^(#!.*|\.\s+"?\$\(dirname -- "\$0"\)/_/husky\.sh"?|\.\s+"?\$\(dirname "\$0"\)/_/husky\.sh"?)\s*$\n?`)
+
+// huskyStateful are the commands whose effect lasts for the rest of a
+// script, which must then be kept whole.
+var huskyStateful = map[string]bool{"cd": true, "export": true, "set": true, "source": true, ".": true, "exit": true, "exec": true, "trap": true}
+
+// lintStagedRunners are the commands lint-staged tasks run as written,
+// without resolving them in node_modules/.bin.
+var lintStagedRunners = map[string]bool{"npx": true, "npm": true, "yarn": true, "pnpm": true, "node": true, "bash": true, "sh": true, "go": true, "python": true}
+
+type packageJSON struct {
+	Husky struct {
+		Hooks map[string]string `yaml:"hooks"`
+	} `yaml:"husky"`
+	LintStaged yaml.Node `yaml:"lint-staged"`
+}
+
+// hasHusky reports whether the project has husky hooks, in .husky/ or, for
+// husky 4, in package.json.
+func (i *Importer) hasHusky() bool {
+	if entries, err := os.ReadDir(filepath.Join(i.projectPath, ".husky")); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				return true
+			}
+		}
+	}
+	pkg, _ := i.packageJSON()
+	return pkg != nil && len(pkg.Husky.Hooks) > 0
+}
+
+// importHusky converts the .husky/pre-commit and .husky/pre-push scripts, or
+// the hooks of husky 4 in package.json. Scripts become one hook per
+// command, and lint-staged runs become one hook per lint-staged task.
+func (i *Importer) importHusky(result *ImportResult) error {
+	pkg, err := i.packageJSON()
+	if err != nil {
+		return err
+	}
+
+	scripts := map[string]string{}
+	if entries, err := os.ReadDir(filepath.Join(i.projectPath, ".husky")); err == nil {
+		for _, entry := range entries {
+			name := filepath.ToSlash(filepath.Join(".husky", entry.Name()))
+			if entry.IsDir() {
+				continue
+			}
+			if entry.Name() != "pre-commit" && entry.Name() != "pre-push" {
+				result.note("%s: only pre-commit and pre-push hooks are supported", name)
+				continue
+			}
+			data, err := i.readFile(name)
+			if err != nil {
+				return err
+			}
+			result.Files = append(result.Files, name)
+			scripts[entry.Name()] = string(data)
+		}
+	}
+	if len(scripts) == 0 && pkg != nil {
+		for _, hook := range sortedKeys(pkg.Husky.Hooks) {
+			if hook != "pre-commit" && hook != "pre-push" {
+				result.note("package.json husky.hooks.%s: only pre-commit and pre-push hooks are supported", hook)
+				continue
+			}
+			scripts[hook] = pkg.Husky.Hooks[hook]
+		}
+		if len(scripts) > 0 {
+			result.Files = append(result.Files, "package.json")
+		}
+	}
+	if len(scripts) == 0 {
+		return fmt.Errorf("no husky pre-commit or pre-push hooks found")
+	}
+
+	for _, hookType := range sortedKeys(scripts) {
+		if err := i.importHuskyScript(result, hookType, scripts[hookType], pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importHuskyScript converts a hook script. Scripts that set variables,
+// change directory or declare functions are kept whole, since their
+// commands depend on each other.
+func (i *Importer) importHuskyScript(result *ImportResult, hookType, script string, pkg *packageJSON) error {
+	script = strings.TrimSpace(huskyBoilerplate.ReplaceAllString(script, ""))
+	if script == "" {
+		return nil
+	}
+	parsed, err := shellparse.Parse(script)
+	if err != nil || huskyStatefulScript(parsed) {
+		if err != nil {
+			result.note("husky %s: the script could not be parsed (%v) and was kept whole", hookType, err)
+		}
+		result.addHook("husky", hookType, config.Hook{Name: hookType, Command: script + "\n"})
+		return nil
+	}
+
+	for _, command := range stmtSources(script, parsed.Stmts) {
+		if isLintStaged(command) {
+			if err := i.importLintStaged(result, hookType, pkg); err != nil {
+				return err
+			}
+			continue
+		}
+		result.addHook("husky", hookType, config.Hook{Name: huskyHookName(command), Command: command})
+	}
+	return nil
+}
+
+// importLintStaged converts the lint-staged tasks into hooks, each run with
+// the staged files matching its glob.
+func (i *Importer) importLintStaged(result *ImportResult, hookType string, pkg *packageJSON) error {
+	var tasks *yaml.Node
+	if pkg != nil && !pkg.LintStaged.IsZero() {
+		tasks = &pkg.LintStaged
+	} else if name, err := i.findFile(lintStagedFiles); err == nil {
+		data, err := i.readFile(name)
+		if err != nil {
+			return err
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		result.Files = append(result.Files, name)
+		if len(doc.Content) > 0 {
+			tasks = doc.Content[0]
+		}
+	}
+	if tasks == nil || tasks.Kind != yaml.MappingNode {
+		result.note("lint-staged: no JSON or YAML configuration found; JavaScript configurations are not supported")
+		return nil
+	}
+
+	for j := 0; j+1 < len(tasks.Content); j += 2 {
+		glob, value := tasks.Content[j].Value, tasks.Content[j+1]
+		var commands []string
+		if err := value.Decode(&commands); err != nil {
+			var command string
+			if err := value.Decode(&command); err != nil {
+				result.note("lint-staged %q: the task could not be translated", glob)
+				continue
+			}
+			commands = []string{command}
+		}
+		for _, command := range commands {
+			hook, ok := lintStagedHook(glob, command)
+			if !ok {
+				result.note("lint-staged %q: %q was skipped; quality-gate does not stage files", glob, command)
+				continue
+			}
+			result.addHook("lint-staged", hookType, hook)
+		}
+	}
+	return nil
+}
+
+// lintStagedHook converts a lint-staged task. Fixing tasks are split into a
+// check and a fix command.
+func lintStagedHook(glob, command string) (config.Hook, bool) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 || (fields[0] == "git" && len(fields) > 1 && fields[1] == "add") {
+		return config.Hook{}, false
+	}
+	tool := fields[0]
+	if !lintStagedRunners[tool] {
+		command = "npx " + command
+	} else if tool == "npx" && len(fields) > 1 {
+		tool = fields[1]
+	}
+
+	hook := config.Hook{Name: fmt.Sprintf("%s (%s)", tool, glob), Files: []string{glob}}
+	check := command
+	switch {
+	case slices.Contains(fields, "--fix"):
+		check = strings.Replace(command, " --fix", "", 1)
+	case slices.Contains(fields, "--write") && tool == "prettier":
+		check = strings.Replace(command, " --write", " --check", 1)
+	}
+	hook.Command = check + " {files}"
+	if check != command {
+		hook.FixCommand = command + " {files}"
+	}
+	return hook, true
+}
+
+// packageJSON reads the husky and lint-staged settings of package.json, or
+// returns nil when the project has none.
+func (i *Importer) packageJSON() (*packageJSON, error) {
+	data, err := i.readFile("package.json")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pkg packageJSON
+	if err := yaml.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("package.json: %w", err)
+	}
+	return &pkg, nil
+}
+
+// huskyStatefulScript reports whether commands of a script affect the ones
+// after them, including commands nested in conditions, lists and compound
+// commands such as a "[ -n "$CI" ] && exit 0" guard.
+func huskyStatefulScript(script *shellparse.Script) bool {
+	stateful := false
+	for _, stmt := range script.Stmts {
+		shellparse.Walk(stmt, func(n shellparse.Node) bool {
+			if _, ok := n.(*shellparse.FuncDecl); ok {
+				stateful = true
+			}
+			return !stateful
+		})
+		for _, cmd := range shellparse.SimpleCommands(stmt) {
+			if len(cmd.Args) == 0 || huskyStateful[cmd.Name()] {
+				stateful = true
+			}
+		}
+	}
+	return stateful
+}
+
+// stmtSources returns the source of each statement of a script, without
+// the comments and separators between them.
+func stmtSources(src string, stmts []*shellparse.Stmt) []string {
+	var sources []string
+	for k, stmt := range stmts {
+		end := len(src)
+		if k+1 < len(stmts) {
+			end = stmts[k+1].Pos.Offset
+		}
+		var lines []string
+		for _, line := range strings.Split(src[stmt.Pos.Offset:end], "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+				lines = append(lines, line)
+			}
+		}
+		source := strings.TrimSpace(strings.Join(lines, "\n"))
+		sources = append(sources, strings.TrimSpace(strings.TrimSuffix(source, ";")))
+	}
+	return sources
+}
+
+// isLintStaged reports whether a command runs lint-staged.
+func isLintStaged(command string) bool {
+	switch strings.Join(strings.Fields(command), " ") {
+	case "lint-staged", "npx lint-staged", "npx --no -- lint-staged", "npx --no-install lint-staged",
+		"npm exec lint-staged", "yarn lint-staged", "pnpm lint-staged", "pnpm exec lint-staged", "bunx lint-staged":
+		return true
+	}
+	return false
+}
+
+// huskyHookName names a hook after its command: the script it runs with a
+// package manager, or the command itself.
+func huskyHookName(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) >= 3 && lintStagedRunners[fields[0]] && fields[1] == "run" {
+		return fields[2]
+	}
+	if len(command) > 40 || strings.Contains(command, "\n") {
+		return fields[0]
+	}
+	return command
+}
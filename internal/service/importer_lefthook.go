@@ -0,0 +1,235 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"github.com/dmux/go-quality-gate/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+var lefthookFiles = []string{"lefthook.yml", ".lefthook.yml", "lefthook.yaml", ".lefthook.yaml"}
+
+// lefthookJob is a command, a script or a job of a lefthook hook.
+type lefthookJob struct {
+	Name       string            `yaml:"name"`
+	Run        string            `yaml:"run"`
+	Script     string            `yaml:"script"`
+	Runner     string            `yaml:"runner"`
+	Glob       yaml.Node         `yaml:"glob"`
+	Exclude    yaml.Node         `yaml:"exclude"`
+	Root       string            `yaml:"root"`
+	FailText   string            `yaml:"fail_text"`
+	Env        map[string]string `yaml:"env"`
+	StageFixed bool              `yaml:"stage_fixed"`
+	Files      string            `yaml:"files"`
+	Skip       yaml.Node         `yaml:"skip"`
+	Only       yaml.Node         `yaml:"only"`
+	Group      *struct {
+		Jobs []lefthookJob `yaml:"jobs"`
+	} `yaml:"group"`
+	Interactive bool `yaml:"interactive"`
+	UseStdin    bool `yaml:"use_stdin"`
+}
+
+// lefthookPlaceholders maps the file placeholders of lefthook to the
+// commands of quality-gate.
+var lefthookPlaceholders = strings.NewReplacer(
+	"{staged_files}", "{files}",
+	"{push_files}", "{files}",
+	"{all_files}", "$(git ls-files)",
+)
+
+// lefthookSettings are top-level keys about how lefthook itself runs.
+var lefthookSettings = map[string]bool{
+	"colors": true, "no_tty": true, "output": true, "skip_output": true, "assert_lefthook_installed": true,
+	"min_version": true, "lefthook": true, "rc": true, "source_dir": true, "source_dir_local": true, "templates": true,
+}
+
+// importLefthook converts lefthook.yml. The commands, scripts and jobs of
+// the pre-commit and pre-push hooks become hooks of a "lefthook" group, in
+// their order in the file.
+func (i *Importer) importLefthook(result *ImportResult) error {
+	name, err := i.findFile(lefthookFiles)
+	if err != nil {
+		return err
+	}
+	data, err := i.readFile(name)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	result.Files = append(result.Files, name)
+	if local, err := i.findFile([]string{"lefthook-local.yml", ".lefthook-local.yml"}); err == nil {
+		result.note("%s: local overrides were not imported; move them to quality.local.yml", local)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+
+	root := doc.Content[0]
+	sourceDir := ".lefthook"
+	if value := mappingValue(root, "source_dir"); value != nil {
+		sourceDir = strings.TrimSuffix(value.Value, "/")
+	}
+	for j := 0; j+1 < len(root.Content); j += 2 {
+		key, value := root.Content[j].Value, root.Content[j+1]
+		hookType, ok := importHookTypes[key]
+		switch {
+		case lefthookSettings[key]:
+		case ok && value.Kind == yaml.MappingNode:
+			if err := result.importLefthookHook(name, sourceDir, key, hookType, value); err != nil {
+				return err
+			}
+		case key == "extends" || key == "remotes":
+			result.note("%s: shared configurations were not imported; use extends in quality.yml", key)
+		default:
+			result.note("%s: unsupported hook or key", key)
+		}
+	}
+	return nil
+}
+
+// importLefthookHook converts the commands, scripts and jobs of a hook.
+func (r *ImportResult) importLefthookHook(file, sourceDir, key, hookType string, hook *yaml.Node) error {
+	var exclude []string
+	for j := 0; j+1 < len(hook.Content); j += 2 {
+		setting, value := hook.Content[j].Value, hook.Content[j+1]
+		switch setting {
+		case "commands", "scripts":
+			for k := 0; k+1 < len(value.Content); k += 2 {
+				var job lefthookJob
+				if err := value.Content[k+1].Decode(&job); err != nil {
+					return fmt.Errorf("%s:%d: %w", file, value.Content[k+1].Line, err)
+				}
+				job.Name = value.Content[k].Value
+				if setting == "scripts" {
+					job.Script = job.Name
+				}
+				r.importLefthookJob(sourceDir, key, hookType, job, exclude)
+			}
+		case "jobs":
+			var jobs []lefthookJob
+			if err := value.Decode(&jobs); err != nil {
+				return fmt.Errorf("%s:%d: %w", file, value.Line, err)
+			}
+			for _, job := range jobs {
+				r.importLefthookJob(sourceDir, key, hookType, job, exclude)
+			}
+		case "exclude":
+			exclude = r.lefthookGlobs(key, "exclude", *value)
+		case "parallel", "follow", "piped":
+		default:
+			r.note("%s.%s: unsupported setting", key, setting)
+		}
+	}
+	return nil
+}
+
+// importLefthookJob converts a command, a script or a job.
+func (r *ImportResult) importLefthookJob(sourceDir, key, hookType string, job lefthookJob, exclude []string) {
+	if job.Group != nil {
+		for _, nested := range job.Group.Jobs {
+			r.importLefthookJob(sourceDir, key, hookType, nested, exclude)
+		}
+		return
+	}
+	name := job.Name
+	if name == "" {
+		name = firstWord(job.Run + job.Script)
+	}
+	id := key + "." + name
+
+	command := lefthookPlaceholders.Replace(job.Run)
+	if job.Script != "" {
+		command = path.Join(sourceDir, key, job.Script)
+		if job.Runner != "" {
+			command = job.Runner + " " + command
+		}
+	}
+	if command == "" {
+		r.note("%s: nothing to run", id)
+		return
+	}
+	if job.Files != "" {
+		r.note("%s: the files command %q was not translated; {files} are the staged files", id, job.Files)
+	}
+
+	hook := config.Hook{Name: name, Command: command}
+	hook.Files = r.lefthookGlobs(id, "glob", job.Glob)
+	hook.Exclude = append(r.lefthookGlobs(id, "exclude", job.Exclude), exclude...)
+	if job.Root != "" {
+		dir := strings.TrimSuffix(job.Root, "/")
+		hook.Command = "cd " + shellWord(dir) + " && " + hook.Command
+		if len(hook.Files) == 0 {
+			hook.Files = []string{dir + "/"}
+		} else {
+			for k, glob := range hook.Files {
+				hook.Files[k] = dir + "/**/" + strings.TrimPrefix(glob, "**/")
+			}
+		}
+		if strings.Contains(hook.Command, "{files}") {
+			r.note("%s: {files} are relative to the repository root, not to %s", id, dir)
+		}
+	}
+	if job.FailText != "" {
+		hook.OutputRules.OnFailureMessage = domain.EscapeMessage(job.FailText)
+	}
+	for _, name := range sortedKeys(job.Env) {
+		if current, ok := r.Config.Env[name]; ok && current != job.Env[name] {
+			r.note("%s: env %s=%s conflicts with %s=%s imported before", id, name, job.Env[name], name, current)
+			continue
+		}
+		if r.Config.Env == nil {
+			r.Config.Env = map[string]string{}
+		}
+		r.Config.Env[name] = job.Env[name]
+	}
+	if job.StageFixed {
+		r.note("%s: stage_fixed is not supported; stage the files it changes, or move the command to fix_command", id)
+	}
+	if !job.Skip.IsZero() || !job.Only.IsZero() {
+		r.note("%s: skip and only conditions were not translated", id)
+	}
+	if job.Interactive || job.UseStdin {
+		r.note("%s: interactive commands are not supported", id)
+	}
+	r.addHook("lefthook", hookType, hook)
+}
+
+// lefthookGlobs converts a glob or exclude setting: a glob, a list of globs
+// or, for exclude in older lefthook versions, a regular expression.
+func (r *ImportResult) lefthookGlobs(id, setting string, node yaml.Node) []string {
+	switch {
+	case node.IsZero():
+		return nil
+	case node.Kind == yaml.SequenceNode:
+		var globs []string
+		if err := node.Decode(&globs); err == nil {
+			return globs
+		}
+	case node.Kind == yaml.ScalarNode && setting == "glob":
+		return []string{node.Value}
+	case node.Kind == yaml.ScalarNode:
+		if globs, ok := regexToGlobs(node.Value); ok {
+			return globs
+		}
+	}
+	r.note("%s: the %s pattern %q could not be translated to globs", id, setting, node.Value)
+	return nil
+}
+
+// mappingValue returns the value of a key of a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+var preCommitFiles = []string{".pre-commit-config.yaml", ".pre-commit-config.yml"}
+
+type preCommitConfig struct {
+	DefaultStages []string        `yaml:"default_stages"`
+	Exclude       string          `yaml:"exclude"`
+	Files         string          `yaml:"files"`
+	FailFast      bool            `yaml:"fail_fast"`
+	Repos         []preCommitRepo `yaml:"repos"`
+	CI            map[string]any  `yaml:"ci"`
+	Rest          map[string]any  `yaml:",inline"`
+}
+
+type preCommitRepo struct {
+	Repo  string          `yaml:"repo"`
+	Hooks []preCommitHook `yaml:"hooks"`
+}
+
+type preCommitHook struct {
+	ID             string   `yaml:"id"`
+	Name           string   `yaml:"name"`
+	Entry          string   `yaml:"entry"`
+	Language       string   `yaml:"language"`
+	Args           []string `yaml:"args"`
+	Files          string   `yaml:"files"`
+	Exclude        string   `yaml:"exclude"`
+	Types          []string `yaml:"types"`
+	TypesOr        []string `yaml:"types_or"`
+	ExcludeTypes   []string `yaml:"exclude_types"`
+	Stages         []string `yaml:"stages"`
+	PassFilenames  *bool    `yaml:"pass_filenames"`
+	AlwaysRun      bool     `yaml:"always_run"`
+	AdditionalDeps []string `yaml:"additional_dependencies"`
+}
+
+// preCommitHookID is how a hook of a known pre-commit repository translates:
+// to a builtin check, or to a command run with the hook's args and, unless
+// passFiles is false, the files.
+type preCommitHookID struct {
+	name    string
+	builtin string
+	command string
+	// fix is the command that repairs the files, run with the same args.
+	fix       string
+	files     []string
+	passFiles bool
+}
+
+// preCommitHookIDs maps the hook IDs of well-known pre-commit repositories
+// to their quality-gate equivalent.
+var preCommitHookIDs = map[string]preCommitHookID{
+	"trailing-whitespace":                  {name: "Trailing whitespace", builtin: "trailing-whitespace"},
+	"end-of-file-fixer":                    {name: "End of file", builtin: "end-of-file"},
+	"check-merge-conflict":                 {name: "Merge conflicts", builtin: "merge-conflict"},
+	"mixed-line-ending":                    {name: "Line endings", builtin: "line-endings"},
+	"check-added-large-files":              {name: "Large files", builtin: "large-files"},
+	"check-case-conflict":                  {name: "Case conflicts", builtin: "case-conflict"},
+	"check-symlinks":                       {name: "Broken symlinks", builtin: "broken-symlinks"},
+	"check-shebang-scripts-are-executable": {name: "Executable scripts", builtin: "shebang-executable"},
+	"detect-private-key":                   {name: "Secrets", builtin: "secrets"},
+	"detect-aws-credentials":               {name: "Secrets", builtin: "secrets"},
+	"gitleaks":                             {name: "Gitleaks", command: "gitleaks protect --staged --redact"},
+	"black":                                {name: "Black", command: "black --check", fix: "black", files: []string{"*.{py,pyi}"}, passFiles: true},
+	"isort":                                {name: "isort", command: "isort --check-only", fix: "isort", files: []string{"*.{py,pyi}"}, passFiles: true},
+	"flake8":                               {name: "Flake8", command: "flake8", files: []string{"*.py"}, passFiles: true},
+	"mypy":                                 {name: "Mypy", command: "mypy", files: []string{"*.{py,pyi}"}, passFiles: true},
+	"ruff":                                 {name: "Ruff", command: "ruff check", fix: "ruff check --fix", files: []string{"*.{py,pyi}"}, passFiles: true},
+	"ruff-check":                           {name: "Ruff", command: "ruff check", fix: "ruff check --fix", files: []string{"*.{py,pyi}"}, passFiles: true},
+	"ruff-format":                          {name: "Ruff format", command: "ruff format --check", fix: "ruff format", files: []string{"*.{py,pyi}"}, passFiles: true},
+	"prettier":                             {name: "Prettier", command: "npx prettier --check", fix: "npx prettier --write", passFiles: true},
+	"eslint":                               {name: "ESLint", command: "npx eslint", fix: "npx eslint --fix", files: []string{"*.{js,jsx,ts,tsx}"}, passFiles: true},
+	"golangci-lint":                        {name: "Golangci-lint", command: "golangci-lint run", files: []string{"*.go"}},
+	"go-fmt":                               {name: "Gofmt", command: `test -z "$(gofmt -l {files})"`, fix: "gofmt -w", files: []string{"*.go"}},
+	"go-vet":                               {name: "Go vet", command: "go vet ./...", files: []string{"*.go"}},
+	"shellcheck":                           {name: "ShellCheck", command: "shellcheck", files: []string{"*.sh"}, passFiles: true},
+	"fmt":                                  {name: "Rustfmt", command: "cargo fmt -- --check", fix: "cargo fmt", files: []string{"*.rs"}},
+	"clippy":                               {name: "Clippy", command: "cargo clippy -- -D warnings", files: []string{"*.rs"}},
+	"codespell":                            {name: "Codespell", command: "codespell", fix: "codespell --write-changes", passFiles: true},
+	"hadolint":                             {name: "Hadolint", command: "hadolint", files: []string{"Dockerfile*"}, passFiles: true},
+	"hadolint-docker":                      {name: "Hadolint", command: "hadolint", files: []string{"Dockerfile*"}, passFiles: true},
+}
+
+// preCommitTypes maps the file types of pre-commit (identify) to globs.
+var preCommitTypes = map[string]string{
+	"python":     "*.{py,pyi}",
+	"pyi":        "*.pyi",
+	"javascript": "*.{js,mjs,cjs}",
+	"jsx":        "*.jsx",
+	"ts":         "*.ts",
+	"tsx":        "*.tsx",
+	"go":         "*.go",
+	"rust":       "*.rs",
+	"shell":      "*.{sh,bash}",
+	"bash":       "*.bash",
+	"yaml":       "*.{yml,yaml}",
+	"json":       "*.json",
+	"toml":       "*.toml",
+	"markdown":   "*.md",
+	"dockerfile": "Dockerfile*",
+	"php":        "*.php",
+	"ruby":       "*.rb",
+	"java":       "*.java",
+	"css":        "*.css",
+	"scss":       "*.scss",
+	"html":       "*.html",
+	"sql":        "*.sql",
+	"terraform":  "*.tf",
+}
+
+// preCommitAnyTypes match any file, or are already skipped by quality-gate.
+var preCommitAnyTypes = map[string]bool{"file": true, "text": true, "non-executable": true}
+
+// importPreCommit converts .pre-commit-config.yaml. Hooks of well-known
+// repositories map to builtins or commands, local hooks run their entry, and
+// file regular expressions become globs where they can.
+func (i *Importer) importPreCommit(result *ImportResult) error {
+	name, err := i.findFile(preCommitFiles)
+	if err != nil {
+		return err
+	}
+	data, err := i.readFile(name)
+	if err != nil {
+		return err
+	}
+	var cfg preCommitConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	result.Files = append(result.Files, name)
+
+	if cfg.FailFast {
+		result.note("fail_fast: quality-gate runs every hook and reports all failures")
+	}
+	if cfg.CI != nil {
+		result.note("ci: pre-commit.ci settings have no equivalent")
+	}
+	for _, key := range sortedKeys(cfg.Rest) {
+		if key != "default_install_hook_types" && key != "default_language_version" && key != "minimum_pre_commit_version" {
+			result.note("%s: unsupported top-level key", key)
+		}
+	}
+	var globalExclude []string
+	if cfg.Exclude != "" {
+		if globs, ok := regexToGlobs(cfg.Exclude); ok {
+			globalExclude = globs
+		} else {
+			result.note("exclude: the pattern %q could not be translated to globs; add it to the exclude of each hook", cfg.Exclude)
+		}
+	}
+	var globalFiles []string
+	if cfg.Files != "" {
+		if globs, ok := regexToGlobs(cfg.Files); ok {
+			globalFiles = globs
+		} else {
+			result.note("files: the pattern %q could not be translated to globs; add it to the files of each hook", cfg.Files)
+		}
+	}
+
+	for _, repo := range cfg.Repos {
+		group := preCommitGroup(repo.Repo)
+		for _, hook := range repo.Hooks {
+			converted, ok := result.convertPreCommitHook(repo, hook)
+			if !ok {
+				continue
+			}
+			if len(converted.Files) == 0 {
+				converted.Files = globalFiles
+			}
+			converted.Exclude = append(converted.Exclude, globalExclude...)
+
+			stages := hook.Stages
+			if len(stages) == 0 {
+				stages = cfg.DefaultStages
+			}
+			for _, hookType := range result.preCommitHookTypes(hook.ID, stages) {
+				result.addHook(group, hookType, converted)
+			}
+		}
+	}
+	return nil
+}
+
+// convertPreCommitHook converts a hook, or reports false when it cannot.
+func (r *ImportResult) convertPreCommitHook(repo preCommitRepo, hook preCommitHook) (config.Hook, bool) {
+	var converted config.Hook
+	known, isKnown := preCommitHookIDs[hook.ID]
+	switch {
+	case repo.Repo == "meta":
+		r.note("%s: meta hooks check the pre-commit configuration itself and were skipped", hook.ID)
+		return converted, false
+	case repo.Repo == "local" || hook.Entry != "":
+		if hook.Entry == "" {
+			r.note("%s: local hook without an entry was skipped", hook.ID)
+			return converted, false
+		}
+		if hook.Language != "" && hook.Language != "system" && hook.Language != "script" && hook.Language != "unsupported" && hook.Language != "unsupported_script" {
+			r.note("%s: language %s environments are not managed by quality-gate; make sure %q is installed", hook.ID, hook.Language, firstWord(hook.Entry))
+		}
+		converted.Name = hook.ID
+		converted.Command = preCommitCommand(hook.Entry, hook.Args, hook.PassFilenames == nil || *hook.PassFilenames)
+	case !isKnown:
+		r.note("%s (%s): unknown hook ID; add an equivalent command by hand", hook.ID, repo.Repo)
+		return converted, false
+	case known.builtin != "":
+		if known.builtin == "secrets" && r.hasBuiltin("secrets") {
+			return converted, false
+		}
+		converted.Name = known.name
+		converted.Builtin = known.builtin
+		converted.Options = r.preCommitOptions(hook)
+	default:
+		converted.Name = known.name
+		converted.Files = known.files
+		passFiles := known.passFiles
+		if hook.PassFilenames != nil {
+			passFiles = passFiles && *hook.PassFilenames
+		}
+		args := hook.Args
+		if known.fix != "" {
+			// The check runs without the args that make it fix files
+			args = slices.DeleteFunc(slices.Clone(args), func(arg string) bool {
+				return arg == "--fix" || arg == "--write" || arg == "--exit-non-zero-on-fix"
+			})
+			converted.FixCommand = preCommitCommand(known.fix, args, true)
+		}
+		converted.Command = preCommitCommand(known.command, args, passFiles)
+	}
+	if hook.Name != "" {
+		converted.Name = hook.Name
+	}
+
+	if hook.Files != "" {
+		if globs, ok := regexToGlobs(hook.Files); ok {
+			converted.Files = globs
+		} else {
+			r.note("%s: the files pattern %q could not be translated to globs", hook.ID, hook.Files)
+		}
+	}
+	if types := append(append([]string{}, hook.Types...), hook.TypesOr...); len(types) > 0 && hook.Files == "" {
+		if globs := r.preCommitTypeGlobs(hook.ID, types); len(globs) > 0 {
+			converted.Files = globs
+		}
+	}
+	if hook.Exclude != "" {
+		if globs, ok := regexToGlobs(hook.Exclude); ok {
+			converted.Exclude = globs
+		} else {
+			r.note("%s: the exclude pattern %q could not be translated to globs", hook.ID, hook.Exclude)
+		}
+	}
+	if len(hook.ExcludeTypes) > 0 {
+		r.note("%s: exclude_types %v were not translated", hook.ID, hook.ExcludeTypes)
+	}
+	if len(hook.AdditionalDeps) > 0 {
+		r.note("%s: additional_dependencies %v must be installed in the project", hook.ID, hook.AdditionalDeps)
+	}
+	if hook.AlwaysRun {
+		r.note("%s: always_run has no equivalent; the hook runs when files match", hook.ID)
+	}
+	return converted, true
+}
+
+// preCommitOptions converts the args of a hook mapped to a builtin.
+func (r *ImportResult) preCommitOptions(hook preCommitHook) map[string]string {
+	var options map[string]string
+	for _, arg := range hook.Args {
+		if hook.ID == "check-added-large-files" && strings.HasPrefix(arg, "--maxkb=") {
+			options = map[string]string{"max_kb": strings.TrimPrefix(arg, "--maxkb=")}
+			continue
+		}
+		r.note("%s: argument %q was not translated", hook.ID, arg)
+	}
+	return options
+}
+
+// preCommitTypeGlobs converts file types to globs.
+func (r *ImportResult) preCommitTypeGlobs(id string, types []string) []string {
+	var globs []string
+	for _, t := range types {
+		if glob, ok := preCommitTypes[t]; ok {
+			globs = append(globs, glob)
+		} else if !preCommitAnyTypes[t] {
+			r.note("%s: file type %q was not translated", id, t)
+		}
+	}
+	return globs
+}
+
+// preCommitHookTypes converts pre-commit stages to hook types.
+func (r *ImportResult) preCommitHookTypes(id string, stages []string) []string {
+	if len(stages) == 0 {
+		return []string{"pre-commit"}
+	}
+	var hookTypes []string
+	for _, stage := range stages {
+		hookType, ok := importHookTypes[stage]
+		if !ok {
+			r.note("%s: stage %s is not supported by quality-gate", id, stage)
+			continue
+		}
+		if !slices.Contains(hookTypes, hookType) {
+			hookTypes = append(hookTypes, hookType)
+		}
+	}
+	return hookTypes
+}
+
+func (r *ImportResult) hasBuiltin(name string) bool {
+	for _, hookTypes := range r.Config.Hooks {
+		for _, hooks := range hookTypes {
+			for _, hook := range hooks {
+				if hook.Builtin == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// preCommitCommand builds a command from an entry, its args and, when
+// passFiles is set, the files. Entries with their own {files} placeholder
+// keep it where it is.
+func preCommitCommand(entry string, args []string, passFiles bool) string {
+	words := []string{entry}
+	for _, arg := range args {
+		words = append(words, shellWord(arg))
+	}
+	if passFiles && !strings.Contains(entry, "{files}") {
+		words = append(words, "{files}")
+	}
+	return strings.Join(words, " ")
+}
+
+// preCommitGroup names the hook group of a repository after it.
+func preCommitGroup(repo string) string {
+	switch repo {
+	case "local":
+		return "local"
+	case "https://github.com/pre-commit/pre-commit-hooks":
+		return "hygiene"
+	}
+	name := path.Base(strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git"))
+	for _, prefix := range []string{"pre-commit-", "mirrors-"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	for _, suffix := range []string{"-pre-commit", "-precommit"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
+
+// shellWord quotes a string as a single shell word when it needs quoting.
+func shellWord(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=+./:,@%") == "" {
+		return s
+	}
+	return shellQuote(s)
+}
+
+func firstWord(command string) string {
+	if fields := strings.Fields(command); len(fields) > 0 {
+		return fields[0]
+	}
+	return command
+}
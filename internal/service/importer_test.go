@@ -0,0 +1,332 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dmux/go-quality-gate/internal/config"
+)
+
+func writeProjectFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// importedHooks lists the imported hooks of a hook type as "group/name: command".
+func importedHooks(cfg *config.Config, hookType string) []string {
+	var hooks []string
+	for _, group := range sortedKeys(cfg.Hooks) {
+		for _, hook := range cfg.Hooks[group][hookType] {
+			run := hook.Command
+			if hook.Builtin != "" {
+				run = "builtin " + hook.Builtin
+			}
+			hooks = append(hooks, group+"/"+hook.Name+": "+run)
+		}
+	}
+	return hooks
+}
+
+func TestImporter_PreCommit(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		".pre-commit-config.yaml": `exclude: ^vendor/
+fail_fast: true
+repos:
+  - repo: https://github.com/pre-commit/pre-commit-hooks
+    rev: v4.6.0
+    hooks:
+      - id: trailing-whitespace
+      - id: check-added-large-files
+        args: [--maxkb=1000]
+      - id: detect-private-key
+      - id: detect-aws-credentials
+      - id: check-yaml
+  - repo: https://github.com/psf/black
+    rev: 24.4.2
+    hooks:
+      - id: black
+        args: [--line-length=100]
+        exclude: ^migrations/
+  - repo: https://github.com/pre-commit/mirrors-prettier
+    rev: v3.1.0
+    hooks:
+      - id: prettier
+        types_or: [javascript, ts, yaml]
+  - repo: local
+    hooks:
+      - id: pytest
+        name: Unit tests
+        entry: pytest -q
+        language: system
+        pass_filenames: false
+        stages: [pre-push]
+      - id: lint-docs
+        entry: ./scripts/lint-docs.sh
+        language: script
+        files: \.(md|rst)$
+  - repo: meta
+    hooks:
+      - id: check-hooks-apply
+`,
+	})
+
+	importer := NewImporter(dir)
+	if detected := importer.Detect(); !reflect.DeepEqual(detected, []string{"pre-commit"}) {
+		t.Errorf("Expected pre-commit to be detected, got %v", detected)
+	}
+	result, err := importer.Import("pre-commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"black/Black: black --check --line-length=100 {files}",
+		"hygiene/Trailing whitespace: builtin trailing-whitespace",
+		"hygiene/Large files: builtin large-files",
+		"hygiene/Secrets: builtin secrets",
+		"local/lint-docs: ./scripts/lint-docs.sh {files}",
+		"prettier/Prettier: npx prettier --check {files}",
+	}
+	if hooks := importedHooks(result.Config, "pre-commit"); !reflect.DeepEqual(hooks, expected) {
+		t.Errorf("Expected pre-commit hooks\n%q\ngot\n%q", expected, hooks)
+	}
+	if hooks := importedHooks(result.Config, "pre-push"); !reflect.DeepEqual(hooks, []string{"local/Unit tests: pytest -q"}) {
+		t.Errorf("Unexpected pre-push hooks %q", hooks)
+	}
+
+	black := result.Config.Hooks["black"]["pre-commit"][0]
+	if black.FixCommand != "black --line-length=100 {files}" || !reflect.DeepEqual(black.Files, []string{"*.{py,pyi}"}) ||
+		!reflect.DeepEqual(black.Exclude, []string{"migrations/**", "vendor/**"}) {
+		t.Errorf("Unexpected Black hook %+v", black)
+	}
+	if options := result.Config.Hooks["hygiene"]["pre-commit"][1].Options; options["max_kb"] != "1000" {
+		t.Errorf("Expected --maxkb to become the max_kb option, got %v", options)
+	}
+	if files := result.Config.Hooks["prettier"]["pre-commit"][0].Files; !reflect.DeepEqual(files, []string{"*.{js,mjs,cjs}", "*.ts", "*.{yml,yaml}"}) {
+		t.Errorf("Expected types to become globs, got %v", files)
+	}
+	// detect-private-key counts as a security hook
+	for _, issue := range config.NewConfigValidator(result.Config).Validate().Errors {
+		if issue.Rule == config.RuleSecurityHooksMissing {
+			t.Errorf("Expected the imported secrets check to satisfy %s", issue.Rule)
+		}
+	}
+	if files := result.Config.Hooks["local"]["pre-commit"][0].Files; !reflect.DeepEqual(files, []string{"*.{md,rst}"}) {
+		t.Errorf("Expected the files regular expression to become a glob, got %v", files)
+	}
+
+	var tools []string
+	for _, tool := range result.Config.Tools {
+		tools = append(tools, tool.Name)
+	}
+	if expected := []string{"Black (Python Formatter)", "Pytest", "Prettier (Code Formatter)"}; !reflect.DeepEqual(tools, expected) {
+		t.Errorf("Expected tools %v, got %v", expected, tools)
+	}
+
+	for _, note := range []string{"fail_fast", "check-yaml (https://github.com/pre-commit/pre-commit-hooks): unknown hook ID", "check-hooks-apply: meta hooks"} {
+		if !strings.Contains(strings.Join(result.Notes, "\n"), note) {
+			t.Errorf("Expected a note about %q, got %q", note, result.Notes)
+		}
+	}
+}
+
+func TestImporter_Lefthook(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"lefthook.yml": `colors: false
+pre-commit:
+  parallel: true
+  commands:
+    eslint:
+      glob: "*.{js,ts}"
+      run: npx eslint {staged_files}
+      fail_text: "Run {{ npm run lint:fix }}"
+    api-tests:
+      root: api/
+      glob: "*.go"
+      run: go test ./...
+      env:
+        CGO_ENABLED: "0"
+  scripts:
+    "check.sh":
+      runner: bash
+pre-push:
+  jobs:
+    - name: audit
+      run: npm audit --omit=dev
+commit-msg:
+  commands:
+    commitlint:
+      run: npx commitlint --edit {1}
+`,
+	})
+
+	result, err := NewImporter(dir).Import("lefthook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"lefthook/eslint: npx eslint {files}",
+		"lefthook/api-tests: cd api && go test ./...",
+		"lefthook/check.sh: bash .lefthook/pre-commit/check.sh",
+	}
+	if hooks := importedHooks(result.Config, "pre-commit"); !reflect.DeepEqual(hooks, expected) {
+		t.Errorf("Expected pre-commit hooks\n%q\ngot\n%q", expected, hooks)
+	}
+	if hooks := importedHooks(result.Config, "pre-push"); !reflect.DeepEqual(hooks, []string{"lefthook/audit: npm audit --omit=dev"}) {
+		t.Errorf("Unexpected pre-push hooks %q", hooks)
+	}
+
+	hooks := result.Config.Hooks["lefthook"]["pre-commit"]
+	if hooks[0].OutputRules.OnFailureMessage != "Run {{`{{`}} npm run lint:fix }}" {
+		t.Errorf("Expected fail_text to be escaped, got %q", hooks[0].OutputRules.OnFailureMessage)
+	}
+	if !reflect.DeepEqual(hooks[1].Files, []string{"api/**/*.go"}) || result.Config.Env["CGO_ENABLED"] != "0" {
+		t.Errorf("Expected the root to scope the glob and env to be imported, got %v and %v", hooks[1].Files, result.Config.Env)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "commit-msg") {
+		t.Errorf("Expected a note about commit-msg, got %q", result.Notes)
+	}
+}
+
+func TestImporter_Husky(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		".husky/pre-commit": `#!/usr/bin/env sh
+. "$(dirname -- "$0")/_/husky.sh"
+
+# lint staged files
+npx lint-staged
+npm run typecheck
+`,
+		".husky/pre-push": `cd web
+npm test
+`,
+		".husky/commit-msg": "npx --no -- commitlint --edit $1\n",
+		"package.json": `{
+  "name": "app",
+  "lint-staged": {
+    "*.{js,ts}": ["eslint --fix", "git add"],
+    "*.md": "prettier --write"
+  }
+}`,
+	})
+
+	result, err := NewImporter(dir).Import("husky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"husky/typecheck: npm run typecheck",
+		"lint-staged/eslint (*.{js,ts}): npx eslint {files}",
+		"lint-staged/prettier (*.md): npx prettier --check {files}",
+	}
+	if hooks := importedHooks(result.Config, "pre-commit"); !reflect.DeepEqual(hooks, expected) {
+		t.Errorf("Expected pre-commit hooks\n%q\ngot\n%q", expected, hooks)
+	}
+	if fix := result.Config.Hooks["lint-staged"]["pre-commit"][1].FixCommand; fix != "npx prettier --write {files}" {
+		t.Errorf("Expected the fixing task to become the fix command, got %q", fix)
+	}
+	// cd changes the directory of the commands after it
+	if hooks := importedHooks(result.Config, "pre-push"); !reflect.DeepEqual(hooks, []string{"husky/pre-push: cd web\nnpm test\n"}) {
+		t.Errorf("Expected the pre-push script to be kept whole, got %q", hooks)
+	}
+	notes := strings.Join(result.Notes, "\n")
+	if !strings.Contains(notes, ".husky/commit-msg") || !strings.Contains(notes, `"git add" was skipped`) {
+		t.Errorf("Expected notes about commit-msg and git add, got %q", result.Notes)
+	}
+}
+
+func TestImporter_HuskyGuards(t *testing.T) {
+	scripts := map[string]string{
+		"and-list": "[ -n \"$CI\" ] && exit 0\nnpm test\n",
+		"if":       "if [ -n \"$CI\" ]; then\n  exit 0\nfi\nnpm test\n",
+	}
+	for name, script := range scripts {
+		t.Run(name, func(t *testing.T) {
+			dir := writeProjectFiles(t, map[string]string{".husky/pre-push": script})
+			result, err := NewImporter(dir).Import("husky")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The guard skips the commands after it, so the script stays whole
+			if hooks := importedHooks(result.Config, "pre-push"); !reflect.DeepEqual(hooks, []string{"husky/pre-push: " + script}) {
+				t.Errorf("Expected the script to be kept whole, got %q", hooks)
+			}
+		})
+	}
+}
+
+func TestImportResult_YAML(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		".husky/pre-commit": "npx lint-staged\n",
+		".lintstagedrc":     `{"*.go": "gofmt -l"}`,
+		".husky/pre-push":   "export CI=1\ngo test ./...\n",
+	})
+	result, err := NewImporter(dir).Import("husky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := result.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# yaml-language-server: $schema="+config.SchemaURL+"\n# Imported from .husky/pre-commit, .husky/pre-push, .lintstagedrc by quality-gate import\nversion: 2\n") {
+		t.Errorf("Unexpected header:\n%s", data)
+	}
+	if !strings.Contains(string(data), "command: |\n") {
+		t.Errorf("Expected the multi-line script as a literal block:\n%s", data)
+	}
+
+	// The imported configuration loads and validates
+	path := filepath.Join(dir, "quality.yml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validation := config.NewConfigValidator(cfg).Validate(); !validation.Valid {
+		t.Errorf("Expected the imported configuration to be valid, got %+v", validation.Errors)
+	}
+}
+
+func TestRegexToGlobs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		globs   []string
+	}{
+		{`\.py$`, []string{"*.py"}},
+		{`\.(ts|tsx)$`, []string{"*.{ts,tsx}"}},
+		{`^docs/`, []string{"docs/**"}},
+		{`^src/.*\.go$`, []string{"src/**/*.go"}},
+		{`^docs/conf\.py$`, []string{"docs/conf.py"}},
+		{`^src/\.go$`, nil},
+		{`^setup\.py$`, []string{"setup.py"}},
+		{`_test\.go$`, []string{"*_test.go"}},
+		{`setup\.py$`, []string{"*setup.py"}},
+		{`^(vendor/|third_party/)`, []string{"vendor/**", "third_party/**"}},
+		{`\.md$|\.rst$`, []string{"*.md", "*.rst"}},
+		{`migrations/`, []string{"**/migrations/**"}},
+		{`^docs/\.md$`, nil},
+		{`(?x)^(a|b)$`, nil},
+	}
+	for _, tt := range tests {
+		globs, ok := regexToGlobs(tt.pattern)
+		if ok != (tt.globs != nil) || !reflect.DeepEqual(globs, tt.globs) {
+			t.Errorf("regexToGlobs(%q) = %q, %v; expected %q", tt.pattern, globs, ok, tt.globs)
+		}
+	}
+}
@@ -2,15 +2,36 @@ package service
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/dmux/go-quality-gate/internal/domain"
 	"github.com/dmux/go-quality-gate/internal/repository"
 )
 
+// managedHookMarker identifies hook scripts written by quality-gate.
+const managedHookMarker = "# Installed by quality-gate."
+
+// chainedHookMarker identifies managed hook scripts that run a backed up hook first.
+const chainedHookMarker = "# Chains the hook that existed before quality-gate."
+
+// Header lines recording how a hook script was installed.
 const (
-	preCommitHookContent = "#!/bin/sh\nexec quality-gate pre-commit\n"
-	prePushHookContent   = "#!/bin/sh\nexec quality-gate pre-push\n"
+	hookVersionHeader  = "# quality-gate-version:"
+	hookChecksumHeader = "# quality-gate-config-checksum:"
 )
 
+// legacyHookScripts are the hook scripts of versions that did not write
+// managedHookMarker yet.
+var legacyHookScripts = map[string]bool{
+	"#!/bin/sh\nexec quality-gate pre-commit\n":        true,
+	"#!/bin/sh\nexec quality-gate pre-push\n":          true,
+	"#!/bin/sh\nexec quality-gate pre-commit \"$@\"\n": true,
+	"#!/bin/sh\nexec quality-gate pre-push \"$@\"\n":   true,
+}
+
+// managedHookTypes lists the git hooks installed by quality-gate.
+var managedHookTypes = []string{"pre-commit", "pre-push"}
+
 // InstallationService is responsible for installing the git hooks.
 
 type InstallationService struct {
@@ -23,16 +44,204 @@ func NewInstallationService(gitRepo repository.GitRepository) *InstallationServi
 	return &InstallationService{gitRepo: gitRepo}
 }
 
+// InstallOptions controls how existing hooks are handled during installation.
+
+type InstallOptions struct {
+	// Force replaces hooks not installed by quality-gate, keeping a backup.
+	Force bool
+	// Chain keeps hooks not installed by quality-gate and runs them first.
+	Chain bool
+	// BinaryPath is the absolute path of the quality-gate binary the hooks
+	// run; hooks fall back to PATH when it no longer exists.
+	BinaryPath string
+	// Version is the version of quality-gate installing the hooks.
+	Version string
+	// ConfigChecksum identifies the quality.yml the hooks were installed for;
+	// doctor warns when the file no longer matches it.
+	ConfigChecksum string
+}
+
 // InstallHooks installs the pre-commit and pre-push git hooks.
 
 func (s *InstallationService) InstallHooks() error {
-	if err := s.gitRepo.InstallHook("pre-commit", preCommitHookContent); err != nil {
-		return fmt.Errorf("failed to install pre-commit hook: %w", err)
+	return s.InstallHooksWithOptions(InstallOptions{})
+}
+
+// InstallHooksWithOptions installs the git hooks, backing up and optionally
+// chaining hooks that were not installed by quality-gate. Without Force or
+// Chain, foreign hooks are left untouched and an error is returned.
+
+func (s *InstallationService) InstallHooksWithOptions(opts InstallOptions) error {
+	// Check every hook before touching any of them, so a refusal leaves the
+	// repository unchanged.
+	existing := make(map[string]string)
+	for _, hookType := range managedHookTypes {
+		content, exists, err := s.gitRepo.ReadHook(hookType)
+		if err != nil {
+			return fmt.Errorf("failed to read existing %s hook: %w", hookType, err)
+		}
+		if !exists {
+			continue
+		}
+		if isSharedDispatcher(content) {
+			return s.sharedHooksDirError()
+		}
+		existing[hookType] = content
+		if !IsManagedHook(content) && !opts.Force && !opts.Chain {
+			return fmt.Errorf("an existing %s hook was not installed by quality-gate; use --chain to run it before quality-gate or --force to replace it (a backup is kept)", hookType)
+		}
+	}
+
+	for _, hookType := range managedHookTypes {
+		content, exists := existing[hookType]
+		chain := false
+
+		switch {
+		case exists && !IsManagedHook(content):
+			if err := s.gitRepo.BackupHook(hookType); err != nil {
+				return fmt.Errorf("failed to back up existing %s hook: %w", hookType, err)
+			}
+			chain = opts.Chain
+		case exists:
+			// Re-installing keeps chaining a backed up hook unless --force asks
+			// for quality-gate alone.
+			chain = s.gitRepo.HasHookBackup(hookType) && (opts.Chain || (!opts.Force && strings.Contains(content, chainedHookMarker)))
+		default:
+			chain = opts.Chain && s.gitRepo.HasHookBackup(hookType)
+		}
+
+		if err := s.gitRepo.InstallHook(hookType, hookScript(hookType, chain, opts)); err != nil {
+			return fmt.Errorf("failed to install %s hook: %w", hookType, err)
+		}
 	}
 
-	if err := s.gitRepo.InstallHook("pre-push", prePushHookContent); err != nil {
-		return fmt.Errorf("failed to install pre-push hook: %w", err)
+	return nil
+}
+
+// UninstallHooks removes the hooks installed by quality-gate and restores the
+// hooks that were backed up during installation. Foreign hooks are kept.
+
+func (s *InstallationService) UninstallHooks() error {
+	for _, hookType := range managedHookTypes {
+		content, exists, err := s.gitRepo.ReadHook(hookType)
+		if err != nil {
+			return fmt.Errorf("failed to read %s hook: %w", hookType, err)
+		}
+		if exists && isSharedDispatcher(content) {
+			return s.sharedHooksDirError()
+		}
+		if exists && !IsManagedHook(content) {
+			continue
+		}
+
+		if exists {
+			if err := s.gitRepo.RemoveHook(hookType); err != nil {
+				return fmt.Errorf("failed to remove %s hook: %w", hookType, err)
+			}
+		}
+
+		if s.gitRepo.HasHookBackup(hookType) {
+			if err := s.gitRepo.RestoreHook(hookType); err != nil {
+				return fmt.Errorf("failed to restore original %s hook: %w", hookType, err)
+			}
+		}
 	}
 
 	return nil
 }
+
+// sharedHooksDirError explains that the hooks directory of the repository is
+// the core.hooksPath directory of a global installation, shared by every
+// repository of the user.
+
+func (s *InstallationService) sharedHooksDirError() error {
+	dir, err := s.gitRepo.HooksDir()
+	if err != nil {
+		dir = "the hooks directory"
+	}
+	return fmt.Errorf("%s holds the global dispatchers of core.hooksPath, shared by every repository; they already run quality-gate in repositories with a configuration file. Run 'quality-gate --uninstall --global' first to manage this repository's hooks itself", dir)
+}
+
+// IsManagedHook reports whether a hook script was written by quality-gate.
+
+func IsManagedHook(content string) bool {
+	return strings.Contains(content, managedHookMarker) || legacyHookScripts[content]
+}
+
+// hookScript returns the script installed for a hook type. The script runs
+// the binary that installed it, falling back to quality-gate from PATH, and
+// records the installing version and config checksum. A chained script runs
+// the backed up hook first and stops when it fails; pre-push input is
+// buffered so both hooks receive git's ref updates.
+
+func hookScript(hookType string, chain bool, opts InstallOptions) string {
+	var b strings.Builder
+	writeHookHeader(&b, managedHookMarker+" Remove with: quality-gate --uninstall", opts)
+	writeBinaryLookup(&b, opts)
+
+	if chain {
+		backup := fmt.Sprintf(`"$(dirname "$0")/%s%s"`, hookType, domain.HookBackupSuffix)
+		b.WriteString(chainedHookMarker + "\n")
+		if hookType == "pre-push" {
+			b.WriteString("input=$(cat)\n")
+			fmt.Fprintf(&b, "if [ -x %s ]; then\n", backup)
+			fmt.Fprintf(&b, "  printf '%%s\\n' \"$input\" | %s \"$@\" || exit $?\n", backup)
+			b.WriteString("fi\n")
+			fmt.Fprintf(&b, "printf '%%s\\n' \"$input\" | exec \"$QUALITY_GATE_BIN\" %s \"$@\"\n", hookType)
+			return b.String()
+		}
+		fmt.Fprintf(&b, "if [ -x %s ]; then\n", backup)
+		fmt.Fprintf(&b, "  %s \"$@\" || exit $?\n", backup)
+		b.WriteString("fi\n")
+	}
+
+	fmt.Fprintf(&b, "exec \"$QUALITY_GATE_BIN\" %s \"$@\"\n", hookType)
+	return b.String()
+}
+
+// writeHookHeader writes the shebang, marker and installation header lines.
+
+func writeHookHeader(b *strings.Builder, marker string, opts InstallOptions) {
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(marker + "\n")
+	if opts.Version != "" {
+		fmt.Fprintf(b, "%s %s\n", hookVersionHeader, opts.Version)
+	}
+	if opts.ConfigChecksum != "" {
+		fmt.Fprintf(b, "%s %s\n", hookChecksumHeader, opts.ConfigChecksum)
+	}
+	b.WriteString("\n")
+}
+
+// writeBinaryLookup writes the lines setting QUALITY_GATE_BIN to the pinned
+// binary, or quality-gate from PATH, and exporting the installing version.
+
+func writeBinaryLookup(b *strings.Builder, opts InstallOptions) {
+	if opts.BinaryPath != "" {
+		fmt.Fprintf(b, "QUALITY_GATE_BIN=%s\n", shellQuote(opts.BinaryPath))
+		b.WriteString("if [ ! -x \"$QUALITY_GATE_BIN\" ]; then\n")
+		b.WriteString("  QUALITY_GATE_BIN=$(command -v quality-gate 2>/dev/null)\n")
+		b.WriteString("fi\n")
+	} else {
+		b.WriteString("QUALITY_GATE_BIN=$(command -v quality-gate 2>/dev/null)\n")
+	}
+	b.WriteString("if [ -z \"$QUALITY_GATE_BIN\" ]; then\n")
+	if opts.BinaryPath != "" {
+		fmt.Fprintf(b, "  echo %s >&2\n", shellQuote(fmt.Sprintf("quality-gate: binary not found at %s nor in PATH.", opts.BinaryPath)))
+	} else {
+		b.WriteString("  echo 'quality-gate: binary not found in PATH.' >&2\n")
+	}
+	b.WriteString("  echo 'quality-gate: install it (https://github.com/dmux/go-quality-gate) or remove the hook with: quality-gate --uninstall' >&2\n")
+	b.WriteString("  exit 1\n")
+	b.WriteString("fi\n")
+	if opts.Version != "" {
+		fmt.Fprintf(b, "export QUALITY_GATE_HOOK_VERSION=%s\n", shellQuote(opts.Version))
+	}
+	b.WriteString("\n")
+}
+
+// shellQuote quotes a string as a single shell word.
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
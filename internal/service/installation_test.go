@@ -0,0 +1,151 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
+)
+
+const huskyHook = "#!/bin/sh\nnpx lint-staged\n"
+
+func TestInstallationService_InstallHooks(t *testing.T) {
+	t.Run("FreshRepository", func(t *testing.T) {
+		gitRepo := NewMockGitRepository()
+		service := NewInstallationService(gitRepo)
+
+		if err := service.InstallHooks(); err != nil {
+			t.Fatalf("InstallHooks failed: %v", err)
+		}
+
+		for _, hookType := range []string{"pre-commit", "pre-push"} {
+			content := gitRepo.Hooks[hookType]
+			if !IsManagedHook(content) || !strings.Contains(content, `exec "$QUALITY_GATE_BIN" `+hookType+` "$@"`) {
+				t.Errorf("Unexpected %s hook:\n%s", hookType, content)
+			}
+		}
+	})
+
+	t.Run("RefusesForeignHook", func(t *testing.T) {
+		gitRepo := NewMockGitRepository()
+		gitRepo.Hooks["pre-push"] = huskyHook
+		service := NewInstallationService(gitRepo)
+
+		if err := service.InstallHooks(); err == nil {
+			t.Fatal("Expected an error for a foreign hook")
+		}
+		if gitRepo.Hooks["pre-push"] != huskyHook || len(gitRepo.Hooks) != 1 {
+			t.Errorf("Expected hooks to be left untouched, got %v", gitRepo.Hooks)
+		}
+	})
+
+	t.Run("ForceBacksUp", func(t *testing.T) {
+		gitRepo := NewMockGitRepository()
+		gitRepo.Hooks["pre-commit"] = huskyHook
+		service := NewInstallationService(gitRepo)
+
+		if err := service.InstallHooksWithOptions(InstallOptions{Force: true}); err != nil {
+			t.Fatalf("InstallHooksWithOptions failed: %v", err)
+		}
+		if gitRepo.Backups["pre-commit"] != huskyHook {
+			t.Errorf("Expected foreign hook to be backed up")
+		}
+		if strings.Contains(gitRepo.Hooks["pre-commit"], domain.HookBackupSuffix) {
+			t.Errorf("Did not expect forced hook to chain the backup")
+		}
+	})
+
+	t.Run("ChainRunsExistingHook", func(t *testing.T) {
+		gitRepo := NewMockGitRepository()
+		gitRepo.Hooks["pre-commit"] = huskyHook
+		gitRepo.Hooks["pre-push"] = huskyHook
+		service := NewInstallationService(gitRepo)
+
+		if err := service.InstallHooksWithOptions(InstallOptions{Chain: true}); err != nil {
+			t.Fatalf("InstallHooksWithOptions failed: %v", err)
+		}
+
+		preCommit := gitRepo.Hooks["pre-commit"]
+		if !strings.Contains(preCommit, `pre-commit.pre-quality-gate" "$@" || exit $?`) {
+			t.Errorf("Expected pre-commit to chain the backup:\n%s", preCommit)
+		}
+		prePush := gitRepo.Hooks["pre-push"]
+		if !strings.Contains(prePush, "input=$(cat)") {
+			t.Errorf("Expected pre-push to buffer stdin for both hooks:\n%s", prePush)
+		}
+
+		// Re-installing keeps the chain without needing --chain again.
+		if err := service.InstallHooks(); err != nil {
+			t.Fatalf("Re-install failed: %v", err)
+		}
+		if !strings.Contains(gitRepo.Hooks["pre-commit"], chainedHookMarker) {
+			t.Errorf("Expected re-install to keep chaining")
+		}
+	})
+}
+
+func TestInstallationService_HookScriptMetadata(t *testing.T) {
+	gitRepo := NewMockGitRepository()
+	service := NewInstallationService(gitRepo)
+
+	err := service.InstallHooksWithOptions(InstallOptions{
+		BinaryPath:     "/opt/tools/it's/quality-gate",
+		Version:        "1.3.0",
+		ConfigChecksum: "sha256:abc123",
+	})
+	if err != nil {
+		t.Fatalf("InstallHooksWithOptions failed: %v", err)
+	}
+
+	content := gitRepo.Hooks["pre-commit"]
+	for _, expected := range []string{
+		"# quality-gate-version: 1.3.0",
+		"# quality-gate-config-checksum: sha256:abc123",
+		`QUALITY_GATE_BIN='/opt/tools/it'\''s/quality-gate'`,
+		"command -v quality-gate",
+		"binary not found at /opt/tools/it",
+		"export QUALITY_GATE_HOOK_VERSION='1.3.0'",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected hook script to contain %q:\n%s", expected, content)
+		}
+	}
+}
+
+func TestInstallationService_UninstallHooks(t *testing.T) {
+	gitRepo := NewMockGitRepository()
+	gitRepo.Hooks["pre-commit"] = huskyHook
+	service := NewInstallationService(gitRepo)
+
+	if err := service.InstallHooksWithOptions(InstallOptions{Chain: true}); err != nil {
+		t.Fatalf("InstallHooksWithOptions failed: %v", err)
+	}
+	if err := service.UninstallHooks(); err != nil {
+		t.Fatalf("UninstallHooks failed: %v", err)
+	}
+
+	if gitRepo.Hooks["pre-commit"] != huskyHook {
+		t.Errorf("Expected original pre-commit hook to be restored, got %q", gitRepo.Hooks["pre-commit"])
+	}
+	if _, ok := gitRepo.Hooks["pre-push"]; ok {
+		t.Errorf("Expected pre-push hook to be removed")
+	}
+	if len(gitRepo.Backups) != 0 {
+		t.Errorf("Expected no backups left, got %v", gitRepo.Backups)
+	}
+}
+
+func TestIsManagedHook(t *testing.T) {
+	if !IsManagedHook("#!/bin/sh\nexec quality-gate pre-commit\n") {
+		t.Error("Expected hooks from earlier versions to be recognized")
+	}
+	if !IsManagedHook("#!/bin/sh\nexec quality-gate pre-push \"$@\"\n") {
+		t.Error("Expected pre-push hooks from earlier versions to be recognized")
+	}
+	if IsManagedHook(huskyHook) {
+		t.Error("Did not expect a husky hook to be recognized")
+	}
+	if IsManagedHook("#!/bin/sh\nnpm test\nexec quality-gate pre-commit\n") {
+		t.Error("Did not expect a foreign hook running quality-gate to be recognized")
+	}
+}
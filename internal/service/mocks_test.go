@@ -1,5 +1,7 @@
 package service
 
+import "fmt"
+
 // MockLogger is a mock implementation of the Logger interface.
 type MockLogger struct {
 	Messages []string
@@ -29,3 +31,86 @@ func (m *MockLogger) StopSpinner() {
 func (m *MockLogger) UpdateSpinner(message string) {
 	// For testing, we just ignore the output
 }
+
+// MockGitRepository is an in-memory implementation of the GitRepository interface.
+type MockGitRepository struct {
+	Hooks   map[string]string
+	Backups map[string]string
+	Dir     string
+}
+
+// NewMockGitRepository creates an empty MockGitRepository.
+func NewMockGitRepository() *MockGitRepository {
+	return &MockGitRepository{Hooks: make(map[string]string), Backups: make(map[string]string), Dir: ".git/hooks"}
+}
+
+// InstallHook implements the GitRepository interface.
+func (m *MockGitRepository) InstallHook(hookType string, content string) error {
+	m.Hooks[hookType] = content
+	return nil
+}
+
+// ReadHook implements the GitRepository interface.
+func (m *MockGitRepository) ReadHook(hookType string) (string, bool, error) {
+	content, ok := m.Hooks[hookType]
+	return content, ok, nil
+}
+
+// RemoveHook implements the GitRepository interface.
+func (m *MockGitRepository) RemoveHook(hookType string) error {
+	delete(m.Hooks, hookType)
+	return nil
+}
+
+// BackupHook implements the GitRepository interface.
+func (m *MockGitRepository) BackupHook(hookType string) error {
+	if _, ok := m.Backups[hookType]; ok {
+		return fmt.Errorf("backup of %s already exists", hookType)
+	}
+	m.Backups[hookType] = m.Hooks[hookType]
+	delete(m.Hooks, hookType)
+	return nil
+}
+
+// HasHookBackup implements the GitRepository interface.
+func (m *MockGitRepository) HasHookBackup(hookType string) bool {
+	_, ok := m.Backups[hookType]
+	return ok
+}
+
+// RestoreHook implements the GitRepository interface.
+func (m *MockGitRepository) RestoreHook(hookType string) error {
+	m.Hooks[hookType] = m.Backups[hookType]
+	delete(m.Backups, hookType)
+	return nil
+}
+
+// HooksDir implements the GitRepository interface.
+func (m *MockGitRepository) HooksDir() (string, error) {
+	if m.Dir == "" {
+		return "", fmt.Errorf("not a git repository")
+	}
+	return m.Dir, nil
+}
+
+// MockGitConfig is an in-memory implementation of the GitConfig interface.
+type MockGitConfig struct {
+	Values map[string]string
+}
+
+// GetGlobal implements the GitConfig interface.
+func (m *MockGitConfig) GetGlobal(key string) (string, error) {
+	return m.Values[key], nil
+}
+
+// SetGlobal implements the GitConfig interface.
+func (m *MockGitConfig) SetGlobal(key, value string) error {
+	m.Values[key] = value
+	return nil
+}
+
+// UnsetGlobal implements the GitConfig interface.
+func (m *MockGitConfig) UnsetGlobal(key string) error {
+	delete(m.Values, key)
+	return nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
+)
+
+// showOutput reports whether the output rules show the output of a hook
+// that passed or failed.
+
+func showOutput(rules domain.OutputRules, success bool) bool {
+	switch rules.ShowOn {
+	case "always":
+		return true
+	case "failure":
+		return !success
+	case "success":
+		return success
+	}
+	return false
+}
+
+// formatOutput applies the strip, filter and max_lines output rules to the
+// output shown on the console. Invalid regular expressions, which the
+// configuration validator reports, are ignored.
+
+func formatOutput(output string, rules domain.OutputRules) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+
+	if strip, err := regexp.Compile(rules.Strip); rules.Strip != "" && err == nil {
+		kept := lines[:0]
+		for _, line := range lines {
+			stripped := strip.ReplaceAllString(line, "")
+			if stripped == "" && line != "" {
+				continue
+			}
+			kept = append(kept, stripped)
+		}
+		lines = kept
+	}
+
+	if filter, err := regexp.Compile(rules.Filter); rules.Filter != "" && err == nil {
+		var kept []string
+		for _, line := range lines {
+			if filter.MatchString(line) {
+				kept = append(kept, line)
+			}
+		}
+		lines = kept
+	}
+
+	if rules.MaxLines > 0 && len(lines) > rules.MaxLines {
+		omitted := len(lines) - rules.MaxLines
+		if rules.Truncate == "tail" {
+			lines = append([]string{fmt.Sprintf("… %d earlier line(s) omitted", omitted)}, lines[omitted:]...)
+		} else {
+			lines = append(lines[:rules.MaxLines], fmt.Sprintf("… %d more line(s) omitted", omitted))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
+)
+
+func TestShowOutput(t *testing.T) {
+	testCases := []struct {
+		showOn  string
+		success bool
+		show    bool
+	}{
+		{"", true, false},
+		{"", false, false},
+		{"always", true, true},
+		{"always", false, true},
+		{"failure", true, false},
+		{"failure", false, true},
+		{"success", true, true},
+		{"success", false, false},
+	}
+
+	for _, tc := range testCases {
+		if got := showOutput(domain.OutputRules{ShowOn: tc.showOn}, tc.success); got != tc.show {
+			t.Errorf("showOutput(%q, success=%v) = %v, want %v", tc.showOn, tc.success, got, tc.show)
+		}
+	}
+}
+
+func TestFormatOutput(t *testing.T) {
+	output := "\x1b[31mFAIL\x1b[0m a_test.go\nok b_test.go\n\x1b[31mFAIL\x1b[0m c_test.go\nok d_test.go\nsummary: 2 failed\n"
+
+	testCases := []struct {
+		name     string
+		rules    domain.OutputRules
+		expected string
+	}{
+		{"NoRules", domain.OutputRules{}, output[:len(output)-1]},
+		{"Strip", domain.OutputRules{Strip: `\x1b\[[0-9;]*m`}, "FAIL a_test.go\nok b_test.go\nFAIL c_test.go\nok d_test.go\nsummary: 2 failed"},
+		{"StripDropsEmptiedLines", domain.OutputRules{Strip: `^ok .*`}, "\x1b[31mFAIL\x1b[0m a_test.go\n\x1b[31mFAIL\x1b[0m c_test.go\nsummary: 2 failed"},
+		{"StripThenFilter", domain.OutputRules{Strip: `\x1b\[[0-9;]*m`, Filter: `^FAIL`}, "FAIL a_test.go\nFAIL c_test.go"},
+		{"Head", domain.OutputRules{MaxLines: 2}, "\x1b[31mFAIL\x1b[0m a_test.go\nok b_test.go\n… 3 more line(s) omitted"},
+		{"Tail", domain.OutputRules{MaxLines: 2, Truncate: "tail"}, "… 3 earlier line(s) omitted\nok d_test.go\nsummary: 2 failed"},
+		{"FilterThenMaxLines", domain.OutputRules{Filter: `FAIL`, MaxLines: 2}, "\x1b[31mFAIL\x1b[0m a_test.go\n\x1b[31mFAIL\x1b[0m c_test.go"},
+		{"InvalidPatternIgnored", domain.OutputRules{Filter: `(`}, output[:len(output)-1]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatOutput(output, tc.rules); got != tc.expected {
+				t.Errorf("formatOutput() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHookRunnerService_OutputRules(t *testing.T) {
+	output := "line 1\nline 2\nline 3\n"
+	mockRunner := &MockShellRunner{Commands: map[string]struct {
+		Output string
+		Err    error
+	}{
+		"pass": {output, nil},
+		"fail": {output, errors.New("exit status 1")},
+	}}
+	mockLogger := &MockLogger{}
+	service := NewHookRunnerService(mockRunner, mockLogger)
+
+	rules := domain.OutputRules{ShowOn: "success", MaxLines: 1, OnSuccessMessage: "{{.Name}} passed", OnFailureMessage: "{{.Name}} failed"}
+	results := service.RunHooks([]domain.Hook{
+		{Name: "Passing", Command: "pass", OutputRules: rules},
+		{Name: "Failing", Command: "fail", OutputRules: rules},
+	})
+
+	expected := []string{"Passing passed", "line 1\n… 2 more line(s) omitted", "Failing failed"}
+	if len(mockLogger.Messages) != len(expected) {
+		t.Fatalf("Expected console output %q, got %q", expected, mockLogger.Messages)
+	}
+	for i := range expected {
+		if mockLogger.Messages[i] != expected[i] {
+			t.Errorf("Expected console line %d to be %q, got %q", i, expected[i], mockLogger.Messages[i])
+		}
+	}
+	for _, result := range results {
+		if result.Output != output {
+			t.Errorf("Expected the result of %s to keep the full output, got %q", result.Hook.Name, result.Output)
+		}
+	}
+}
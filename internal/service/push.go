@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
+)
+
+// ParsePushInfo parses the "<local ref> <local sha> <remote ref> <remote sha>"
+// lines git writes to the standard input of the pre-push hook.
+
+func ParsePushInfo(remoteName, remoteURL string, r io.Reader) (*domain.PushInfo, error) {
+	push := &domain.PushInfo{RemoteName: remoteName, RemoteURL: remoteURL}
+	if r == nil {
+		return push, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid pre-push input on line %d: expected 4 fields, got %d", lineNumber, len(fields))
+		}
+
+		push.Updates = append(push.Updates, domain.RefUpdate{
+			LocalRef:  fields[0],
+			LocalSHA:  fields[1],
+			RemoteRef: fields[2],
+			RemoteSHA: fields[3],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pre-push input: %w", err)
+	}
+
+	return push, nil
+}
+
+// hookPlaceholders returns the {placeholder} values available to hook
+// commands. Values are shell-quoted since ref names and URLs may contain shell
+// syntax.
+
+func hookPlaceholders(ctx domain.HookContext) map[string]string {
+	placeholders := map[string]string{
+		"{hook_type}": shellQuote(ctx.HookType),
+	}
+	if ctx.Push == nil {
+		return placeholders
+	}
+
+	update, _ := ctx.Push.Primary()
+	placeholders["{remote_name}"] = shellQuote(ctx.Push.RemoteName)
+	placeholders["{remote_url}"] = shellQuote(ctx.Push.RemoteURL)
+	placeholders["{push_range}"] = shellQuote(ctx.Push.Range())
+	placeholders["{push_exclude}"] = shellQuoteFiles(ctx.Push.Exclusions())
+	placeholders["{push_from}"] = shellQuote(update.RemoteSHA)
+	placeholders["{push_to}"] = shellQuote(update.LocalSHA)
+	placeholders["{push_local_ref}"] = shellQuote(update.LocalRef)
+	placeholders["{push_remote_ref}"] = shellQuote(update.RemoteRef)
+	return placeholders
+}
+
+// hookEnvironment returns the QUALITY_GATE_* variables exported to hook commands.
+
+func hookEnvironment(ctx domain.HookContext) []string {
+	var env []string
+	if ctx.HookType != "" {
+		env = append(env, "QUALITY_GATE_HOOK_TYPE="+ctx.HookType)
+	}
+	if ctx.Push == nil {
+		return env
+	}
+
+	update, _ := ctx.Push.Primary()
+	var refs []string
+	for _, u := range ctx.Push.Updates {
+		refs = append(refs, strings.Join([]string{u.LocalRef, u.LocalSHA, u.RemoteRef, u.RemoteSHA}, " "))
+	}
+
+	return append(env,
+		"QUALITY_GATE_REMOTE_NAME="+ctx.Push.RemoteName,
+		"QUALITY_GATE_REMOTE_URL="+ctx.Push.RemoteURL,
+		"QUALITY_GATE_PUSH_RANGE="+ctx.Push.Range(),
+		"QUALITY_GATE_PUSH_EXCLUDE="+strings.Join(ctx.Push.Exclusions(), " "),
+		"QUALITY_GATE_PUSH_FROM="+update.RemoteSHA,
+		"QUALITY_GATE_PUSH_TO="+update.LocalSHA,
+		"QUALITY_GATE_PUSH_LOCAL_REF="+update.LocalRef,
+		"QUALITY_GATE_PUSH_REMOTE_REF="+update.RemoteRef,
+		"QUALITY_GATE_PUSH_REFS="+strings.Join(refs, "\n"),
+	)
+}
+
+// expandPlaceholders replaces known {placeholder} tokens in a command, leaving
+// any other braces (awk programs, brace expansion) untouched.
+
+func expandPlaceholders(command string, placeholders map[string]string) string {
+	if !strings.Contains(command, "{") {
+		return command
+	}
+	var pairs []string
+	for key, value := range placeholders {
+		pairs = append(pairs, key, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(command)
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/dmux/go-quality-gate/internal/domain"
+)
+
+const (
+	localSHA  = "1111111111111111111111111111111111111111"
+	remoteSHA = "2222222222222222222222222222222222222222"
+	zeroSHA   = "0000000000000000000000000000000000000000"
+)
+
+func TestParsePushInfo(t *testing.T) {
+	t.Run("ParsesRefUpdates", func(t *testing.T) {
+		input := strings.Join([]string{
+			"refs/heads/feature " + localSHA + " refs/heads/feature " + remoteSHA,
+			"",
+			"(delete) " + zeroSHA + " refs/heads/old " + remoteSHA,
+		}, "\n")
+
+		push, err := ParsePushInfo("origin", "git@example.com:org/repo.git", strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("ParsePushInfo failed: %v", err)
+		}
+
+		if push.RemoteName != "origin" || push.RemoteURL != "git@example.com:org/repo.git" {
+			t.Errorf("Unexpected remote: %q %q", push.RemoteName, push.RemoteURL)
+		}
+		if len(push.Updates) != 2 {
+			t.Fatalf("Expected 2 updates, got %d", len(push.Updates))
+		}
+		if push.Updates[0].LocalRef != "refs/heads/feature" || push.Updates[0].RemoteSHA != remoteSHA {
+			t.Errorf("Unexpected first update: %+v", push.Updates[0])
+		}
+		if !push.Updates[1].IsDelete() {
+			t.Errorf("Expected second update to be a deletion")
+		}
+		if got, want := push.Range(), remoteSHA+".."+localSHA; got != want {
+			t.Errorf("Expected range %q, got %q", want, got)
+		}
+	})
+
+	t.Run("NewBranch", func(t *testing.T) {
+		input := "refs/heads/new " + localSHA + " refs/heads/new " + zeroSHA + "\n"
+		push, err := ParsePushInfo("origin", "", strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("ParsePushInfo failed: %v", err)
+		}
+
+		if !push.Updates[0].IsNew() {
+			t.Errorf("Expected update to create a new ref")
+		}
+		if got, want := push.Range(), localSHA; got != want {
+			t.Errorf("Expected range %q, got %q", want, got)
+		}
+		if got, want := strings.Join(push.Exclusions(), " "), "--not --remotes=origin"; got != want {
+			t.Errorf("Expected exclusions %q, got %q", want, got)
+		}
+	})
+
+	t.Run("NilReader", func(t *testing.T) {
+		push, err := ParsePushInfo("origin", "url", nil)
+		if err != nil {
+			t.Fatalf("ParsePushInfo failed: %v", err)
+		}
+		if len(push.Updates) != 0 || push.Range() != "" {
+			t.Errorf("Expected no updates, got %+v", push.Updates)
+		}
+	})
+
+	t.Run("MalformedLine", func(t *testing.T) {
+		if _, err := ParsePushInfo("origin", "url", strings.NewReader("refs/heads/main abc\n")); err == nil {
+			t.Error("Expected an error for a malformed line")
+		}
+	})
+}
+
+func TestHookRunnerService_PushContext(t *testing.T) {
+	mockRunner := &MockShellRunner{
+		Commands: make(map[string]struct {
+			Output string
+			Err    error
+		}),
+	}
+	mockRunner.Commands["git log '"+remoteSHA+".."+localSHA+"' && awk '{print $1}'"] = struct {
+		Output string
+		Err    error
+	}{"ok", nil}
+
+	service := NewHookRunnerService(mockRunner, &MockLogger{})
+	service.SetContext(domain.HookContext{
+		HookType: "pre-push",
+		Push: &domain.PushInfo{
+			RemoteName: "origin",
+			RemoteURL:  "url",
+			Updates: []domain.RefUpdate{
+				{LocalRef: "refs/heads/main", LocalSHA: localSHA, RemoteRef: "refs/heads/main", RemoteSHA: remoteSHA},
+			},
+		},
+	})
+
+	results := service.RunHooks([]domain.Hook{
+		{Name: "Range", Command: "git log {push_range} && awk '{print $1}'"},
+	})
+
+	if !results[0].Success {
+		t.Fatalf("Expected placeholders to be expanded, got output %q", results[0].Output)
+	}
+
+	env := strings.Join(mockRunner.LastEnv, "\n")
+	for _, expected := range []string{
+		"QUALITY_GATE_HOOK_TYPE=pre-push",
+		"QUALITY_GATE_REMOTE_NAME=origin",
+		"QUALITY_GATE_PUSH_REMOTE_REF=refs/heads/main",
+		"QUALITY_GATE_PUSH_RANGE=" + remoteSHA + ".." + localSHA,
+	} {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected environment to contain %q, got %v", expected, mockRunner.LastEnv)
+		}
+	}
+}
+
+func TestHookPlaceholders(t *testing.T) {
+	t.Run("QuotesValues", func(t *testing.T) {
+		ref := "refs/heads/x$(touch${IFS}pwned);echo"
+		ctx := domain.HookContext{
+			HookType: "pre-push",
+			Push: &domain.PushInfo{
+				RemoteName: "origin",
+				RemoteURL:  "https://example.com/a;b",
+				Updates: []domain.RefUpdate{
+					{LocalRef: ref, LocalSHA: localSHA, RemoteRef: ref, RemoteSHA: remoteSHA},
+				},
+			},
+		}
+
+		command := expandPlaceholders("echo {push_local_ref} {push_remote_ref} {remote_url}", hookPlaceholders(ctx))
+		output, err := exec.Command("sh", "-c", command).Output()
+		if err != nil {
+			t.Fatalf("Expanded command failed: %v", err)
+		}
+		if got, want := strings.TrimSpace(string(output)), ref+" "+ref+" https://example.com/a;b"; got != want {
+			t.Errorf("Expected values to be passed literally, got %q", got)
+		}
+	})
+
+	t.Run("NewRefRangeIsOneWord", func(t *testing.T) {
+		ctx := domain.HookContext{
+			HookType: "pre-push",
+			Push: &domain.PushInfo{
+				RemoteName: "origin",
+				Updates: []domain.RefUpdate{
+					{LocalRef: "refs/heads/new", LocalSHA: localSHA, RemoteRef: "refs/heads/new", RemoteSHA: zeroSHA},
+				},
+			},
+		}
+
+		placeholders := hookPlaceholders(ctx)
+		if got, want := expandPlaceholders("git diff {push_range}", placeholders), "git diff '"+localSHA+"'"; got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+		if got, want := expandPlaceholders("git log {push_range} {push_exclude}", placeholders), "git log '"+localSHA+"' '--not' '--remotes=origin'"; got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("ExistingRefHasNoExclusions", func(t *testing.T) {
+		ctx := domain.HookContext{
+			Push: &domain.PushInfo{
+				RemoteName: "origin",
+				Updates: []domain.RefUpdate{
+					{LocalRef: "refs/heads/main", LocalSHA: localSHA, RemoteRef: "refs/heads/main", RemoteSHA: remoteSHA},
+				},
+			},
+		}
+
+		if got := hookPlaceholders(ctx)["{push_exclude}"]; got != "" {
+			t.Errorf("Expected no exclusions, got %q", got)
+		}
+	})
+}
@@ -29,6 +29,7 @@ func (s *QualityGateService) Run(cfg *config.Config, hookType string) ([]domain.
 	}
 
 	// 2. Run the hooks for the given hook type.
+	s.hookRunner.SetEnvironment(cfg.Env)
 	hooksToRun := s.getHooksToRun(cfg.Hooks, hookType)
 	results := s.hookRunner.RunHooks(hooksToRun)
 
@@ -46,9 +47,10 @@ func (s *QualityGateService) Run(cfg *config.Config, hookType string) ([]domain.
 
 func (s *QualityGateService) Fix(cfg *config.Config, hookType string) error {
 	// 2. Run fix commands for the given hook type.
+	s.hookRunner.SetEnvironment(cfg.Env)
 	hooksToFix := s.getHooksToRun(cfg.Hooks, hookType)
 	for _, hook := range hooksToFix {
-		if hook.FixCommand != "" {
+		if hook.FixCommand != "" || s.hookRunner.HasBuiltinFix(hook) {
 			_, err := s.hookRunner.RunFixCommand(hook)
 			if err != nil {
 				return fmt.Errorf("failed to run fix command for hook %s: %w", hook.Name, err)
@@ -79,10 +81,19 @@ func (s *QualityGateService) getHooksToRun(configHooks config.Hooks, hookType st
 				domainHooks = append(domainHooks, domain.Hook{
 					Name:       h.Name,
 					Command:    h.Command,
+					Builtin:    h.Builtin,
+					Options:    h.Options,
+					Files:      h.Files,
+					Exclude:    h.Exclude,
 					FixCommand: h.FixCommand,
 					OutputRules: domain.OutputRules{
 						ShowOn:           h.OutputRules.ShowOn,
 						OnFailureMessage: h.OutputRules.OnFailureMessage,
+						OnSuccessMessage: h.OutputRules.OnSuccessMessage,
+						MaxLines:         h.OutputRules.MaxLines,
+						Truncate:         h.OutputRules.Truncate,
+						Filter:           h.OutputRules.Filter,
+						Strip:            h.OutputRules.Strip,
 					},
 				})
 			}
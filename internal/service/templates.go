@@ -2,7 +2,10 @@ package service
 
 import (
 	"fmt"
+	"os/exec"
 	"strings"
+
+	"github.com/dmux/go-quality-gate/internal/config"
 )
 
 // QualityTemplate represents a quality.yml template for a specific stack
@@ -30,6 +33,7 @@ type HookTemplate struct {
 type CommandTemplate struct {
 	Name             string            `yaml:"name"`
 	Command          string            `yaml:"command"`
+	Builtin          string            `yaml:"builtin,omitempty"`
 	FixCommand       string            `yaml:"fix_command,omitempty"`
 	OutputRules      map[string]string `yaml:"output_rules,omitempty"`
 	WorkingDirectory string            `yaml:"working_directory,omitempty"`
@@ -37,16 +41,19 @@ type CommandTemplate struct {
 }
 
 // TemplateGenerator generates quality.yml content based on detected project structure
-type TemplateGenerator struct{}
+type TemplateGenerator struct {
+	lookPath func(file string) (string, error)
+}
 
 // NewTemplateGenerator creates a new template generator
 func NewTemplateGenerator() *TemplateGenerator {
-	return &TemplateGenerator{}
+	return &TemplateGenerator{lookPath: exec.LookPath}
 }
 
 // GenerateTemplate creates a quality.yml template based on project structure
 func (g *TemplateGenerator) GenerateTemplate(structure *ProjectStructure) string {
-	var sections []string
+	// Let YAML editor plugins autocomplete and validate the file
+	sections := []string{"# yaml-language-server: $schema=" + config.SchemaURL + "\n" + fmt.Sprintf("version: %d", config.CurrentVersion)}
 
 	// Generate tools section
 	tools := g.generateTools(structure)
@@ -68,12 +75,15 @@ func (g *TemplateGenerator) generateTools(structure *ProjectStructure) []ToolTem
 	var tools []ToolTemplate
 	seen := make(map[string]bool)
 
-	// Always include Gitleaks for security
-	tools = append(tools, ToolTemplate{
-		Name:           "Gitleaks",
-		CheckCommand:   "gitleaks version",
-		InstallCommand: "go install github.com/gitleaks/gitleaks/v8@latest",
-	})
+	// Include Gitleaks for security when it can be used; otherwise the
+	// builtin secret scanner needs no tool at all
+	if g.gitleaksAvailable() {
+		tools = append(tools, ToolTemplate{
+			Name:           "Gitleaks",
+			CheckCommand:   "gitleaks version",
+			InstallCommand: "go install github.com/gitleaks/gitleaks/v8@latest",
+		})
+	}
 	seen["gitleaks"] = true
 
 	// Language-specific tools
@@ -250,6 +260,23 @@ func (g *TemplateGenerator) getFrameworkTools(framework Language) []ToolTemplate
 
 // generateSecurityHooks creates security-related hooks
 func (g *TemplateGenerator) generateSecurityHooks() HookTemplate {
+	if !g.gitleaksAvailable() {
+		return HookTemplate{
+			Name:        "security",
+			Description: "Security checks for all projects (builtin scanner, gitleaks not available)",
+			Commands: []CommandTemplate{
+				{
+					Name:    "🔒 Secret Detection",
+					Builtin: "secrets",
+					OutputRules: map[string]string{
+						"show_on":            "failure",
+						"on_failure_message": "⚠️  Secret leak detected! Review your code before committing.",
+					},
+				},
+			},
+		}
+	}
+
 	return HookTemplate{
 		Name:        "security",
 		Description: "Security checks for all projects",
@@ -579,7 +606,11 @@ func (g *TemplateGenerator) formatHooksSection(hooks []HookTemplate) string {
 
 		for _, cmd := range hook.Commands {
 			lines = append(lines, fmt.Sprintf("      - name: \"%s\"", cmd.Name))
-			lines = append(lines, fmt.Sprintf("        command: \"%s\"", cmd.Command))
+			if cmd.Builtin != "" {
+				lines = append(lines, fmt.Sprintf("        builtin: %s", cmd.Builtin))
+			} else {
+				lines = append(lines, fmt.Sprintf("        command: \"%s\"", cmd.Command))
+			}
 
 			if cmd.FixCommand != "" {
 				lines = append(lines, fmt.Sprintf("        fix_command: \"%s\"", cmd.FixCommand))
@@ -600,6 +631,16 @@ func (g *TemplateGenerator) formatHooksSection(hooks []HookTemplate) string {
 }
 
 // Helper functions
+
+// gitleaksAvailable reports whether gitleaks is installed or can be installed with go install
+func (g *TemplateGenerator) gitleaksAvailable() bool {
+	if _, err := g.lookPath("gitleaks"); err == nil {
+		return true
+	}
+	_, err := g.lookPath("go")
+	return err == nil
+}
+
 func (g *TemplateGenerator) hasLanguage(target Language, languages []Language) bool {
 	for _, lang := range languages {
 		if lang == target {
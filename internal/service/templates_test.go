@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -26,6 +27,26 @@ func TestTemplateGenerator_GenerateTemplate(t *testing.T) {
 		if !strings.Contains(template, "security:") {
 			t.Errorf("Expected security hooks to be included in empty project template")
 		}
+
+		if !strings.Contains(template, "\nversion: 2\n") {
+			t.Errorf("Expected the current configuration version to be declared, got:\n%s", template)
+		}
+	})
+
+	t.Run("WithoutGitleaks", func(t *testing.T) {
+		generator := NewTemplateGenerator()
+		generator.lookPath = func(file string) (string, error) {
+			return "", errors.New("not found")
+		}
+
+		template := generator.GenerateTemplate(&ProjectStructure{Structure: make(map[string][]string)})
+
+		if strings.Contains(template, "gitleaks detect") || strings.Contains(template, "name: \"Gitleaks\"") {
+			t.Errorf("Did not expect gitleaks when neither gitleaks nor go is available")
+		}
+		if !strings.Contains(template, "builtin: secrets") {
+			t.Errorf("Expected the builtin secret scanner as a fallback, got:\n%s", template)
+		}
 	})
 
 	t.Run("GoProject", func(t *testing.T) {
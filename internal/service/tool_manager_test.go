@@ -14,6 +14,7 @@ type MockShellRunner struct {
 		Output string
 		Err    error
 	}
+	LastEnv []string
 }
 
 // Run implements the ShellRunner interface.
@@ -25,6 +26,13 @@ func (r *MockShellRunner) Run(command string) (string, error) {
 	return "", errors.New("command not found")
 }
 
+// RunWithEnv implements the ShellRunner interface.
+
+func (r *MockShellRunner) RunWithEnv(command string, env []string) (string, error) {
+	r.LastEnv = env
+	return r.Run(command)
+}
+
 func TestToolManagerService_EnsureToolsInstalled(t *testing.T) {
 	mockRunner := &MockShellRunner{
 		Commands: make(map[string]struct {
@@ -0,0 +1,379 @@
+// Package shellparse parses shell commands into a syntax tree, so hook
+// commands can be checked for syntax errors and inspected structurally. It
+// reads POSIX syntax and the bash extensions hook commands commonly use, since
+// hooks run under bash or zsh.
+package shellparse
+
+import "strings"
+
+// Pos locates a node in the parsed source. Line and Column start at 1.
+type Pos struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Node is any node of the syntax tree.
+type Node interface {
+	node()
+}
+
+// Script is a parsed command line: a list of statements.
+type Script struct {
+	Stmts []*Stmt
+}
+
+// Stmt is a command with its redirections, negated with ! or run in the
+// background with &.
+type Stmt struct {
+	Pos        Pos
+	Cmd        Command
+	Redirs     []*Redirect
+	Negated    bool
+	Background bool
+}
+
+// Command is a simple command, a pipeline or list, or a compound command.
+type Command interface {
+	Node
+	command()
+}
+
+// SimpleCommand is a command name with its arguments, preceded by variable
+// assignments. Args is empty for a command made only of assignments.
+type SimpleCommand struct {
+	Pos     Pos
+	Assigns []*Assign
+	Args    []*Word
+}
+
+// Name returns the literal command name, or "" when there is none or it is
+// built from expansions.
+func (c *SimpleCommand) Name() string {
+	if len(c.Args) == 0 {
+		return ""
+	}
+	name, _ := c.Args[0].Literal()
+	return name
+}
+
+// Assign is a VAR=value prefix of a simple command, or a bash array
+// assignment VAR=(items). Name includes the [subscript] of an element.
+type Assign struct {
+	Name string
+	// Append is set for VAR+=value.
+	Append bool
+	Value  *Word
+	// Array holds the items of VAR=(items); Value is nil then.
+	Array []*Word
+}
+
+// BinaryCmd joins two statements with "|", "|&", "&&" or "||".
+type BinaryCmd struct {
+	Op   string
+	X, Y *Stmt
+}
+
+// Subshell is a list run in a subshell: ( list ).
+type Subshell struct {
+	Stmts []*Stmt
+}
+
+// Group is a list run in the current shell: { list; }.
+type Group struct {
+	Stmts []*Stmt
+}
+
+// IfClause is if/then/else. An elif is an IfClause alone in Else.
+type IfClause struct {
+	Cond, Then, Else []*Stmt
+}
+
+// LoopClause is a while or until loop.
+type LoopClause struct {
+	Until      bool
+	Cond, Body []*Stmt
+}
+
+// ForClause is a for loop. Items is nil when the loop has no "in" list.
+type ForClause struct {
+	Name  string
+	Items []*Word
+	Body  []*Stmt
+}
+
+// CaseClause is a case statement.
+type CaseClause struct {
+	Word  *Word
+	Items []*CaseItem
+}
+
+// CaseItem is one pattern list of a case statement with its commands.
+type CaseItem struct {
+	Patterns []*Word
+	Stmts    []*Stmt
+}
+
+// TestClause is a bash conditional expression: [[ words ]]. The operators
+// of the expression, such as == or &&, are words too.
+type TestClause struct {
+	Words []*Word
+}
+
+// ArithCmd is a bash arithmetic command: (( expr )).
+type ArithCmd struct {
+	Expr string
+}
+
+// FuncDecl declares a shell function.
+type FuncDecl struct {
+	Name string
+	Body *Stmt
+}
+
+// Redirect is an I/O redirection such as 2>&1 or <<EOF.
+type Redirect struct {
+	Pos Pos
+	// Fd is the explicit file descriptor, or "".
+	Fd     string
+	Op     string
+	Target *Word
+	// Heredoc is the body of a here-document.
+	Heredoc string
+}
+
+// Word is a shell word made of literal, quoted and expanded parts.
+type Word struct {
+	Pos   Pos
+	Parts []WordPart
+	// Raw is the word as written in the source.
+	Raw string
+}
+
+// Literal returns the value of a word without expansions, with its quotes
+// removed. It reports false when the word has expansions.
+func (w *Word) Literal() (string, bool) {
+	var b strings.Builder
+	var add func(parts []WordPart) bool
+	add = func(parts []WordPart) bool {
+		for _, part := range parts {
+			switch part := part.(type) {
+			case *Lit:
+				b.WriteString(part.Value)
+			case *SingleQuoted:
+				b.WriteString(part.Value)
+			case *DoubleQuoted:
+				if !add(part.Parts) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+		return true
+	}
+	if !add(w.Parts) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// HasUnquotedGlob reports whether the word has a *, ? or [ outside quotes.
+func (w *Word) HasUnquotedGlob() bool {
+	for _, part := range w.Parts {
+		if lit, ok := part.(*Lit); ok && strings.ContainsAny(lit.Value, "*?[") {
+			return true
+		}
+	}
+	return false
+}
+
+// WordPart is a part of a word.
+type WordPart interface {
+	Node
+	wordPart()
+}
+
+// Lit is unquoted literal text, with backslash escapes removed.
+type Lit struct {
+	Value string
+}
+
+// SingleQuoted is text in single quotes.
+type SingleQuoted struct {
+	Value string
+}
+
+// DoubleQuoted is text in double quotes, which may contain expansions.
+type DoubleQuoted struct {
+	Parts []WordPart
+}
+
+// ParamExp is a parameter expansion: $name, ${name} or ${name...}.
+type ParamExp struct {
+	Name string
+	// Expr is the text between the braces of ${...}, or the name.
+	Expr string
+}
+
+// CmdSubst is a command substitution: $(list) or `list`.
+type CmdSubst struct {
+	Stmts     []*Stmt
+	Backquote bool
+}
+
+// ProcSubst is a process substitution of bash and zsh: <(list) or >(list).
+type ProcSubst struct {
+	Op    string
+	Stmts []*Stmt
+}
+
+// ArithExp is an arithmetic expansion: $((expr)).
+type ArithExp struct {
+	Expr string
+}
+
+func (*Script) node()        {}
+func (*Stmt) node()          {}
+func (*SimpleCommand) node() {}
+func (*BinaryCmd) node()     {}
+func (*Subshell) node()      {}
+func (*Group) node()         {}
+func (*IfClause) node()      {}
+func (*LoopClause) node()    {}
+func (*ForClause) node()     {}
+func (*CaseClause) node()    {}
+func (*CaseItem) node()      {}
+func (*TestClause) node()    {}
+func (*ArithCmd) node()      {}
+func (*FuncDecl) node()      {}
+func (*Assign) node()        {}
+func (*Redirect) node()      {}
+func (*Word) node()          {}
+func (*Lit) node()           {}
+func (*SingleQuoted) node()  {}
+func (*DoubleQuoted) node()  {}
+func (*ParamExp) node()      {}
+func (*CmdSubst) node()      {}
+func (*ProcSubst) node()     {}
+func (*ArithExp) node()      {}
+
+func (*SimpleCommand) command() {}
+func (*BinaryCmd) command()     {}
+func (*Subshell) command()      {}
+func (*Group) command()         {}
+func (*IfClause) command()      {}
+func (*LoopClause) command()    {}
+func (*ForClause) command()     {}
+func (*CaseClause) command()    {}
+func (*TestClause) command()    {}
+func (*ArithCmd) command()      {}
+func (*FuncDecl) command()      {}
+
+func (*Lit) wordPart()          {}
+func (*SingleQuoted) wordPart() {}
+func (*DoubleQuoted) wordPart() {}
+func (*ParamExp) wordPart()     {}
+func (*CmdSubst) wordPart()     {}
+func (*ProcSubst) wordPart()    {}
+func (*ArithExp) wordPart()     {}
+
+// Walk calls fn for node and every node below it, depth first, including
+// the commands of command and process substitutions. It does not descend
+// below a node for which fn returns false.
+func Walk(node Node, fn func(Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+	stmts := func(list []*Stmt) {
+		for _, stmt := range list {
+			Walk(stmt, fn)
+		}
+	}
+	words := func(list []*Word) {
+		for _, word := range list {
+			Walk(word, fn)
+		}
+	}
+	parts := func(list []WordPart) {
+		for _, part := range list {
+			Walk(part, fn)
+		}
+	}
+
+	switch n := node.(type) {
+	case *Script:
+		stmts(n.Stmts)
+	case *Stmt:
+		if n.Cmd != nil {
+			Walk(n.Cmd, fn)
+		}
+		for _, redir := range n.Redirs {
+			Walk(redir, fn)
+		}
+	case *SimpleCommand:
+		for _, assign := range n.Assigns {
+			Walk(assign, fn)
+		}
+		words(n.Args)
+	case *Assign:
+		if n.Value != nil {
+			Walk(n.Value, fn)
+		}
+		words(n.Array)
+	case *BinaryCmd:
+		Walk(n.X, fn)
+		Walk(n.Y, fn)
+	case *Subshell:
+		stmts(n.Stmts)
+	case *Group:
+		stmts(n.Stmts)
+	case *IfClause:
+		stmts(n.Cond)
+		stmts(n.Then)
+		stmts(n.Else)
+	case *LoopClause:
+		stmts(n.Cond)
+		stmts(n.Body)
+	case *ForClause:
+		words(n.Items)
+		stmts(n.Body)
+	case *CaseClause:
+		Walk(n.Word, fn)
+		for _, item := range n.Items {
+			Walk(item, fn)
+		}
+	case *CaseItem:
+		words(n.Patterns)
+		stmts(n.Stmts)
+	case *TestClause:
+		words(n.Words)
+	case *FuncDecl:
+		Walk(n.Body, fn)
+	case *Redirect:
+		if n.Target != nil {
+			Walk(n.Target, fn)
+		}
+	case *Word:
+		parts(n.Parts)
+	case *DoubleQuoted:
+		parts(n.Parts)
+	case *CmdSubst:
+		stmts(n.Stmts)
+	case *ProcSubst:
+		stmts(n.Stmts)
+	}
+}
+
+// SimpleCommands returns every simple command below node, in source order,
+// including those in pipelines, lists, compound commands and substitutions.
+func SimpleCommands(node Node) []*SimpleCommand {
+	var calls []*SimpleCommand
+	Walk(node, func(n Node) bool {
+		if call, ok := n.(*SimpleCommand); ok {
+			calls = append(calls, call)
+		}
+		return true
+	})
+	return calls
+}
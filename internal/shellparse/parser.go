@@ -0,0 +1,1017 @@
+package shellparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError is a shell syntax error located in the parsed source.
+type SyntaxError struct {
+	Pos     Pos
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Pos.Line, e.Pos.Column, e.Message)
+}
+
+// Parse parses a shell command line: POSIX syntax, the <(list) and >(list)
+// process substitutions of bash and zsh, and the bash arrays, [[ ]] and
+// (( )) commands, function keyword, here-strings, &> and |& redirections and
+// $'...' quoting.
+func Parse(src string) (script *Script, err error) {
+	p := &parser{src: src}
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			script, err = nil, syntaxErr
+		}
+	}()
+
+	stmts := p.list()
+	if p.pos < len(p.src) {
+		p.unexpected()
+	}
+	return &Script{Stmts: stmts}, nil
+}
+
+// listTerminators end a list when they appear where a command is expected.
+var listTerminators = map[string]bool{
+	"then": true, "elif": true, "else": true, "fi": true,
+	"do": true, "done": true, "esac": true, "}": true,
+}
+
+// metaChars end an unquoted word.
+const metaChars = " \t\n;&|<>()"
+
+type parser struct {
+	src string
+	pos int
+	// heredocs wait for the next newline to read their bodies.
+	heredocs []*Redirect
+}
+
+func (p *parser) fail(offset int, format string, args ...interface{}) {
+	panic(&SyntaxError{Pos: p.position(offset), Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) position(offset int) Pos {
+	line := 1 + strings.Count(p.src[:offset], "\n")
+	column := offset - strings.LastIndex(p.src[:offset], "\n")
+	return Pos{Offset: offset, Line: line, Column: column}
+}
+
+func (p *parser) unexpected() {
+	if p.pos >= len(p.src) {
+		p.fail(p.pos, "unexpected end of input")
+	}
+	token := p.operator()
+	if token == "" {
+		token = p.peekWord()
+	}
+	if token == "\n" {
+		p.fail(p.pos, "unexpected newline")
+	}
+	p.fail(p.pos, "unexpected %q", token)
+}
+
+// skipBlanks skips spaces, tabs, escaped newlines and comments.
+func (p *parser) skipBlanks() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t':
+			p.pos++
+		case c == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\n':
+			p.pos += 2
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// skipNewlines skips blanks and newlines, reading pending here-documents.
+func (p *parser) skipNewlines() {
+	for {
+		p.skipBlanks()
+		if p.pos >= len(p.src) || p.src[p.pos] != '\n' {
+			return
+		}
+		p.pos++
+		p.readHeredocs()
+	}
+}
+
+var operators = []string{"&>>", "&>", "&&", "||", "|&", ";;", "<<<", "<<-", "<<", ">>", "<&", ">&", "<>", ">|", "|", "&", ";", "<", ">", "(", ")", "\n"}
+
+// redirectOps are the redirection operators.
+var redirectOps = map[string]bool{
+	"<": true, ">": true, ">>": true, "<&": true, ">&": true, "<>": true, ">|": true,
+	"<<": true, "<<-": true, "<<<": true, "&>": true, "&>>": true,
+}
+
+// declarationCommands are the bash builtins whose arguments may be array
+// assignments, as in local files=(a b).
+var declarationCommands = map[string]bool{"declare": true, "typeset": true, "local": true, "export": true, "readonly": true}
+
+// operator returns the operator at the current position, or "".
+func (p *parser) operator() string {
+	for _, op := range operators {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// peekWord returns the literal text of the next word without consuming it.
+func (p *parser) peekWord() string {
+	end := p.pos
+	for end < len(p.src) && !strings.ContainsRune(metaChars, rune(p.src[end])) {
+		end++
+	}
+	return p.src[p.pos:end]
+}
+
+// atReserved reports whether the next word is the unquoted reserved word.
+func (p *parser) atReserved(word string) bool {
+	return p.peekWord() == word
+}
+
+func (p *parser) expectReserved(word, context string) {
+	p.skipNewlines()
+	if !p.atReserved(word) {
+		if p.pos >= len(p.src) {
+			p.fail(p.pos, "unexpected end of input, expected %q to close %s", word, context)
+		}
+		p.fail(p.pos, "expected %q to close %s", word, context)
+	}
+	p.pos += len(word)
+}
+
+// atListEnd reports whether the list being parsed ends here.
+func (p *parser) atListEnd() bool {
+	if p.pos >= len(p.src) {
+		return true
+	}
+	switch p.operator() {
+	case ")":
+		return true
+	case ";;":
+		return true
+	}
+	return listTerminators[p.peekWord()]
+}
+
+// list parses statements separated by ;, & or newlines.
+func (p *parser) list() []*Stmt {
+	var stmts []*Stmt
+	for {
+		p.skipNewlines()
+		if p.atListEnd() {
+			return stmts
+		}
+		stmt := p.andOr()
+		p.skipBlanks()
+		switch p.operator() {
+		case "&":
+			stmt.Background = true
+			p.pos++
+		case ";":
+			p.pos++
+		case "\n", ")", ";;":
+		default:
+			if !p.atListEnd() {
+				p.unexpected()
+			}
+		}
+		stmts = append(stmts, stmt)
+	}
+}
+
+// andOr parses pipelines joined by && and ||.
+func (p *parser) andOr() *Stmt {
+	stmt := p.pipeline()
+	for {
+		p.skipBlanks()
+		op := p.operator()
+		if op != "&&" && op != "||" {
+			return stmt
+		}
+		opPos := p.pos
+		p.pos += 2
+		p.skipNewlines()
+		if p.atListEnd() {
+			p.fail(opPos, "%q must be followed by a command", op)
+		}
+		stmt = &Stmt{Pos: stmt.Pos, Cmd: &BinaryCmd{Op: op, X: stmt, Y: p.pipeline()}}
+	}
+}
+
+// pipeline parses commands joined by |, optionally negated with !.
+func (p *parser) pipeline() *Stmt {
+	p.skipBlanks()
+	negated := false
+	if p.atReserved("!") {
+		negated = true
+		p.pos++
+		p.skipBlanks()
+	}
+	stmt := p.command()
+	for {
+		p.skipBlanks()
+		op := p.operator()
+		if op != "|" && op != "|&" {
+			break
+		}
+		opPos := p.pos
+		p.pos += len(op)
+		p.skipNewlines()
+		if p.atListEnd() {
+			p.fail(opPos, "%q must be followed by a command", op)
+		}
+		stmt = &Stmt{Pos: stmt.Pos, Cmd: &BinaryCmd{Op: op, X: stmt, Y: p.command()}}
+	}
+	if negated {
+		stmt = &Stmt{Pos: stmt.Pos, Cmd: stmt.Cmd, Redirs: stmt.Redirs, Negated: true}
+	}
+	return stmt
+}
+
+// command parses a compound command, a function declaration or a simple command.
+func (p *parser) command() *Stmt {
+	p.skipBlanks()
+	stmt := &Stmt{Pos: p.position(p.pos)}
+	if p.pos >= len(p.src) {
+		p.fail(p.pos, "unexpected end of input, expected a command")
+	}
+
+	switch op := p.operator(); {
+	case op == "(":
+		if arith := p.arithCmd(); arith != nil {
+			stmt.Cmd = arith
+		} else {
+			p.pos++
+			stmts := p.list()
+			p.closeParen(stmt.Pos.Offset, "subshell")
+			stmt.Cmd = &Subshell{Stmts: stmts}
+		}
+		p.redirects(stmt)
+		return stmt
+	case op == "" || redirectOps[op]:
+	default:
+		p.unexpected()
+	}
+
+	switch word := p.peekWord(); word {
+	case "{":
+		p.pos++
+		stmts := p.list()
+		p.expectReserved("}", "the { group")
+		stmt.Cmd = &Group{Stmts: stmts}
+	case "if":
+		p.pos += 2
+		stmt.Cmd = p.ifClause()
+	case "while", "until":
+		p.pos += len(word)
+		cond := p.list()
+		p.expectReserved("do", word)
+		body := p.list()
+		p.expectReserved("done", word)
+		stmt.Cmd = &LoopClause{Until: word == "until", Cond: cond, Body: body}
+	case "for":
+		p.pos += 3
+		stmt.Cmd = p.forClause()
+	case "case":
+		p.pos += 4
+		stmt.Cmd = p.caseClause()
+	case "[[":
+		p.pos += 2
+		stmt.Cmd = p.testClause(stmt.Pos.Offset)
+	case "function":
+		p.pos += len(word)
+		stmt.Cmd = p.functionKeyword()
+		return stmt
+	default:
+		if listTerminators[word] {
+			p.fail(p.pos, "unexpected %q", word)
+		}
+		if decl := p.funcDecl(); decl != nil {
+			stmt.Cmd = decl
+			return stmt
+		}
+		stmt.Cmd = p.simpleCommand(stmt)
+		return stmt
+	}
+	p.redirects(stmt)
+	return stmt
+}
+
+func (p *parser) closeParen(open int, context string) {
+	p.skipNewlines()
+	if p.operator() != ")" {
+		p.fail(open, "unclosed %s: missing ')'", context)
+	}
+	p.pos++
+}
+
+func (p *parser) ifClause() *IfClause {
+	clause := &IfClause{Cond: p.list()}
+	p.expectReserved("then", "if")
+	clause.Then = p.list()
+	p.skipNewlines()
+	switch {
+	case p.atReserved("elif"):
+		elifPos := p.position(p.pos)
+		p.pos += 4
+		clause.Else = []*Stmt{{Pos: elifPos, Cmd: p.ifClause()}}
+		return clause
+	case p.atReserved("else"):
+		p.pos += 4
+		clause.Else = p.list()
+	}
+	p.expectReserved("fi", "if")
+	return clause
+}
+
+func (p *parser) forClause() *ForClause {
+	p.skipBlanks()
+	name := p.peekWord()
+	if !isName(name) {
+		p.fail(p.pos, "invalid for loop variable %q", name)
+	}
+	p.pos += len(name)
+	clause := &ForClause{Name: name}
+
+	p.skipNewlines()
+	if p.atReserved("in") {
+		p.pos += 2
+		clause.Items = []*Word{}
+		for {
+			p.skipBlanks()
+			if op := p.operator(); op == ";" || op == "\n" {
+				p.pos++
+				break
+			}
+			word := p.word()
+			if word == nil {
+				p.unexpected()
+			}
+			clause.Items = append(clause.Items, word)
+		}
+	} else if p.operator() == ";" {
+		p.pos++
+	}
+	p.expectReserved("do", "for")
+	clause.Body = p.list()
+	p.expectReserved("done", "for")
+	return clause
+}
+
+func (p *parser) caseClause() *CaseClause {
+	p.skipBlanks()
+	clause := &CaseClause{Word: p.word()}
+	if clause.Word == nil {
+		p.fail(p.pos, "expected a word after case")
+	}
+	p.expectReserved("in", "case")
+
+	for {
+		p.skipNewlines()
+		if p.atReserved("esac") {
+			p.pos += 4
+			return clause
+		}
+		if p.pos >= len(p.src) {
+			p.fail(p.pos, "unexpected end of input, expected \"esac\" to close case")
+		}
+
+		item := &CaseItem{}
+		if p.operator() == "(" {
+			p.pos++
+		}
+		for {
+			p.skipBlanks()
+			pattern := p.word()
+			if pattern == nil {
+				p.fail(p.pos, "expected a case pattern")
+			}
+			item.Patterns = append(item.Patterns, pattern)
+			p.skipBlanks()
+			if p.operator() != "|" {
+				break
+			}
+			p.pos++
+		}
+		if p.operator() != ")" {
+			p.fail(p.pos, "expected ')' after case pattern")
+		}
+		p.pos++
+		item.Stmts = p.list()
+		clause.Items = append(clause.Items, item)
+
+		p.skipNewlines()
+		if p.operator() == ";;" {
+			p.pos += 2
+		} else if !p.atReserved("esac") {
+			p.fail(p.pos, "expected \";;\" or \"esac\" after case item")
+		}
+	}
+}
+
+// funcDecl parses "name() compound-command", or returns nil.
+func (p *parser) funcDecl() *FuncDecl {
+	start := p.pos
+	name := p.peekWord()
+	if name == "" {
+		return nil
+	}
+	p.pos += len(name)
+	p.skipBlanks()
+	if !strings.HasPrefix(p.src[p.pos:], "(") {
+		p.pos = start
+		return nil
+	}
+	p.pos++
+	p.skipBlanks()
+	if !strings.HasPrefix(p.src[p.pos:], ")") {
+		p.pos = start
+		return nil
+	}
+	p.pos++
+	p.skipNewlines()
+	return &FuncDecl{Name: name, Body: p.command()}
+}
+
+// functionKeyword parses the rest of "function name [()] compound-command".
+func (p *parser) functionKeyword() *FuncDecl {
+	p.skipBlanks()
+	name := p.peekWord()
+	if name == "" {
+		p.fail(p.pos, "expected a function name")
+	}
+	p.pos += len(name)
+	p.skipBlanks()
+	if strings.HasPrefix(p.src[p.pos:], "()") {
+		p.pos += 2
+	}
+	p.skipNewlines()
+	return &FuncDecl{Name: name, Body: p.command()}
+}
+
+// arithCmd parses a (( expr )) arithmetic command, or returns nil when the
+// parentheses open nested subshells instead, as in ((cd a); b).
+func (p *parser) arithCmd() *ArithCmd {
+	if !strings.HasPrefix(p.src[p.pos:], "((") {
+		return nil
+	}
+	end := matchingParens(p.src, p.pos+2)
+	if end < 0 || !strings.HasPrefix(p.src[end:], "))") {
+		return nil
+	}
+	expr := p.src[p.pos+2 : end]
+	p.pos = end + 2
+	return &ArithCmd{Expr: expr}
+}
+
+// testClause parses the words of a [[ ]] conditional expression. The
+// operators &&, ||, !, (, ), < and > are words there, and the regular
+// expression after =~ may contain unquoted parentheses and |.
+func (p *parser) testClause(open int) *TestClause {
+	clause := &TestClause{}
+	for {
+		p.skipNewlines()
+		if p.pos >= len(p.src) {
+			p.fail(open, "unexpected end of input, expected \"]]\" to close [[")
+		}
+		afterRegexOp := len(clause.Words) > 0 && clause.Words[len(clause.Words)-1].Raw == "=~"
+		if p.atReserved("]]") {
+			if afterRegexOp {
+				p.fail(p.pos, "expected a regular expression after =~")
+			}
+			p.pos += 2
+			break
+		}
+		var word *Word
+		if op := p.operator(); op == "&&" || op == "||" || op == "(" || op == ")" || op == "<" || op == ">" {
+			word = &Word{Pos: p.position(p.pos), Parts: []WordPart{&Lit{Value: op}}, Raw: op}
+			p.pos += len(op)
+		} else if afterRegexOp {
+			word = p.regexWord()
+		} else if word = p.word(); word == nil {
+			p.unexpected()
+		}
+		clause.Words = append(clause.Words, word)
+	}
+	if len(clause.Words) == 0 {
+		p.fail(open, "empty [[ ]] expression")
+	}
+	return clause
+}
+
+// regexWord parses the regular expression operand of =~, which ends at a
+// blank outside parentheses.
+func (p *parser) regexWord() *Word {
+	start := p.pos
+	word := &Word{Pos: p.position(start)}
+	depth := 0
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case (c == ' ' || c == '\t' || c == '\n') && depth == 0:
+		case c == '(' || c == '|' || (c == ')' && depth > 0):
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+			}
+			word.Parts = append(word.Parts, &Lit{Value: string(c)})
+			p.pos++
+			continue
+		case strings.IndexByte(metaChars, c) >= 0 && depth == 0:
+		case strings.IndexByte(metaChars, c) >= 0:
+			word.Parts = append(word.Parts, &Lit{Value: string(c)})
+			p.pos++
+			continue
+		default:
+			part := p.word()
+			word.Parts = append(word.Parts, part.Parts...)
+			continue
+		}
+		break
+	}
+	if p.pos == start {
+		p.fail(p.pos, "expected a regular expression after =~")
+	}
+	word.Raw = p.src[start:p.pos]
+	return word
+}
+
+// simpleCommand parses assignments, words and redirections.
+func (p *parser) simpleCommand(stmt *Stmt) *SimpleCommand {
+	call := &SimpleCommand{Pos: stmt.Pos}
+	for {
+		p.skipBlanks()
+		if p.redirect(stmt) {
+			continue
+		}
+		if p.pos >= len(p.src) || (p.operator() != "" && !p.atProcSubst()) {
+			break
+		}
+		word := p.word()
+		if word == nil {
+			break
+		}
+		if len(call.Args) == 0 {
+			if assign := p.assignment(word); assign != nil {
+				call.Assigns = append(call.Assigns, assign)
+				continue
+			}
+		} else if declarationCommands[call.Name()] && strings.HasSuffix(word.Raw, "=") && strings.HasPrefix(p.src[p.pos:], "(") {
+			// local files=(a b): the items are arguments of the builtin.
+			if assign := p.assignment(word); assign != nil {
+				word.Raw = p.src[word.Pos.Offset:p.pos]
+				call.Args = append(call.Args, word)
+				for _, item := range assign.Array {
+					call.Args = append(call.Args, item)
+				}
+				continue
+			}
+		}
+		call.Args = append(call.Args, word)
+	}
+	if len(call.Args) == 0 && len(call.Assigns) == 0 && len(stmt.Redirs) == 0 {
+		p.unexpected()
+	}
+	return call
+}
+
+// assignment parses a word of the form NAME=value, NAME+=value or
+// NAME[subscript]=value, reading the items of an array after NAME=( . It
+// returns nil for other words.
+func (p *parser) assignment(word *Word) *Assign {
+	target, value, ok := strings.Cut(word.Raw, "=")
+	if !ok {
+		return nil
+	}
+	assign := &Assign{}
+	target, assign.Append = strings.CutSuffix(target, "+")
+	name := target
+	if i := strings.IndexByte(target, '['); i > 0 && strings.HasSuffix(target, "]") {
+		name = target[:i]
+	}
+	if !isName(name) {
+		return nil
+	}
+	assign.Name = target
+
+	if value == "" && strings.HasPrefix(p.src[p.pos:], "(") {
+		open := p.pos
+		p.pos++
+		assign.Array = []*Word{}
+		for {
+			p.skipNewlines()
+			if p.operator() == ")" {
+				p.pos++
+				return assign
+			}
+			if p.pos >= len(p.src) {
+				p.fail(open, "unclosed array: missing ')'")
+			}
+			item := p.word()
+			if item == nil {
+				p.unexpected()
+			}
+			assign.Array = append(assign.Array, item)
+		}
+	}
+	assign.Value = p.subWord(word.Pos.Offset+len(word.Raw)-len(value), value)
+	return assign
+}
+
+// subWord parses a part of a word already read from the source.
+func (p *parser) subWord(offset int, raw string) *Word {
+	sub := &parser{src: p.src[:offset+len(raw)], pos: offset}
+	word := sub.word()
+	if word == nil {
+		return &Word{Pos: p.position(offset)}
+	}
+	return word
+}
+
+// atProcSubst reports whether a process substitution starts here.
+func (p *parser) atProcSubst() bool {
+	rest := p.src[p.pos:]
+	return strings.HasPrefix(rest, "<(") || strings.HasPrefix(rest, ">(")
+}
+
+func (p *parser) redirects(stmt *Stmt) {
+	for {
+		p.skipBlanks()
+		if !p.redirect(stmt) {
+			return
+		}
+	}
+}
+
+// redirect parses a redirection, with an optional file descriptor, into stmt.
+func (p *parser) redirect(stmt *Stmt) bool {
+	start := p.pos
+	fdEnd := p.pos
+	for fdEnd < len(p.src) && p.src[fdEnd] >= '0' && p.src[fdEnd] <= '9' {
+		fdEnd++
+	}
+	p.pos = fdEnd
+	op := p.operator()
+	if fdEnd == start && p.atProcSubst() {
+		p.pos = start
+		return false
+	}
+	if !redirectOps[op] || (fdEnd > start && (op == "&>" || op == "&>>")) {
+		p.pos = start
+		return false
+	}
+
+	redir := &Redirect{Pos: p.position(start), Fd: p.src[start:fdEnd], Op: op}
+	p.pos += len(op)
+	p.skipBlanks()
+	redir.Target = p.word()
+	if redir.Target == nil {
+		p.fail(p.pos, "missing target for redirection %q", op)
+	}
+	if op == "<<" || op == "<<-" {
+		p.heredocs = append(p.heredocs, redir)
+	}
+	stmt.Redirs = append(stmt.Redirs, redir)
+	return true
+}
+
+// readHeredocs reads the bodies of the pending here-documents, which start
+// after the newline just consumed.
+func (p *parser) readHeredocs() {
+	pending := p.heredocs
+	p.heredocs = nil
+	for _, redir := range pending {
+		delimiter, _ := redir.Target.Literal()
+		var body strings.Builder
+		for {
+			if p.pos >= len(p.src) {
+				p.fail(redir.Pos.Offset, "here-document delimited by %q is not closed", delimiter)
+			}
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			line := p.src[p.pos:]
+			if end >= 0 {
+				line = p.src[p.pos : p.pos+end]
+				p.pos += end + 1
+			} else {
+				p.pos = len(p.src)
+			}
+			if redir.Op == "<<-" {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == delimiter {
+				break
+			}
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+		redir.Heredoc = body.String()
+	}
+}
+
+// word parses a word, or returns nil when none starts here.
+func (p *parser) word() *Word {
+	start := p.pos
+	word := &Word{Pos: p.position(start)}
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			word.Parts = append(word.Parts, &Lit{Value: lit.String()})
+			lit.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if p.atProcSubst() {
+			flush()
+			word.Parts = append(word.Parts, p.procSubst())
+			continue
+		}
+		if strings.IndexByte(metaChars, c) >= 0 {
+			break
+		}
+		switch c {
+		case '\\':
+			if p.pos+1 < len(p.src) {
+				if p.src[p.pos+1] != '\n' {
+					lit.WriteByte(p.src[p.pos+1])
+				}
+				p.pos += 2
+			} else {
+				p.pos++
+			}
+		case '\'':
+			flush()
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				p.fail(p.pos, "unterminated single quote")
+			}
+			word.Parts = append(word.Parts, &SingleQuoted{Value: p.src[p.pos+1 : p.pos+1+end]})
+			p.pos += end + 2
+		case '"':
+			flush()
+			word.Parts = append(word.Parts, p.doubleQuoted())
+		case '$':
+			switch {
+			case strings.HasPrefix(p.src[p.pos:], "$'"):
+				flush()
+				word.Parts = append(word.Parts, p.ansiQuoted())
+			case strings.HasPrefix(p.src[p.pos:], `$"`):
+				// A locale-translated string is a double-quoted string.
+				flush()
+				p.pos++
+				word.Parts = append(word.Parts, p.doubleQuoted())
+			default:
+				if part := p.dollar(); part != nil {
+					flush()
+					word.Parts = append(word.Parts, part)
+				} else {
+					lit.WriteByte(c)
+					p.pos++
+				}
+			}
+		case '`':
+			flush()
+			word.Parts = append(word.Parts, p.backquote())
+		default:
+			lit.WriteByte(c)
+			p.pos++
+		}
+	}
+	flush()
+
+	if p.pos == start {
+		return nil
+	}
+	word.Raw = p.src[start:p.pos]
+	return word
+}
+
+// ansiQuoted parses a $'...' string, in which a backslash escapes the next
+// character. Its value keeps the escapes as written.
+func (p *parser) ansiQuoted() *SingleQuoted {
+	open := p.pos
+	var value strings.Builder
+	for p.pos += 2; p.pos < len(p.src); p.pos++ {
+		switch c := p.src[p.pos]; c {
+		case '\'':
+			p.pos++
+			return &SingleQuoted{Value: value.String()}
+		case '\\':
+			value.WriteByte(c)
+			if p.pos+1 < len(p.src) {
+				p.pos++
+				value.WriteByte(p.src[p.pos])
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	p.fail(open, "unterminated $' quote")
+	return nil
+}
+
+func (p *parser) doubleQuoted() *DoubleQuoted {
+	open := p.pos
+	p.pos++
+	quoted := &DoubleQuoted{}
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			quoted.Parts = append(quoted.Parts, &Lit{Value: lit.String()})
+			lit.Reset()
+		}
+	}
+
+	for {
+		if p.pos >= len(p.src) {
+			p.fail(open, "unterminated double quote")
+		}
+		switch c := p.src[p.pos]; c {
+		case '"':
+			p.pos++
+			flush()
+			return quoted
+		case '\\':
+			if p.pos+1 < len(p.src) && strings.IndexByte("$`\"\\\n", p.src[p.pos+1]) >= 0 {
+				if p.src[p.pos+1] != '\n' {
+					lit.WriteByte(p.src[p.pos+1])
+				}
+				p.pos += 2
+			} else {
+				lit.WriteByte(c)
+				p.pos++
+			}
+		case '$':
+			if part := p.dollar(); part != nil {
+				flush()
+				quoted.Parts = append(quoted.Parts, part)
+			} else {
+				lit.WriteByte(c)
+				p.pos++
+			}
+		case '`':
+			flush()
+			quoted.Parts = append(quoted.Parts, p.backquote())
+		default:
+			lit.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// dollar parses an expansion starting with $, or returns nil for a literal $.
+func (p *parser) dollar() WordPart {
+	open := p.pos
+	rest := p.src[p.pos+1:]
+	switch {
+	case strings.HasPrefix(rest, "(("):
+		end := matchingParens(p.src, p.pos+3)
+		if end < 0 || !strings.HasPrefix(p.src[end:], "))") {
+			p.fail(open, "unterminated arithmetic expansion")
+		}
+		expr := p.src[p.pos+3 : end]
+		p.pos = end + 2
+		return &ArithExp{Expr: expr}
+	case strings.HasPrefix(rest, "("):
+		p.pos += 2
+		stmts := p.list()
+		p.skipNewlines()
+		if p.operator() != ")" {
+			p.fail(open, "unterminated command substitution: missing ')'")
+		}
+		p.pos++
+		return &CmdSubst{Stmts: stmts}
+	case strings.HasPrefix(rest, "{"):
+		end := p.pos + 2
+		depth := 1
+		for end < len(p.src) && depth > 0 {
+			switch p.src[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			case '\\':
+				end++
+			}
+			end++
+		}
+		if depth > 0 {
+			p.fail(open, "unterminated parameter expansion: missing '}'")
+		}
+		expr := p.src[p.pos+2 : end-1]
+		if expr == "" {
+			p.fail(open, "bad substitution: empty ${}")
+		}
+		p.pos = end
+		name := expr
+		if i := strings.IndexAny(expr[1:], ":-=+?%#/"); i >= 0 {
+			name = expr[:i+1]
+		}
+		return &ParamExp{Name: strings.TrimPrefix(name, "#"), Expr: expr}
+	case rest != "" && strings.IndexByte("@*#?-$!0123456789", rest[0]) >= 0:
+		p.pos += 2
+		return &ParamExp{Name: rest[:1], Expr: rest[:1]}
+	case rest != "" && isNameStart(rest[0]):
+		end := 1
+		for end < len(rest) && isNameChar(rest[end]) {
+			end++
+		}
+		p.pos += 1 + end
+		return &ParamExp{Name: rest[:end], Expr: rest[:end]}
+	}
+	return nil
+}
+
+// procSubst parses a <(list) or >(list) process substitution.
+func (p *parser) procSubst() *ProcSubst {
+	open := p.pos
+	op := p.src[p.pos : p.pos+2]
+	p.pos += 2
+	stmts := p.list()
+	p.skipNewlines()
+	if p.operator() != ")" {
+		p.fail(open, "unterminated process substitution: missing ')'")
+	}
+	p.pos++
+	return &ProcSubst{Op: op, Stmts: stmts}
+}
+
+// backquote parses a `command` substitution.
+func (p *parser) backquote() *CmdSubst {
+	open := p.pos
+	var inner strings.Builder
+	end := p.pos + 1
+	for ; end < len(p.src) && p.src[end] != '`'; end++ {
+		if p.src[end] == '\\' && end+1 < len(p.src) && strings.IndexByte("$`\\", p.src[end+1]) >= 0 {
+			end++
+		}
+		inner.WriteByte(p.src[end])
+	}
+	if end >= len(p.src) {
+		p.fail(open, "unterminated backquote substitution")
+	}
+	p.pos = end + 1
+
+	script, err := Parse(inner.String())
+	if err != nil {
+		syntaxErr := err.(*SyntaxError)
+		p.fail(open+1, "in backquote substitution: %s", syntaxErr.Message)
+	}
+	return &CmdSubst{Stmts: script.Stmts, Backquote: true}
+}
+
+// matchingParens returns the offset of the ) closing an arithmetic
+// expression starting at start, or -1.
+func matchingParens(src string, start int) int {
+	depth := 0
+	for i := start; i < len(src); i++ {
+		switch src[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func isName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isNameChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,169 @@
+package shellparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func commandNames(t *testing.T, src string) []string {
+	t.Helper()
+	script, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse(%q) failed: %v", src, err)
+	}
+	names := []string{}
+	for _, cmd := range SimpleCommands(script) {
+		names = append(names, cmd.Name())
+	}
+	return names
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src   string
+		names []string
+	}{
+		{`echo "it's fine"`, []string{"echo"}},
+		{`gofmt -l . | grep . && exit 1 || true`, []string{"gofmt", "grep", "exit", "true"}},
+		{`FOO=bar GOFLAGS=-mod=mod go test ./...`, []string{"go"}},
+		{`(cd web && npm test) > out.log 2>&1`, []string{"cd", "npm"}},
+		{`{ echo a; echo b; } | sort`, []string{"echo", "echo", "sort"}},
+		{`if [ -f go.mod ]; then go vet ./...; elif true; then :; else echo none; fi`, []string{"[", "go", "true", ":", "echo"}},
+		{`for f in $(git diff --name-only); do gofmt -l "$f"; done`, []string{"git", "gofmt"}},
+		{`while read -r line; do echo "$line"; done < files.txt`, []string{"read", "echo"}},
+		{`case "$1" in *.go|*.mod) go build ;; *) echo skip ;; esac`, []string{"go", "echo"}},
+		{"echo `date +%s` $(( 1 + 2 )) ${HOME:-/tmp}", []string{"echo", "date"}},
+		{`diff <(sort a) <(sort b)`, []string{"diff", "sort", "sort"}},
+		{"cat <<EOF | sh\necho $x )\nEOF\necho done", []string{"cat", "sh", "echo"}},
+		{`test '{push_remote_ref}' != refs/heads/main || git merge-base --is-ancestor {push_from} {push_to}`, []string{"test", "git"}},
+		{`f() { echo hi; }; f # comment ; rm -rf /`, []string{"echo", "f"}},
+		{"npm ci &&\n  npm test", []string{"npm", "npm"}},
+	}
+
+	for _, tt := range tests {
+		if names := commandNames(t, tt.src); !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("Parse(%q): expected commands %q, got %q", tt.src, tt.names, names)
+		}
+	}
+}
+
+func TestParse_Bash(t *testing.T) {
+	tests := []struct {
+		src   string
+		names []string
+	}{
+		{`files=(a b); gofmt -l "${files[@]}"`, []string{"", "gofmt"}},
+		{"args+=(--fix\n  --quiet) count+=1 arr[0]=x eslint \"${args[@]}\"", []string{"eslint"}},
+		{`local dirs=(cmd internal) name=x; go vet "${dirs[@]}"`, []string{"local", "go"}},
+		{`grep -q x <<< "$y" && echo found`, []string{"grep", "echo"}},
+		{`[[ $a =~ ^x(y|z)$ ]] && echo match`, []string{"echo"}},
+		{`[[ -n "$CI" && ( $branch == main || $branch < v2 ) ]] || exit 0`, []string{"exit"}},
+		{`(( count > 0 )) && echo "$count"`, []string{"echo"}},
+		{`((cd web; npm test) && make)`, []string{"cd", "npm", "make"}},
+		{`function check { go vet ./...; }; function lint() { golangci-lint run; }; check`, []string{"go", "golangci-lint", "check"}},
+		{`go test ./... &> test.log; go build ./... &>> build.log`, []string{"go", "go"}},
+		{`go test ./... |& tee test.log`, []string{"go", "tee"}},
+		{`printf $'%s\n' $'it\'s' $"done"`, []string{"printf"}},
+	}
+
+	for _, tt := range tests {
+		if names := commandNames(t, tt.src); !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("Parse(%q): expected commands %q, got %q", tt.src, tt.names, names)
+		}
+	}
+
+	script, err := Parse(`[[ $a =~ ^x(y|z)$ ]]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := script.Stmts[0].Cmd.(*TestClause).Words
+	if len(words) != 3 || words[2].Raw != "^x(y|z)$" {
+		t.Errorf("Expected the regular expression to be one word, got %d words", len(words))
+	}
+
+	script, err = Parse("files=(a 'b c'\n  $d) go test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assign := script.Stmts[0].Cmd.(*SimpleCommand).Assigns[0]
+	if assign.Name != "files" || assign.Value != nil || len(assign.Array) != 3 || assign.Array[1].Raw != "'b c'" {
+		t.Errorf("Expected an array assignment of 3 items, got %+v", assign)
+	}
+}
+
+func TestParse_BashSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{`files=(a b`, `1:7: unclosed array: missing ')'`},
+		{`[[ -f go.mod`, `1:1: unexpected end of input, expected "]]" to close [[`},
+		{`[[ ]]`, `1:1: empty [[ ]] expression`},
+		{`[[ $a =~ ]]`, `1:10: expected a regular expression after =~`},
+		{`echo $'unterminated`, `1:6: unterminated $' quote`},
+		{`go test |&`, `1:9: "|&" must be followed by a command`},
+		{`grep x <<<`, `1:11: missing target for redirection "<<<"`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil {
+			t.Errorf("Parse(%q): expected an error", tt.src)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("Parse(%q): expected %q, got %q", tt.src, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestParse_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{`echo 'hello world`, `1:6: unterminated single quote`},
+		{`echo "hello`, `1:6: unterminated double quote`},
+		{`echo $(ls`, `1:6: unterminated command substitution: missing ')'`},
+		{`echo ${HOME`, `1:6: unterminated parameter expansion: missing '}'`},
+		{`go vet ./... &&`, `1:14: "&&" must be followed by a command`},
+		{`| grep x`, `1:1: unexpected "|"`},
+		{`echo )`, `1:6: unexpected ")"`},
+		{`if true; then echo`, `1:19: unexpected end of input, expected "fi" to close if`},
+		{`{ echo a }`, `1:11: unexpected end of input, expected "}" to close the { group`},
+		{"for f in a b; do\n  echo $f\n", `3:1: unexpected end of input, expected "done" to close for`},
+		{`echo a > `, `1:10: missing target for redirection ">"`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil {
+			t.Errorf("Parse(%q): expected an error", tt.src)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("Parse(%q): expected %q, got %q", tt.src, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestWord(t *testing.T) {
+	script, err := Parse(`rm -rf "$DIR"/ 'a b'c * "*"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := script.Stmts[0].Cmd.(*SimpleCommand).Args
+
+	if _, ok := args[2].Literal(); ok {
+		t.Errorf("Expected %s not to be literal", args[2].Raw)
+	}
+	if value, ok := args[3].Literal(); !ok || value != "a bc" {
+		t.Errorf("Expected literal 'a bc', got %q (%v)", value, ok)
+	}
+	if !args[4].HasUnquotedGlob() || args[5].HasUnquotedGlob() {
+		t.Errorf("Expected only the unquoted * to glob")
+	}
+	if args[2].Raw != `"$DIR"/` {
+		t.Errorf("Expected the raw word, got %q", args[2].Raw)
+	}
+}